@@ -0,0 +1,575 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf16"
+)
+
+// CanonicalVersion identifies the canonical encoding of dataset documents
+// produced by this package. The version pairs the encoding scheme with the
+// dataset spec version, any change to either produces a new version & new
+// hashes. Version "jcs1:0" is defined as:
+//
+//  1. build a JSON object from the fields of the dataset or component listed
+//     below. The field set is fixed by the version: fields added to the Go
+//     types, or renamed in their JSON encoding, don't change canonical bytes.
+//     Empty values are omitted unless marked "always". "qri" is the kind of
+//     the document, defaulting to the current kind when unset
+//  2. serialize with the JSON Canonicalization Scheme, RFC 8785
+//
+// The fields of version "jcs1:0" are:
+//
+//	dataset:   bodyPath, commit, meta, previousPath, qri (always), readme,
+//	           stats, structure, transform, viz
+//	commit:    author, message, qri (always), signature,
+//	           timestamp (always, RFC 3339 in UTC), title (always)
+//	meta:      accessURL, accrualPeriodicity, citations, contributors,
+//	           description, downloadURL, homeURL, identifier, keywords,
+//	           language, license, qri (always), readmeURL, theme, title,
+//	           version, and any additional metadata fields
+//	readme:    format, qri (always), renderedPath, scriptBytes, scriptPath
+//	stats:     qri (always), stats
+//	structure: checksum, compression, depth, encoding, entries, errCount,
+//	           format (always), formatConfig, length, qri (always), schema,
+//	           strict
+//	transform: config, qri (always), resources, scriptBytes, scriptPath,
+//	           syntax, syntaxVersion
+//	viz:       format, qri (always), renderedPath, scriptBytes, scriptPath
+//	user:      email, id, name
+//	citation:  email, name, url
+//	license:   type, url
+//
+// Components of a dataset that only hold a path are encoded as the path
+// string. Script bytes are base64 strings, transform resources are objects
+// with a path. Schemas, configs, stats & additional metadata fields are
+// encoded as the JSON values they hold.
+//
+// Canonical hashes are the base58-encoded SHA2-256 multihash of the canonical
+// bytes, as produced by HashBytes
+const CanonicalVersion = "jcs1:" + CurrentSpecVersion
+
+// ObjectMarshaler is implemented by dataset components, which can always be
+// encoded as a JSON object
+type ObjectMarshaler interface {
+	MarshalJSONObject() ([]byte, error)
+}
+
+// Canonical gives the canonical encoding of a dataset component. c must be
+// one of the component types of a dataset document
+func Canonical(c ObjectMarshaler) ([]byte, error) {
+	var (
+		obj canonicalObject
+		err error
+	)
+	switch x := c.(type) {
+	case *Commit:
+		obj = canonicalCommit(x)
+	case *Meta:
+		obj, err = canonicalMeta(x)
+	case *Readme:
+		obj = canonicalReadme(x)
+	case *Stats:
+		obj, err = canonicalStats(x)
+	case *Structure:
+		obj, err = canonicalStructure(x)
+	case Structure:
+		obj, err = canonicalStructure(&x)
+	case *Transform:
+		obj, err = canonicalTransform(x)
+	case Transform:
+		obj, err = canonicalTransform(&x)
+	case *Viz:
+		obj = canonicalViz(x)
+	default:
+		return nil, fmt.Errorf("unsupported type for canonical encoding: %T", c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return canonicalEncode(map[string]interface{}(obj))
+}
+
+// CanonicalHash gives the hash of the canonical encoding of a component
+func CanonicalHash(c ObjectMarshaler) (string, error) {
+	data, err := Canonical(c)
+	if err != nil {
+		return "", err
+	}
+	return HashBytes(data)
+}
+
+// CanonicalDataset gives the canonical encoding of a dataset document
+func CanonicalDataset(ds *Dataset) ([]byte, error) {
+	obj := canonicalObject{"qri": kindOr(ds.Qri, KindDataset)}
+	obj.setString("bodyPath", ds.BodyPath)
+	obj.setString("previousPath", ds.PreviousPath)
+
+	var err error
+	if cm := ds.Commit; cm != nil && err == nil {
+		err = obj.setComponent("commit", cm.Path, cm.IsEmpty(), func() (canonicalObject, error) {
+			return canonicalCommit(cm), nil
+		})
+	}
+	if md := ds.Meta; md != nil && err == nil {
+		err = obj.setComponent("meta", md.Path, md.IsEmpty(), func() (canonicalObject, error) {
+			return canonicalMeta(md)
+		})
+	}
+	if r := ds.Readme; r != nil && err == nil {
+		err = obj.setComponent("readme", r.Path, r.IsEmpty(), func() (canonicalObject, error) {
+			return canonicalReadme(r), nil
+		})
+	}
+	if sa := ds.Stats; sa != nil && err == nil {
+		err = obj.setComponent("stats", sa.Path, sa.IsEmpty(), func() (canonicalObject, error) {
+			return canonicalStats(sa)
+		})
+	}
+	if st := ds.Structure; st != nil && err == nil {
+		err = obj.setComponent("structure", st.Path, st.IsEmpty(), func() (canonicalObject, error) {
+			return canonicalStructure(st)
+		})
+	}
+	if tf := ds.Transform; tf != nil && err == nil {
+		err = obj.setComponent("transform", tf.Path, tf.IsEmpty(), func() (canonicalObject, error) {
+			return canonicalTransform(tf)
+		})
+	}
+	if v := ds.Viz; v != nil && err == nil {
+		err = obj.setComponent("viz", v.Path, v.IsEmpty(), func() (canonicalObject, error) {
+			return canonicalViz(v), nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return canonicalEncode(map[string]interface{}(obj))
+}
+
+// CanonicalHashes gives canonical hashes for a dataset & each non-empty
+// component it has, keyed by the component's field name in a dataset
+// document. The hash of the dataset itself is keyed "dataset"
+func CanonicalHashes(ds *Dataset) (map[string]string, error) {
+	data, err := CanonicalDataset(ds)
+	if err != nil {
+		return nil, err
+	}
+	dsHash, err := HashBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	hashes := map[string]string{"dataset": dsHash}
+
+	components := map[string]ObjectMarshaler{}
+	if ds.Commit != nil && !ds.Commit.IsEmpty() {
+		components["commit"] = ds.Commit
+	}
+	if ds.Meta != nil && !ds.Meta.IsEmpty() {
+		components["meta"] = ds.Meta
+	}
+	if ds.Readme != nil && !ds.Readme.IsEmpty() {
+		components["readme"] = ds.Readme
+	}
+	if ds.Stats != nil && !ds.Stats.IsEmpty() {
+		components["stats"] = ds.Stats
+	}
+	if ds.Structure != nil && !ds.Structure.IsEmpty() {
+		components["structure"] = ds.Structure
+	}
+	if ds.Transform != nil && !ds.Transform.IsEmpty() {
+		components["transform"] = ds.Transform
+	}
+	if ds.Viz != nil && !ds.Viz.IsEmpty() {
+		components["viz"] = ds.Viz
+	}
+
+	for key, c := range components {
+		if hashes[key], err = CanonicalHash(c); err != nil {
+			return nil, fmt.Errorf("hashing %s: %s", key, err.Error())
+		}
+	}
+	return hashes, nil
+}
+
+// canonicalObject collects the fields of a canonical JSON object, omitting
+// empty values
+type canonicalObject map[string]interface{}
+
+func kindOr(kind string, def Kind) string {
+	if kind == "" {
+		return def.String()
+	}
+	return kind
+}
+
+func (o canonicalObject) setString(key, s string) {
+	if s != "" {
+		o[key] = s
+	}
+}
+
+func (o canonicalObject) setInt(key string, i int) {
+	if i != 0 {
+		o[key] = json.Number(strconv.Itoa(i))
+	}
+}
+
+func (o canonicalObject) setStrings(key string, strs []string) {
+	if len(strs) == 0 {
+		return
+	}
+	list := make([]interface{}, len(strs))
+	for i, s := range strs {
+		list[i] = s
+	}
+	o[key] = list
+}
+
+func (o canonicalObject) setBytes(key string, data []byte) {
+	if len(data) > 0 {
+		o[key] = base64.StdEncoding.EncodeToString(data)
+	}
+}
+
+// setValue sets a field that holds arbitrary JSON data
+func (o canonicalObject) setValue(key string, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %s", key, err.Error())
+	}
+	val, err := decodeJSONNumbers(data)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %s", key, err.Error())
+	}
+	o[key] = val
+	return nil
+}
+
+// setComponent sets a dataset component field. Components that only hold a
+// path are encoded as the path string
+func (o canonicalObject) setComponent(key, path string, empty bool, build func() (canonicalObject, error)) error {
+	if path != "" && empty {
+		o[key] = path
+		return nil
+	}
+	c, err := build()
+	if err != nil {
+		return fmt.Errorf("encoding %s: %s", key, err.Error())
+	}
+	o[key] = map[string]interface{}(c)
+	return nil
+}
+
+func canonicalCommit(cm *Commit) canonicalObject {
+	obj := canonicalObject{
+		"qri":       kindOr(cm.Qri, KindCommit),
+		"timestamp": cm.Timestamp.UTC().Format(time.RFC3339Nano),
+		"title":     cm.Title,
+	}
+	if cm.Author != nil {
+		obj["author"] = map[string]interface{}(canonicalUser(cm.Author))
+	}
+	obj.setString("message", cm.Message)
+	obj.setString("signature", cm.Signature)
+	return obj
+}
+
+func canonicalUser(u *User) canonicalObject {
+	obj := canonicalObject{}
+	obj.setString("email", u.Email)
+	obj.setString("id", u.ID)
+	obj.setString("name", u.Fullname)
+	return obj
+}
+
+// canonicalMetaKeys are the fixed fields of meta, which take precedence over
+// additional metadata fields
+var canonicalMetaKeys = []string{"accessURL", "accrualPeriodicity", "citations", "contributors", "description", "downloadURL", "homeURL", "identifier", "keywords", "language", "license", "path", "qri", "readmeURL", "theme", "title", "version"}
+
+func canonicalMeta(md *Meta) (canonicalObject, error) {
+	obj := canonicalObject{}
+	for key, val := range md.meta {
+		if err := obj.setValue(key, val); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range canonicalMetaKeys {
+		delete(obj, key)
+	}
+
+	obj["qri"] = kindOr(md.Qri, KindMeta)
+	obj.setString("accessURL", md.AccessURL)
+	obj.setString("accrualPeriodicity", md.AccrualPeriodicity)
+	if len(md.Citations) > 0 {
+		list := make([]interface{}, len(md.Citations))
+		for i, c := range md.Citations {
+			if c != nil {
+				cite := canonicalObject{}
+				cite.setString("email", c.Email)
+				cite.setString("name", c.Name)
+				cite.setString("url", c.URL)
+				list[i] = map[string]interface{}(cite)
+			}
+		}
+		obj["citations"] = list
+	}
+	if len(md.Contributors) > 0 {
+		list := make([]interface{}, len(md.Contributors))
+		for i, u := range md.Contributors {
+			if u != nil {
+				list[i] = map[string]interface{}(canonicalUser(u))
+			}
+		}
+		obj["contributors"] = list
+	}
+	obj.setString("description", md.Description)
+	obj.setString("downloadURL", md.DownloadURL)
+	obj.setString("homeURL", md.HomeURL)
+	obj.setString("identifier", md.Identifier)
+	obj.setStrings("keywords", md.Keywords)
+	obj.setStrings("language", md.Language)
+	if md.License != nil {
+		license := canonicalObject{}
+		license.setString("type", md.License.Type)
+		license.setString("url", md.License.URL)
+		obj["license"] = map[string]interface{}(license)
+	}
+	obj.setString("readmeURL", md.ReadmeURL)
+	obj.setStrings("theme", md.Theme)
+	obj.setString("title", md.Title)
+	obj.setString("version", md.Version)
+	return obj, nil
+}
+
+func canonicalReadme(r *Readme) canonicalObject {
+	obj := canonicalObject{"qri": kindOr(r.Qri, KindReadme)}
+	obj.setString("format", r.Format)
+	obj.setString("renderedPath", r.RenderedPath)
+	obj.setBytes("scriptBytes", r.ScriptBytes)
+	obj.setString("scriptPath", r.ScriptPath)
+	return obj
+}
+
+func canonicalStats(sa *Stats) (canonicalObject, error) {
+	obj := canonicalObject{"qri": kindOr(sa.Qri, KindStats)}
+	return obj, obj.setValue("stats", sa.Stats)
+}
+
+func canonicalStructure(st *Structure) (canonicalObject, error) {
+	obj := canonicalObject{
+		"format": st.Format,
+		"qri":    kindOr(st.Qri, KindStructure),
+	}
+	obj.setString("checksum", st.Checksum)
+	obj.setString("compression", st.Compression)
+	obj.setInt("depth", st.Depth)
+	obj.setString("encoding", st.Encoding)
+	obj.setInt("entries", st.Entries)
+	obj.setInt("errCount", st.ErrCount)
+	obj.setInt("length", st.Length)
+	if st.Strict {
+		obj["strict"] = true
+	}
+	if len(st.FormatConfig) > 0 {
+		if err := obj.setValue("formatConfig", st.FormatConfig); err != nil {
+			return nil, err
+		}
+	}
+	if len(st.Schema) > 0 {
+		if err := obj.setValue("schema", st.Schema); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+func canonicalTransform(tf *Transform) (canonicalObject, error) {
+	obj := canonicalObject{"qri": kindOr(tf.Qri, KindTransform)}
+	if len(tf.Config) > 0 {
+		if err := obj.setValue("config", tf.Config); err != nil {
+			return nil, err
+		}
+	}
+	if len(tf.Resources) > 0 {
+		resources := map[string]interface{}{}
+		for name, res := range tf.Resources {
+			resources[name] = nil
+			if res != nil {
+				resources[name] = map[string]interface{}{"path": res.Path}
+			}
+		}
+		obj["resources"] = resources
+	}
+	obj.setBytes("scriptBytes", tf.ScriptBytes)
+	obj.setString("scriptPath", tf.ScriptPath)
+	obj.setString("syntax", tf.Syntax)
+	obj.setString("syntaxVersion", tf.SyntaxVersion)
+	return obj, nil
+}
+
+func canonicalViz(v *Viz) canonicalObject {
+	obj := canonicalObject{"qri": kindOr(v.Qri, KindViz)}
+	obj.setString("format", v.Format)
+	obj.setString("renderedPath", v.RenderedPath)
+	obj.setBytes("scriptBytes", v.ScriptBytes)
+	obj.setString("scriptPath", v.ScriptPath)
+	return obj
+}
+
+// CanonicalizeJSON re-encodes JSON data using the JSON Canonicalization
+// Scheme, RFC 8785
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	v, err := decodeJSONNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalEncode(v)
+}
+
+func decodeJSONNumbers(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decoding json: %s", err.Error())
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("decoding json: unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+func canonicalEncode(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeCanonical(buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch x := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		if x {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case json.Number:
+		f, err := strconv.ParseFloat(string(x), 64)
+		if err != nil || math.IsInf(f, 0) {
+			return fmt.Errorf("invalid number for canonical encoding: %s", x)
+		}
+		buf.WriteString(formatCanonicalNumber(f))
+	case string:
+		writeCanonicalString(buf, x)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, x[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported type for canonical encoding: %T", v)
+	}
+	return nil
+}
+
+// formatCanonicalNumber formats a float the way ECMAScript's
+// Number.prototype.toString does, as required by RFC 8785
+func formatCanonicalNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	if format == 'e' {
+		// ECMAScript doesn't zero-pad exponents: 1e-07 becomes 1e-7
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return s
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[r>>4])
+				buf.WriteByte(hex[r&0xf])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as RFC 8785 requires
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
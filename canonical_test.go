@@ -0,0 +1,243 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// golden vectors live in testdata/canonical. each [name].input.json file has
+// a matching [name].canonical.json file with expected canonical bytes, and an
+// entry in hashes.json
+func TestCanonicalizeJSONVectors(t *testing.T) {
+	hashes := map[string]string{}
+	data, err := ioutil.ReadFile("testdata/canonical/hashes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs, err := filepath.Glob("testdata/canonical/*.input.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no golden vectors found")
+	}
+
+	for _, path := range inputs {
+		name := strings.TrimSuffix(filepath.Base(path), ".input.json")
+		if name == "dataset" {
+			continue
+		}
+		input, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect, err := ioutil.ReadFile(strings.Replace(path, ".input.json", ".canonical.json", 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := CanonicalizeJSON(input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+			continue
+		}
+		if !bytes.Equal(expect, got) {
+			t.Errorf("%s: canonical mismatch.\nexpected: %s\ngot:      %s", name, expect, got)
+		}
+		hash, err := HashBytes(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hashes[name] != hash {
+			t.Errorf("%s: hash mismatch. expected: %s, got: %s", name, hashes[name], hash)
+		}
+	}
+}
+
+func TestCanonicalizeJSONErrors(t *testing.T) {
+	cases := []struct {
+		in  string
+		err string
+	}{
+		{``, "decoding json: EOF"},
+		{`{}{}`, "decoding json: unexpected data after top-level value"},
+		{`1e400`, "invalid number for canonical encoding: 1e400"},
+		{`[-1e999]`, "invalid number for canonical encoding: -1e999"},
+	}
+
+	for i, c := range cases {
+		_, err := CanonicalizeJSON([]byte(c.in))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestCanonicalDataset(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/canonical/dataset.input.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := &Dataset{}
+	if err := json.Unmarshal(data, ds); err != nil {
+		t.Fatal(err)
+	}
+
+	expect, err := ioutil.ReadFile("testdata/canonical/dataset.canonical.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := CanonicalDataset(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expect, got) {
+		t.Errorf("canonical mismatch.\nexpected: %s\ngot:      %s", expect, got)
+	}
+
+	expectHashes := map[string]string{}
+	data, err = ioutil.ReadFile("testdata/canonical/dataset.hashes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &expectHashes); err != nil {
+		t.Fatal(err)
+	}
+	hashes, err := CanonicalHashes(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expectHashes, hashes); diff != "" {
+		t.Errorf("hashes mismatch (-want +got):\n%s", diff)
+	}
+
+	// transient values & component paths must not affect hashes
+	ds.Name = "renamed"
+	ds.Path = "/ipfs/QmOtherPath"
+	ds.NumVersions = 12
+	ds.Meta.Path = "/ipfs/QmOtherMetaPath"
+	ds.BodyBytes = []byte("a,b")
+	if hashes, err = CanonicalHashes(ds); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expectHashes, hashes); diff != "" {
+		t.Errorf("hashes changed by transient values (-want +got):\n%s", diff)
+	}
+
+	ds.Meta.Title = "changed"
+	if hashes, err = CanonicalHashes(ds); err != nil {
+		t.Fatal(err)
+	}
+	if hashes["meta"] == expectHashes["meta"] || hashes["dataset"] == expectHashes["dataset"] {
+		t.Errorf("expected changing meta title to change meta & dataset hashes")
+	}
+	if hashes["structure"] != expectHashes["structure"] {
+		t.Errorf("expected changing meta title to leave structure hash unchanged")
+	}
+}
+
+func TestCanonicalVersion(t *testing.T) {
+	if CanonicalVersion != "jcs1:"+CurrentSpecVersion {
+		t.Errorf("canonical version mismatch: %s", CanonicalVersion)
+	}
+}
+
+// canonicalGoFields lists the Go fields of each type that hold values in the
+// canonical encoding of CanonicalVersion
+var canonicalGoFields = map[string][]string{
+	"Dataset":   {"BodyPath", "Commit", "Meta", "PreviousPath", "Qri", "Readme", "Stats", "Structure", "Transform", "Viz"},
+	"Commit":    {"Author", "Message", "Qri", "Signature", "Timestamp", "Title"},
+	"Meta":      {"AccessURL", "AccrualPeriodicity", "Citations", "Contributors", "Description", "DownloadURL", "HomeURL", "Identifier", "Keywords", "Language", "License", "Qri", "ReadmeURL", "Theme", "Title", "Version"},
+	"Readme":    {"Format", "Qri", "RenderedPath", "ScriptBytes", "ScriptPath"},
+	"Stats":     {"Qri", "Stats"},
+	"Structure": {"Checksum", "Compression", "Depth", "Encoding", "Entries", "ErrCount", "Format", "FormatConfig", "Length", "Qri", "Schema", "Strict"},
+	"Transform": {"Config", "Qri", "Resources", "ScriptBytes", "ScriptPath", "Syntax", "SyntaxVersion"},
+	"Viz":       {"Format", "Qri", "RenderedPath", "ScriptBytes", "ScriptPath"},
+}
+
+// fillNonCanonical sets every exported field of a struct that isn't part of
+// the canonical encoding to a non-zero value, standing in for fields added
+// to dataset types in the future
+func fillNonCanonical(v interface{}) {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	canonical := map[string]bool{}
+	for _, name := range canonicalGoFields[rt.Name()] {
+		canonical[name] = true
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rv.Field(i)
+		if canonical[rt.Field(i).Name] || !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString("non-canonical")
+		case reflect.Int:
+			f.SetInt(7)
+		case reflect.Bool:
+			f.SetBool(true)
+		case reflect.Slice:
+			if f.Type().Elem().Kind() == reflect.Uint8 {
+				f.SetBytes([]byte("non-canonical"))
+			}
+		case reflect.Map:
+			if f.Type().Key().Kind() == reflect.String && f.Type().Elem().Kind() == reflect.String {
+				f.Set(reflect.ValueOf(map[string]string{"key": "non-canonical"}))
+			}
+		case reflect.Interface:
+			f.Set(reflect.ValueOf("non-canonical"))
+		}
+	}
+}
+
+func TestCanonicalFieldSet(t *testing.T) {
+	load := func() *Dataset {
+		data, err := ioutil.ReadFile("testdata/canonical/dataset.input.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ds := &Dataset{}
+		if err := json.Unmarshal(data, ds); err != nil {
+			t.Fatal(err)
+		}
+		ds.Readme = &Readme{Format: "md", ScriptPath: "/ipfs/QmReadme"}
+		ds.Stats = &Stats{Stats: []interface{}{map[string]interface{}{"count": 2}}}
+		ds.Transform = &Transform{Syntax: "starlark", Resources: map[string]*TransformResource{"a": {Path: "/ipfs/QmA"}}}
+		return ds
+	}
+
+	expect, err := CanonicalHashes(load())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds := load()
+	fillNonCanonical(ds)
+	fillNonCanonical(ds.Commit)
+	fillNonCanonical(ds.Meta)
+	fillNonCanonical(ds.Readme)
+	fillNonCanonical(ds.Stats)
+	fillNonCanonical(ds.Structure)
+	fillNonCanonical(ds.Transform)
+	fillNonCanonical(ds.Viz)
+
+	got, err := CanonicalHashes(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("fields outside the canonical field set changed hashes (-want +got):\n%s", diff)
+	}
+}
@@ -22,7 +22,8 @@ type Commit struct {
 	// Qri is this commit's qri kind
 	// derived
 	Qri string `json:"qri,omitempty"`
-	// Signature is a base58 encoded privateKey signing of Title
+	// Signature is a base58 encoded privateKey signing of the dataset, made
+	// over the payload described by CommitSignatureVersion
 	Signature string `json:"signature,omitempty"`
 	// Time this dataset was created. Required.
 	Timestamp time.Time `json:"timestamp"`
@@ -132,6 +133,11 @@ func (cm *Commit) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	data, err := upgradeJSON(data, KindCommit)
+	if err != nil {
+		return err
+	}
+
 	m := _commitMsg{}
 	if err := json.Unmarshal(data, &m); err != nil {
 		return fmt.Errorf("error unmarshling commit: %s", err.Error())
@@ -143,13 +149,16 @@ func (cm *Commit) UnmarshalJSON(data []byte) error {
 
 // UnmarshalCommit tries to extract a dataset type from an empty
 // interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
-func UnmarshalCommit(v interface{}) (*Commit, error) {
+func UnmarshalCommit(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Commit, error) {
 	switch r := v.(type) {
 	case *Commit:
 		return r, nil
 	case Commit:
 		return &r, nil
 	case []byte:
+		if err := checkStrict(KindCommit, r, configs); err != nil {
+			return nil, err
+		}
 		cm := &Commit{}
 		err := json.Unmarshal(r, cm)
 		return cm, err
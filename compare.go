@@ -53,6 +53,9 @@ func CompareDatasets(a, b *Dataset) error {
 	if err := CompareVizs(a.Viz, b.Viz); err != nil {
 		return fmt.Errorf("Transform: %s", err.Error())
 	}
+	if err := CompareStats(a.Stats, b.Stats); err != nil {
+		return fmt.Errorf("Stats: %s", err.Error())
+	}
 
 	return nil
 }
@@ -196,6 +199,35 @@ func CompareVizs(a, b *Viz) error {
 	return nil
 }
 
+// CompareStats checks if all fields of two Stats pointers are equal,
+// returning an error on the first, nil if equal
+// Note that comparison does not examine the internal path property
+func CompareStats(a, b *Stats) error {
+	if a == nil && b == nil {
+		return nil
+	} else if a == nil && b != nil {
+		return fmt.Errorf("nil: <nil> != <not nil>")
+	} else if a != nil && b == nil {
+		return fmt.Errorf("nil: <not nil> != <nil>")
+	}
+	if a.Qri != b.Qri {
+		return fmt.Errorf("Qri: %s != %s", a.Qri, b.Qri)
+	}
+
+	ab, err := json.Marshal(a.Stats)
+	if err != nil {
+		return fmt.Errorf("error encoding a to JSON: %s", err.Error())
+	}
+	bb, err := json.Marshal(b.Stats)
+	if err != nil {
+		return fmt.Errorf("error encoding b to JSON: %s", err.Error())
+	}
+	if !bytes.Equal(ab, bb) {
+		return fmt.Errorf("json bytes are not equal")
+	}
+	return nil
+}
+
 // CompareSchemas checks if all fields of two Schema pointers are equal,
 // returning an error on the first, nil if equal
 // Note that comparison does not examine the internal path property
@@ -332,7 +364,9 @@ func CompareTransformResources(a, b *TransformResource) error {
 }
 
 // CompareLicenses checks if all fields in two License pointers are equal,
-// returning an error if unequal
+// returning an error if unequal. Types are compared by SPDX identifier when
+// both resolve to one, urls are compared ignoring scheme, "www." & trailing
+// slashes
 func CompareLicenses(a, b *License) error {
 	if a == nil && b == nil {
 		return nil
@@ -341,7 +375,14 @@ func CompareLicenses(a, b *License) error {
 	}
 
 	if a.Type != b.Type {
-		return fmt.Errorf("type mismatch: '%s' != '%s'", a.Type, b.Type)
+		aid, aerr := NormalizeLicenseID(a.Type)
+		bid, berr := NormalizeLicenseID(b.Type)
+		if aerr != nil || berr != nil || aid != bid {
+			return fmt.Errorf("type mismatch: '%s' != '%s'", a.Type, b.Type)
+		}
+	}
+	if a.URL != b.URL && licenseURLKey(a.URL) != licenseURLKey(b.URL) {
+		return fmt.Errorf("url mismatch: '%s' != '%s'", a.URL, b.URL)
 	}
 
 	return nil
@@ -116,6 +116,27 @@ func TestCompareVizs(t *testing.T) {
 	}
 }
 
+func TestCompareStats(t *testing.T) {
+	cases := []struct {
+		a, b *Stats
+		err  string
+	}{
+		{nil, nil, ""},
+		{&Stats{Qri: "a", Stats: map[string]interface{}{"count": 1}}, &Stats{Qri: "a", Stats: map[string]interface{}{"count": 1}}, ""},
+		{&Stats{}, nil, "nil: <not nil> != <nil>"},
+		{nil, &Stats{}, "nil: <nil> != <not nil>"},
+		{&Stats{Qri: "a"}, &Stats{Qri: "b"}, "Qri: a != b"},
+		{&Stats{Stats: []interface{}{1}}, &Stats{Stats: []interface{}{2}}, "json bytes are not equal"},
+	}
+
+	for i, c := range cases {
+		err := CompareStats(c.a, c.b)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error: expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
 func TestCompareCommits(t *testing.T) {
 	c1 := &Commit{
 		Path:    "/foo",
@@ -218,6 +239,15 @@ func TestCompareLicenses(t *testing.T) {
 	}{
 		{nil, nil, ""},
 		{nil, nil, ""},
+		{&License{Type: "MIT"}, &License{Type: "MIT"}, ""},
+		{&License{Type: "cc by 4.0"}, &License{Type: "CC-BY-4.0"}, ""},
+		{&License{Type: "CC-BY-4.0"}, &License{Type: "CC-BY-SA-4.0"}, "type mismatch: 'CC-BY-4.0' != 'CC-BY-SA-4.0'"},
+		{&License{Type: "foo"}, &License{Type: "bar"}, "type mismatch: 'foo' != 'bar'"},
+		{&License{Type: "foo"}, &License{Type: "foo", URL: "https://example.com/foo"}, "url mismatch: '' != 'https://example.com/foo'"},
+		// urls don't stand in for types
+		{&License{Type: "", URL: "https://spdx.org/licenses/MIT.html"}, &License{Type: "MIT", URL: "https://spdx.org/licenses/MIT.html"}, "type mismatch: '' != 'MIT'"},
+		{&License{Type: "MIT", URL: "https://spdx.org/licenses/MIT.html"}, &License{Type: "MIT", URL: "https://spdx.org/licenses/0BSD.html"}, "url mismatch: 'https://spdx.org/licenses/MIT.html' != 'https://spdx.org/licenses/0BSD.html'"},
+		{&License{Type: "MIT", URL: "http://www.opensource.org/licenses/MIT/"}, &License{Type: "mit", URL: "https://opensource.org/licenses/MIT"}, ""},
 	}
 
 	for i, c := range cases {
@@ -0,0 +1,457 @@
+package csvw
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+)
+
+// numericTypes are CSVW datatypes that map to the JSON schema "number" type
+var numericTypes = map[string]bool{
+	"number": true, "decimal": true, "double": true, "float": true,
+}
+
+// integerTypes are CSVW datatypes that map to the JSON schema "integer" type
+var integerTypes = map[string]bool{
+	"integer": true, "int": true, "long": true, "short": true, "byte": true,
+	"nonNegativeInteger": true, "nonPositiveInteger": true,
+	"positiveInteger": true, "negativeInteger": true,
+	"unsignedLong": true, "unsignedInt": true, "unsignedShort": true, "unsignedByte": true,
+}
+
+// formatTypes map CSVW datatypes to JSON schema string formats
+var formatTypes = map[string]string{
+	"date":     "date",
+	"datetime": "date-time",
+	"dateTime": "date-time",
+	"time":     "time",
+	"anyURI":   "uri",
+}
+
+// ToDataset converts a CSVW table description to a dataset with structure
+// and meta, using the table url as the body path
+func ToDataset(t *Table) (*dataset.Dataset, error) {
+	st, err := Structure(t)
+	if err != nil {
+		return nil, err
+	}
+	ds := &dataset.Dataset{
+		BodyPath:  t.URL,
+		Structure: st,
+	}
+	if md := Meta(t); !md.IsEmpty() {
+		ds.Meta = md
+	}
+	if ts, err := time.Parse(time.RFC3339, annotationString(t.Annotations["dc:modified"])); err == nil {
+		ds.Commit = &dataset.Commit{Timestamp: ts}
+	}
+	return ds, nil
+}
+
+// Structure creates a csv structure from a CSVW table description. Columns
+// become a tabular JSON schema, dialects become csv format configuration.
+// Tables without columns get the base array schema
+func Structure(t *Table) (*dataset.Structure, error) {
+	if t == nil {
+		return nil, fmt.Errorf("table is required")
+	}
+	st := &dataset.Structure{
+		Format:       dataset.CSVDataFormat.String(),
+		FormatConfig: FormatConfig(t.Dialect),
+		Schema:       dataset.BaseSchemaArray,
+	}
+	if t.Dialect != nil && t.Dialect.Encoding != "" {
+		st.Encoding = t.Dialect.Encoding
+	}
+
+	if t.TableSchema == nil || len(t.TableSchema.Columns) == 0 {
+		return st, nil
+	}
+
+	var cols []interface{}
+	for i, c := range t.TableSchema.Columns {
+		if c.Virtual {
+			continue
+		}
+		col, err := columnSchema(c)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %s", i, err.Error())
+		}
+		cols = append(cols, col)
+	}
+	st.Schema = map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": cols,
+		},
+	}
+	if len(t.TableSchema.PrimaryKey) > 0 {
+		pk := make([]interface{}, len(t.TableSchema.PrimaryKey))
+		for i, key := range t.TableSchema.PrimaryKey {
+			pk[i] = key
+		}
+		st.Schema["primaryKey"] = pk
+	}
+	return st, nil
+}
+
+// FormatConfig creates csv format configuration from a dialect. Dialects
+// that don't use standard quoting are read with lazy quotes
+func FormatConfig(d *Dialect) map[string]interface{} {
+	cfg := map[string]interface{}{}
+	if d.HasHeader() {
+		cfg["headerRow"] = true
+	}
+	if d != nil && d.Delimiter != "" && d.Delimiter != "," {
+		cfg["separator"] = d.Delimiter
+	}
+	if !d.StrictQuotes() {
+		cfg["lazyQuotes"] = true
+	}
+	return cfg
+}
+
+func columnSchema(c *Column) (map[string]interface{}, error) {
+	col := map[string]interface{}{}
+	switch {
+	case c.Name != "":
+		col["title"] = c.Name
+	case len(c.Titles) > 0:
+		col["title"] = c.Titles[0]
+	}
+	if c.Description != "" {
+		col["description"] = c.Description
+	}
+
+	dt := c.Datatype
+	if dt == nil {
+		dt = &Datatype{Base: "string"}
+	}
+	switch {
+	case integerTypes[dt.Base]:
+		col["type"] = "integer"
+	case numericTypes[dt.Base]:
+		col["type"] = "number"
+	case dt.Base == "boolean":
+		col["type"] = "boolean"
+	case dt.Base == "json":
+		col["type"] = "object"
+	case formatTypes[dt.Base] != "":
+		col["type"] = "string"
+		col["format"] = formatTypes[dt.Base]
+	default:
+		col["type"] = "string"
+		// string formats are regular expressions
+		if dt.Format != "" && (dt.Base == "string" || dt.Base == "") {
+			col["pattern"] = dt.Format
+		}
+	}
+
+	if dt.Minimum != nil {
+		col["minimum"] = dt.Minimum
+	}
+	if dt.Maximum != nil {
+		col["maximum"] = dt.Maximum
+	}
+	if dt.MinLength != nil {
+		col["minLength"] = *dt.MinLength
+	}
+	if dt.MaxLength != nil {
+		col["maxLength"] = *dt.MaxLength
+	}
+	return col, nil
+}
+
+// Meta reads dataset metadata from table annotations, using the Dublin Core
+// & DCAT properties CSVW predefines prefixes for
+func Meta(t *Table) *dataset.Meta {
+	a := t.Annotations
+	md := &dataset.Meta{
+		Title:              annotationString(a["dc:title"]),
+		Description:        annotationString(a["dc:description"]),
+		Identifier:         annotationString(a["dc:identifier"]),
+		AccrualPeriodicity: annotationString(a["dc:accrualPeriodicity"]),
+		HomeURL:            annotationString(a["dcat:landingPage"]),
+		Version:            annotationString(a["owl:versionInfo"]),
+		Keywords:           annotationStrings(a["dcat:keyword"]),
+		Theme:              annotationStrings(a["dcat:theme"]),
+		Language:           annotationStrings(a["dc:language"]),
+	}
+
+	switch l := a["dc:license"].(type) {
+	case string:
+		if strings.Contains(l, "://") {
+			md.License = &dataset.License{URL: l}
+		} else {
+			md.License = &dataset.License{Type: l}
+		}
+	case map[string]interface{}:
+		md.License = &dataset.License{
+			Type: annotationString(l["dc:identifier"]),
+			URL:  annotationString(l["@id"]),
+		}
+	}
+
+	for _, v := range asSlice(a["dc:contributor"]) {
+		switch u := v.(type) {
+		case string:
+			md.Contributors = append(md.Contributors, &dataset.User{Fullname: u})
+		case map[string]interface{}:
+			md.Contributors = append(md.Contributors, &dataset.User{
+				ID:       annotationString(u["dc:identifier"]),
+				Fullname: annotationString(u["foaf:name"]),
+				Email:    strings.TrimPrefix(annotationString(u["foaf:mbox"]), "mailto:"),
+			})
+		}
+	}
+	for _, v := range asSlice(a["dc:source"]) {
+		switch c := v.(type) {
+		case string:
+			md.Citations = append(md.Citations, &dataset.Citation{URL: c})
+		case map[string]interface{}:
+			md.Citations = append(md.Citations, &dataset.Citation{
+				Name:  annotationString(c["dc:title"]),
+				URL:   annotationString(c["@id"]),
+				Email: strings.TrimPrefix(annotationString(c["foaf:mbox"]), "mailto:"),
+			})
+		}
+	}
+	return md
+}
+
+func asSlice(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.([]interface{}); ok {
+		return s
+	}
+	return []interface{}{v}
+}
+
+// FromDataset creates a CSVW table description of a csv-formatted dataset.
+// The table url is the base name of the body path, assuming metadata is
+// written next to the body file
+func FromDataset(ds *dataset.Dataset) (*Table, error) {
+	if ds == nil || ds.Structure == nil {
+		return nil, fmt.Errorf("dataset structure is required")
+	}
+	st := ds.Structure
+	if st.DataFormat() != dataset.CSVDataFormat {
+		return nil, fmt.Errorf("csvw metadata requires csv format, got: '%s'", st.Format)
+	}
+	opts, err := dataset.NewCSVOptions(st.FormatConfig)
+	if err != nil {
+		return nil, fmt.Errorf("structure formatConfig: %s", err.Error())
+	}
+
+	t := &Table{URL: filepath.Base(ds.BodyPath)}
+	if ds.BodyPath == "" {
+		t.URL = "body.csv"
+	}
+
+	header := opts.HeaderRow
+	t.Dialect = &Dialect{Header: &header}
+	if opts.Separator != 0 && opts.Separator != ',' {
+		t.Dialect.Delimiter = string(opts.Separator)
+	}
+	if st.Encoding != "" {
+		t.Dialect.Encoding = st.Encoding
+	}
+
+	if cols := tabularColumns(st.Schema); cols != nil {
+		t.TableSchema = &TableSchema{}
+		for i, c := range cols {
+			col, ok := c.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("schema column %d must be an object", i)
+			}
+			t.TableSchema.Columns = append(t.TableSchema.Columns, schemaColumn(col))
+		}
+		if pk, ok := st.Schema["primaryKey"].([]interface{}); ok {
+			for _, key := range pk {
+				if s, ok := key.(string); ok {
+					t.TableSchema.PrimaryKey = append(t.TableSchema.PrimaryKey, s)
+				}
+			}
+		}
+	}
+
+	t.Annotations = annotations(ds)
+	return t, nil
+}
+
+func tabularColumns(sch map[string]interface{}) []interface{} {
+	items, _ := sch["items"].(map[string]interface{})
+	cols, _ := items["items"].([]interface{})
+	return cols
+}
+
+func schemaColumn(col map[string]interface{}) *Column {
+	c := &Column{}
+	if title, ok := col["title"].(string); ok {
+		c.Name = title
+		c.Titles = Strings{title}
+	}
+	c.Description, _ = col["description"].(string)
+
+	dt := &Datatype{Base: "string"}
+	format, _ := col["format"].(string)
+	switch typ := columnType(col["type"]); typ {
+	case "integer", "number", "boolean":
+		dt.Base = typ
+	case "object", "array":
+		dt.Base = "json"
+	case "string":
+		for base, f := range formatTypes {
+			// dateTime is the canonical spelling of date-time
+			if f == format && base != "datetime" {
+				dt.Base = base
+			}
+		}
+		if pattern, ok := col["pattern"].(string); ok && dt.Base == "string" {
+			dt.Format = pattern
+		}
+	}
+
+	dt.Minimum = col["minimum"]
+	dt.Maximum = col["maximum"]
+	if n, ok := toInt(col["minLength"]); ok {
+		dt.MinLength = &n
+	}
+	if n, ok := toInt(col["maxLength"]); ok {
+		dt.MaxLength = &n
+	}
+	c.Datatype = dt
+	return c
+}
+
+// columnType gives the JSON schema type of a column, ignoring "null" in type
+// lists. Columns with more than one type are strings
+func columnType(t interface{}) string {
+	switch x := t.(type) {
+	case string:
+		return x
+	case []interface{}:
+		typ := ""
+		for _, v := range x {
+			s, _ := v.(string)
+			if s == "null" {
+				continue
+			}
+			if typ != "" {
+				return "string"
+			}
+			typ = s
+		}
+		return typ
+	}
+	return "string"
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// annotations describes dataset meta with CSVW common properties
+func annotations(ds *dataset.Dataset) map[string]interface{} {
+	a := map[string]interface{}{}
+	if ds.Commit != nil && !ds.Commit.Timestamp.IsZero() {
+		a["dc:modified"] = map[string]interface{}{
+			"@value": ds.Commit.Timestamp.UTC().Format(time.RFC3339),
+			"@type":  "xsd:dateTime",
+		}
+	}
+	md := ds.Meta
+	if md == nil {
+		return a
+	}
+
+	setString := func(key, val string) {
+		if val != "" {
+			a[key] = val
+		}
+	}
+	setStrings := func(key string, vals []string) {
+		if len(vals) > 0 {
+			a[key] = vals
+		}
+	}
+	setString("dc:title", md.Title)
+	setString("dc:description", md.Description)
+	setString("dc:identifier", md.Identifier)
+	setString("dc:accrualPeriodicity", md.AccrualPeriodicity)
+	setString("owl:versionInfo", md.Version)
+	setStrings("dcat:keyword", md.Keywords)
+	setStrings("dcat:theme", md.Theme)
+	setStrings("dc:language", md.Language)
+	if md.HomeURL != "" {
+		a["dcat:landingPage"] = map[string]interface{}{"@id": md.HomeURL}
+	}
+
+	if l := md.License; l != nil {
+		license := map[string]interface{}{}
+		if l.URL != "" {
+			license["@id"] = l.URL
+		}
+		if l.Type != "" {
+			license["dc:identifier"] = l.Type
+		}
+		a["dc:license"] = license
+	}
+
+	var contributors []interface{}
+	for _, u := range md.Contributors {
+		if u == nil {
+			continue
+		}
+		agent := map[string]interface{}{}
+		if u.Fullname != "" {
+			agent["foaf:name"] = u.Fullname
+		}
+		if u.Email != "" {
+			agent["foaf:mbox"] = map[string]interface{}{"@id": "mailto:" + u.Email}
+		}
+		if u.ID != "" {
+			agent["dc:identifier"] = u.ID
+		}
+		contributors = append(contributors, agent)
+	}
+	if contributors != nil {
+		a["dc:contributor"] = contributors
+	}
+
+	var sources []interface{}
+	for _, c := range md.Citations {
+		if c == nil {
+			continue
+		}
+		src := map[string]interface{}{}
+		if c.URL != "" {
+			src["@id"] = c.URL
+		}
+		if c.Name != "" {
+			src["dc:title"] = c.Name
+		}
+		if c.Email != "" {
+			src["foaf:mbox"] = map[string]interface{}{"@id": "mailto:" + c.Email}
+		}
+		sources = append(sources, src)
+	}
+	if sources != nil {
+		a["dc:source"] = sources
+	}
+	return a
+}
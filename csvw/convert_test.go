@@ -0,0 +1,215 @@
+package csvw
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/qri-io/dataset"
+)
+
+var cmpDataset = []cmp.Option{
+	cmp.AllowUnexported(dataset.Dataset{}, dataset.Meta{}, dataset.Structure{}, dataset.Commit{}),
+	cmpopts.EquateEmpty(),
+}
+
+func TestToDataset(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/observations.csv-metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ToDataset(tables[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &dataset.Dataset{
+		BodyPath: "observations.csv",
+		Commit:   &dataset.Commit{Timestamp: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)},
+		Meta: &dataset.Meta{
+			Title:        "Weather observations",
+			Description:  "Daily weather station observations",
+			Keywords:     []string{"weather", "temperature"},
+			License:      &dataset.License{URL: "http://opendefinition.org/licenses/cc-by/"},
+			Contributors: []*dataset.User{{Fullname: "Met Office"}},
+		},
+		Structure: &dataset.Structure{
+			Format:       "csv",
+			Encoding:     "utf-8",
+			FormatConfig: map[string]interface{}{"headerRow": true, "separator": "\t", "lazyQuotes": true},
+			Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": []interface{}{
+						map[string]interface{}{"title": "Station", "type": "string", "pattern": "[A-Z]{4}", "description": "station identifier"},
+						map[string]interface{}{"title": "observed", "type": "string", "format": "date-time"},
+						map[string]interface{}{"title": "temp", "type": "number", "minimum": float64(-90), "maximum": float64(60)},
+						map[string]interface{}{"title": "reading", "type": "integer"},
+						map[string]interface{}{"title": "valid", "type": "boolean"},
+					},
+				},
+				"primaryKey": []interface{}{"observed", "station"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, got, cmpDataset...); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := Structure(nil); err == nil || err.Error() != "table is required" {
+		t.Errorf("expected nil table error, got: %v", err)
+	}
+	st, err := Structure(&Table{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(dataset.BaseSchemaArray, st.Schema); diff != "" {
+		t.Errorf("expected tables without columns to use the base array schema (-want +got):\n%s", diff)
+	}
+}
+
+func testDataset() *dataset.Dataset {
+	return &dataset.Dataset{
+		BodyPath: "/ipfs/QmBody/body.csv",
+		Commit:   &dataset.Commit{Timestamp: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)},
+		Meta: &dataset.Meta{
+			Title:              "Weather observations",
+			Description:        "Daily weather station observations",
+			Identifier:         "weather-1",
+			AccrualPeriodicity: "R/P1D",
+			HomeURL:            "https://example.org/weather",
+			Version:            "2",
+			Keywords:           []string{"weather"},
+			Theme:              []string{"climate"},
+			Language:           []string{"en"},
+			License:            &dataset.License{Type: "CC-BY-4.0", URL: "http://creativecommons.org/licenses/by/4.0/"},
+			Contributors:       []*dataset.User{{ID: "QmUser", Fullname: "Ada", Email: "ada@example.org"}},
+			Citations:          []*dataset.Citation{{Name: "stations", URL: "https://example.org/stations", Email: "stations@example.org"}},
+		},
+		Structure: &dataset.Structure{
+			Format:       "csv",
+			FormatConfig: map[string]interface{}{"headerRow": true, "separator": ";"},
+			Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": []interface{}{
+						map[string]interface{}{"title": "station", "type": "string", "pattern": "[A-Z]{4}", "minLength": 4, "maxLength": 4},
+						map[string]interface{}{"title": "observed", "type": "string", "format": "date-time"},
+						map[string]interface{}{"title": "day", "type": "string", "format": "date"},
+						map[string]interface{}{"title": "link", "type": "string", "format": "uri"},
+						map[string]interface{}{"title": "temp", "type": "number", "minimum": float64(-90), "description": "celsius"},
+						map[string]interface{}{"title": "count", "type": []interface{}{"integer", "null"}},
+						map[string]interface{}{"title": "extra", "type": "object"},
+					},
+				},
+				"primaryKey": []interface{}{"station"},
+			},
+		},
+	}
+}
+
+func TestFromDataset(t *testing.T) {
+	tbl, err := FromDataset(testDataset())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{
+  "@context": "http://www.w3.org/ns/csvw",
+  "url": "body.csv",
+  "dc:title": "Weather observations",
+  "dc:description": "Daily weather station observations",
+  "dc:identifier": "weather-1",
+  "dc:accrualPeriodicity": "R/P1D",
+  "dc:language": ["en"],
+  "dc:license": { "@id": "http://creativecommons.org/licenses/by/4.0/", "dc:identifier": "CC-BY-4.0" },
+  "dc:modified": { "@type": "xsd:dateTime", "@value": "2019-06-01T00:00:00Z" },
+  "dc:contributor": [{ "dc:identifier": "QmUser", "foaf:mbox": { "@id": "mailto:ada@example.org" }, "foaf:name": "Ada" }],
+  "dc:source": [{ "@id": "https://example.org/stations", "dc:title": "stations", "foaf:mbox": { "@id": "mailto:stations@example.org" } }],
+  "dcat:keyword": ["weather"],
+  "dcat:landingPage": { "@id": "https://example.org/weather" },
+  "dcat:theme": ["climate"],
+  "owl:versionInfo": "2",
+  "dialect": { "delimiter": ";", "header": true },
+  "tableSchema": {
+    "columns": [
+      { "name": "station", "titles": "station", "datatype": { "base": "string", "format": "[A-Z]{4}", "minLength": 4, "maxLength": 4 } },
+      { "name": "observed", "titles": "observed", "datatype": "dateTime" },
+      { "name": "day", "titles": "day", "datatype": "date" },
+      { "name": "link", "titles": "link", "datatype": "anyURI" },
+      { "name": "temp", "titles": "temp", "dc:description": "celsius", "datatype": { "base": "number", "minimum": -90 } },
+      { "name": "count", "titles": "count", "datatype": "integer" },
+      { "name": "extra", "titles": "extra", "datatype": "json" }
+    ],
+    "primaryKey": "station"
+  }
+}`
+	var a, b interface{}
+	if err := json.Unmarshal([]byte(expect), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(got, &b); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(a, b); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	cases := []struct {
+		ds  *dataset.Dataset
+		err string
+	}{
+		{nil, "dataset structure is required"},
+		{&dataset.Dataset{}, "dataset structure is required"},
+		{&dataset.Dataset{Structure: &dataset.Structure{Format: "json"}}, "csvw metadata requires csv format, got: 'json'"},
+		{&dataset.Dataset{Structure: &dataset.Structure{Format: "csv", FormatConfig: map[string]interface{}{"separator": 5}}}, "structure formatConfig: invalid separator value: 5"},
+	}
+	for i, c := range cases {
+		_, err := FromDataset(c.ds)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ds := testDataset()
+	tbl, err := FromDataset(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ToDataset(tables[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// body paths are relative to the metadata file, and nullable types aren't
+	// expressed in CSVW
+	ds.BodyPath = "body.csv"
+	cols := ds.Structure.Schema["items"].(map[string]interface{})["items"].([]interface{})
+	cols[5].(map[string]interface{})["type"] = "integer"
+	if diff := cmp.Diff(ds, got, cmpDataset...); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,344 @@
+// Package csvw reads & writes W3C CSV on the Web (CSVW) metadata documents
+// for csv-formatted datasets. See https://www.w3.org/TR/tabular-metadata/
+// for the metadata vocabulary
+package csvw
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Context is the JSON-LD context of CSVW metadata documents
+const Context = "http://www.w3.org/ns/csvw"
+
+// Table is a CSVW table description
+type Table struct {
+	// URL of the csv file this table describes, relative to the metadata file
+	URL         string       `json:"url"`
+	Dialect     *Dialect     `json:"dialect,omitempty"`
+	TableSchema *TableSchema `json:"tableSchema,omitempty"`
+	// Annotations are common properties describing the table, with prefixed
+	// names like "dc:title"
+	Annotations map[string]interface{} `json:"-"`
+}
+
+// Dialect describes how to parse a csv file
+type Dialect struct {
+	Delimiter        string  `json:"delimiter,omitempty"`
+	Encoding         string  `json:"encoding,omitempty"`
+	Header           *bool   `json:"header,omitempty"`
+	HeaderRowCount   *int    `json:"headerRowCount,omitempty"`
+	DoubleQuote      *bool   `json:"doubleQuote,omitempty"`
+	QuoteChar        *string `json:"quoteChar,omitempty"`
+	SkipInitialSpace *bool   `json:"skipInitialSpace,omitempty"`
+	// nullQuote is true when quoteChar was explicitly set to null, disabling
+	// quoting entirely
+	nullQuote bool
+}
+
+type _dialect Dialect
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Dialect
+func (d *Dialect) UnmarshalJSON(data []byte) error {
+	_d := _dialect{}
+	if err := json.Unmarshal(data, &_d); err != nil {
+		return err
+	}
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if q, ok := raw["quoteChar"]; ok && string(q) == "null" {
+		_d.nullQuote = true
+	}
+	*d = Dialect(_d)
+	return nil
+}
+
+// HasHeader reports whether the first row of the csv file is a header row
+func (d *Dialect) HasHeader() bool {
+	if d == nil {
+		return true
+	}
+	if d.HeaderRowCount != nil {
+		return *d.HeaderRowCount > 0
+	}
+	return d.Header == nil || *d.Header
+}
+
+// StrictQuotes reports whether the dialect uses standard RFC 4180 quoting:
+// fields quoted with '"' and quotes escaped by doubling
+func (d *Dialect) StrictQuotes() bool {
+	if d == nil {
+		return true
+	}
+	return !d.nullQuote &&
+		(d.QuoteChar == nil || *d.QuoteChar == `"`) &&
+		(d.DoubleQuote == nil || *d.DoubleQuote)
+}
+
+// TableSchema describes the columns of a table
+type TableSchema struct {
+	Columns    []*Column `json:"columns,omitempty"`
+	PrimaryKey Strings   `json:"primaryKey,omitempty"`
+}
+
+// Column describes a single column of a table
+type Column struct {
+	Name        string    `json:"name,omitempty"`
+	Titles      Strings   `json:"titles,omitempty"`
+	Description string    `json:"dc:description,omitempty"`
+	Datatype    *Datatype `json:"datatype,omitempty"`
+	Required    bool      `json:"required,omitempty"`
+	Virtual     bool      `json:"virtual,omitempty"`
+}
+
+// Strings is a list of strings that may be encoded as a single string. Titles
+// may also be a map of language tags to strings
+type Strings []string
+
+// MarshalJSON implements the json.Marshaler interface for Strings
+func (s Strings) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Strings
+func (s *Strings) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	strs := annotationStrings(v)
+	if strs == nil {
+		if langs, ok := v.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(langs))
+			for lang := range langs {
+				keys = append(keys, lang)
+			}
+			sort.Strings(keys)
+			for _, lang := range keys {
+				strs = append(strs, annotationStrings(langs[lang])...)
+			}
+		}
+	}
+	if strs == nil && v != nil {
+		return fmt.Errorf("expected a string or array of strings, got: %s", data)
+	}
+	*s = Strings(strs)
+	return nil
+}
+
+// Datatype is the type of a column's cells. A datatype with only a base
+// encodes as the base name
+type Datatype struct {
+	Base      string      `json:"base,omitempty"`
+	Format    string      `json:"format,omitempty"`
+	Minimum   interface{} `json:"minimum,omitempty"`
+	Maximum   interface{} `json:"maximum,omitempty"`
+	MinLength *int        `json:"minLength,omitempty"`
+	MaxLength *int        `json:"maxLength,omitempty"`
+}
+
+type _datatype Datatype
+
+// MarshalJSON implements the json.Marshaler interface for Datatype
+func (dt Datatype) MarshalJSON() ([]byte, error) {
+	if dt.Format == "" && dt.Minimum == nil && dt.Maximum == nil && dt.MinLength == nil && dt.MaxLength == nil {
+		return json.Marshal(dt.Base)
+	}
+	return json.Marshal(_datatype(dt))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Datatype
+func (dt *Datatype) UnmarshalJSON(data []byte) error {
+	var base string
+	if err := json.Unmarshal(data, &base); err == nil {
+		*dt = Datatype{Base: base}
+		return nil
+	}
+	_dt := _datatype{}
+	if err := json.Unmarshal(data, &_dt); err != nil {
+		return err
+	}
+	if _dt.Base == "" {
+		_dt.Base = "string"
+	}
+	*dt = Datatype(_dt)
+	return nil
+}
+
+// tableKeys are the CSVW properties decoded into Table fields
+var tableKeys = map[string]bool{"@context": true, "url": true, "dialect": true, "tableSchema": true}
+
+type _table Table
+
+// MarshalJSON implements the json.Marshaler interface for Table
+func (t *Table) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*_table)(t))
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for key, val := range t.Annotations {
+		if !tableKeys[key] {
+			doc[key] = val
+		}
+	}
+	doc["@context"] = Context
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Table. Prefixed
+// common properties are hoisted into Annotations
+func (t *Table) UnmarshalJSON(data []byte) error {
+	_t := _table{}
+	if err := json.Unmarshal(data, &_t); err != nil {
+		return err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for key, val := range doc {
+		if strings.Contains(key, ":") {
+			if _t.Annotations == nil {
+				_t.Annotations = map[string]interface{}{}
+			}
+			_t.Annotations[key] = val
+		}
+	}
+	*t = Table(_t)
+	return nil
+}
+
+// tableGroup is a CSVW document describing multiple tables. Properties of
+// the group are inherited by tables that don't set them
+type tableGroup struct {
+	Tables      []*Table     `json:"tables"`
+	Dialect     *Dialect     `json:"dialect"`
+	TableSchema *TableSchema `json:"tableSchema"`
+}
+
+// Unmarshal decodes a CSVW metadata document describing a single table or a
+// group of tables, returning all described tables
+func Unmarshal(data []byte) ([]*Table, error) {
+	group := &tableGroup{}
+	if err := json.Unmarshal(data, group); err != nil {
+		return nil, fmt.Errorf("decoding csvw metadata: %s", err.Error())
+	}
+	if group.Tables == nil {
+		t := &Table{}
+		if err := json.Unmarshal(data, t); err != nil {
+			return nil, fmt.Errorf("decoding csvw metadata: %s", err.Error())
+		}
+		return []*Table{t}, nil
+	}
+
+	groupTable := &Table{}
+	if err := json.Unmarshal(data, groupTable); err != nil {
+		return nil, fmt.Errorf("decoding csvw metadata: %s", err.Error())
+	}
+	for _, t := range group.Tables {
+		if t.Dialect == nil {
+			t.Dialect = group.Dialect
+		}
+		if t.TableSchema == nil {
+			t.TableSchema = group.TableSchema
+		}
+		for key, val := range groupTable.Annotations {
+			if _, ok := t.Annotations[key]; !ok {
+				if t.Annotations == nil {
+					t.Annotations = map[string]interface{}{}
+				}
+				t.Annotations[key] = val
+			}
+		}
+	}
+	return group.Tables, nil
+}
+
+// MetadataPaths lists the locations CSVW metadata for a csv file is looked up
+// from, in order of precedence: "[file]-metadata.json", then
+// "csv-metadata.json" in the same directory
+func MetadataPaths(csvPath string) []string {
+	return []string{
+		csvPath + "-metadata.json",
+		filepath.Join(filepath.Dir(csvPath), "csv-metadata.json"),
+	}
+}
+
+// Locate finds the CSVW table description of a csv file on the local
+// filesystem, returning nil if no metadata describes the file. Metadata files
+// that can't be read or decoded are skipped in favour of later locations, the
+// first such error is returned only if no metadata describes the file
+func Locate(csvPath string) (*Table, error) {
+	var firstErr error
+	name := filepath.Base(csvPath)
+	for _, path := range MetadataPaths(csvPath) {
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		tables, err := Unmarshal(data)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %s", path, err.Error())
+			}
+			continue
+		}
+		for _, t := range tables {
+			if t.URL == "" || filepath.Base(t.URL) == name {
+				return t, nil
+			}
+		}
+	}
+	return nil, firstErr
+}
+
+// annotationString gives the string value of an annotation. Value objects
+// give their @value, node objects their @id, and arrays their first value
+func annotationString(v interface{}) string {
+	if strs := annotationStrings(v); len(strs) > 0 {
+		return strs[0]
+	}
+	return ""
+}
+
+// annotationStrings gives the string values of an annotation
+func annotationStrings(v interface{}) []string {
+	switch x := v.(type) {
+	case string:
+		return []string{x}
+	case []interface{}:
+		var strs []string
+		for _, item := range x {
+			strs = append(strs, annotationStrings(item)...)
+		}
+		return strs
+	case map[string]interface{}:
+		if val, ok := x["@value"]; ok {
+			return annotationStrings(val)
+		}
+		if id, ok := x["@id"].(string); ok {
+			return []string{id}
+		}
+	}
+	return nil
+}
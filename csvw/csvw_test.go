@@ -0,0 +1,192 @@
+package csvw
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/observations.csv-metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table, got: %d", len(tables))
+	}
+	tbl := tables[0]
+
+	if tbl.URL != "observations.csv" {
+		t.Errorf("url mismatch. expected: %s, got: %s", "observations.csv", tbl.URL)
+	}
+	if tbl.Dialect.StrictQuotes() {
+		t.Errorf("expected null quoteChar to disable strict quoting")
+	}
+	if diff := cmp.Diff(Strings{"Station", "Station"}, tbl.TableSchema.Columns[0].Titles); diff != "" {
+		t.Errorf("titles mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&Datatype{Base: "dateTime"}, tbl.TableSchema.Columns[1].Datatype); diff != "" {
+		t.Errorf("datatype mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"dc:contributor", "dc:description", "dc:license", "dc:modified", "dc:title", "dcat:keyword"}, sortedKeys(tbl.Annotations)); diff != "" {
+		t.Errorf("annotations mismatch (-want +got):\n%s", diff)
+	}
+
+	group := `{
+		"@context": "http://www.w3.org/ns/csvw",
+		"dc:title": "group",
+		"dialect": { "header": false },
+		"tables": [{ "url": "a.csv" }, { "url": "b.csv", "dc:title": "b", "dialect": { "delimiter": ";" } }]
+	}`
+	tables, err = Unmarshal([]byte(group))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables[0].Dialect.HasHeader() || tables[0].Annotations["dc:title"] != "group" {
+		t.Errorf("expected first table to inherit group properties")
+	}
+	if !tables[1].Dialect.HasHeader() || tables[1].Annotations["dc:title"] != "b" {
+		t.Errorf("expected second table to override group properties")
+	}
+
+	cases := []struct {
+		data string
+		err  string
+	}{
+		{`{`, "decoding csvw metadata: unexpected end of JSON input"},
+		{`{"tableSchema":{"columns":[{"titles":5}]}}`, "decoding csvw metadata: expected a string or array of strings, got: 5"},
+	}
+	for i, c := range cases {
+		_, err := Unmarshal([]byte(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestMarshalTable(t *testing.T) {
+	header := false
+	min := 1
+	tbl := &Table{
+		URL:     "body.csv",
+		Dialect: &Dialect{Header: &header},
+		TableSchema: &TableSchema{
+			Columns: []*Column{
+				{Name: "a", Titles: Strings{"a"}, Datatype: &Datatype{Base: "integer"}},
+				{Name: "b", Titles: Strings{"b", "B"}, Datatype: &Datatype{Base: "string", MinLength: &min}},
+			},
+			PrimaryKey: Strings{"a"},
+		},
+		Annotations: map[string]interface{}{"dc:title": "title", "url": "ignored"},
+	}
+	got, err := json.Marshal(tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"@context":"http://www.w3.org/ns/csvw","dc:title":"title","dialect":{"header":false},"tableSchema":{"columns":[{"datatype":"integer","name":"a","titles":"a"},{"datatype":{"base":"string","minLength":1},"name":"b","titles":["b","B"]}],"primaryKey":"a"},"url":"body.csv"}`
+	if string(got) != expect {
+		t.Errorf("result mismatch.\nexpected: %s\ngot:      %s", expect, got)
+	}
+}
+
+func TestDialect(t *testing.T) {
+	yes, no, zero, one := true, false, 0, 1
+	quote, single := `"`, "'"
+	cases := []struct {
+		d      *Dialect
+		header bool
+		strict bool
+	}{
+		{nil, true, true},
+		{&Dialect{}, true, true},
+		{&Dialect{Header: &no}, false, true},
+		{&Dialect{Header: &yes, HeaderRowCount: &zero}, false, true},
+		{&Dialect{Header: &no, HeaderRowCount: &one}, true, true},
+		{&Dialect{QuoteChar: &quote, DoubleQuote: &yes}, true, true},
+		{&Dialect{QuoteChar: &single}, true, false},
+		{&Dialect{DoubleQuote: &no}, true, false},
+	}
+
+	for i, c := range cases {
+		if got := c.d.HasHeader(); got != c.header {
+			t.Errorf("case %d header mismatch. expected: %t, got: %t", i, c.header, got)
+		}
+		if got := c.d.StrictQuotes(); got != c.strict {
+			t.Errorf("case %d strict quotes mismatch. expected: %t, got: %t", i, c.strict, got)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csvw_locate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.csv-metadata.json", `{"url":"a.csv","dc:title":"a"}`)
+	write("csv-metadata.json", `{"tables":[{"url":"b.csv","dc:title":"b"},{"url":"c.csv","dc:title":"c"}]}`)
+
+	cases := []struct {
+		path  string
+		title string
+		err   string
+	}{
+		{filepath.Join(dir, "a.csv"), "a", ""},
+		{filepath.Join(dir, "c.csv"), "c", ""},
+		{filepath.Join(dir, "d.csv"), "", ""},
+		{"testdata/observations.csv", "Weather observations", ""},
+		{"testdata/missing.csv", "", ""},
+	}
+	for i, c := range cases {
+		tbl, err := Locate(c.path)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		title := ""
+		if tbl != nil {
+			title = annotationString(tbl.Annotations["dc:title"])
+		}
+		if title != c.title {
+			t.Errorf("case %d title mismatch. expected: '%s', got: '%s'", i, c.title, title)
+		}
+	}
+
+	// malformed file-level metadata falls back to the directory metadata
+	write("b.csv-metadata.json", `{`)
+	tbl, err := Locate(filepath.Join(dir, "b.csv"))
+	if err != nil {
+		t.Errorf("expected malformed file metadata to be skipped. got: %s", err)
+	} else if title := annotationString(tbl.Annotations["dc:title"]); title != "b" {
+		t.Errorf("expected directory metadata title 'b'. got: '%s'", title)
+	}
+
+	write("csv-metadata.json", `[`)
+	if _, err := Locate(filepath.Join(dir, "b.csv")); err == nil {
+		t.Errorf("expected invalid metadata to error")
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
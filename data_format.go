@@ -3,6 +3,7 @@ package dataset
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // ErrUnknownDataFormat is the expected error for
@@ -65,6 +66,36 @@ func (f DataFormat) String() string {
 	return s
 }
 
+// mediaTypes maps data formats to IANA media types
+var mediaTypes = map[DataFormat]string{
+	CSVDataFormat:  "text/csv",
+	JSONDataFormat: "application/json",
+	XMLDataFormat:  "application/xml",
+	XLSXDataFormat: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	CBORDataFormat: "application/cbor",
+}
+
+// MediaType gives the IANA media type of a data format, returning an empty
+// string for unknown formats
+func (f DataFormat) MediaType() string {
+	return mediaTypes[f]
+}
+
+// ParseMediaType gives the data format for an IANA media type. Media type
+// parameters like "; charset=utf-8" are ignored
+func ParseMediaType(mt string) (DataFormat, error) {
+	if i := strings.Index(mt, ";"); i >= 0 {
+		mt = mt[:i]
+	}
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	for f, t := range mediaTypes {
+		if t == mt {
+			return f, nil
+		}
+	}
+	return UnknownDataFormat, fmt.Errorf("unsupported media type: `%s`", mt)
+}
+
 // ParseDataFormatString takes a string representation of a data format
 // TODO (b5): trim "." prefix, remove prefixed map keys
 func ParseDataFormatString(s string) (df DataFormat, err error) {
@@ -41,6 +41,51 @@ func TestDataFormatString(t *testing.T) {
 	}
 }
 
+func TestDataFormatMediaType(t *testing.T) {
+	cases := []struct {
+		f      DataFormat
+		expect string
+	}{
+		{UnknownDataFormat, ""},
+		{CSVDataFormat, "text/csv"},
+		{JSONDataFormat, "application/json"},
+		{XMLDataFormat, "application/xml"},
+		{XLSXDataFormat, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{CBORDataFormat, "application/cbor"},
+	}
+
+	for i, c := range cases {
+		if got := c.f.MediaType(); got != c.expect {
+			t.Errorf("case %d mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestParseMediaType(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect DataFormat
+		err    string
+	}{
+		{"text/csv", CSVDataFormat, ""},
+		{"text/csv; charset=utf-8", CSVDataFormat, ""},
+		{"Application/JSON", JSONDataFormat, ""},
+		{"application/cbor", CBORDataFormat, ""},
+		{"text/html", UnknownDataFormat, "unsupported media type: `text/html`"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseMediaType(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
 func TestParseDataFormatString(t *testing.T) {
 	cases := []struct {
 		in     string
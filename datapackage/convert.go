@@ -0,0 +1,641 @@
+package datapackage
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset"
+)
+
+// Unmapped describes a value that couldn't be carried across a conversion
+type Unmapped struct {
+	// Path is a JSON pointer to the value in the source document
+	Path string
+	// Reason explains why the value wasn't mapped
+	Reason string
+}
+
+// String implements the stringer interface
+func (u Unmapped) String() string {
+	return fmt.Sprintf("%s: %s", u.Path, u.Reason)
+}
+
+// ToDataset converts a data package to a dataset. The first resource becomes
+// the dataset body. Table schema fields become the columns of a tabular JSON
+// schema, with primary keys recorded in a "primaryKey" schema keyword.
+// ToDataset reports each value that has no dataset equivalent
+func ToDataset(p *Package) (*dataset.Dataset, []Unmapped, error) {
+	if p == nil {
+		return nil, nil, fmt.Errorf("data package is required")
+	}
+	var un []Unmapped
+	for _, key := range p.unknown {
+		un = append(un, Unmapped{"/" + key, "unknown property"})
+	}
+
+	ds := &dataset.Dataset{Name: p.Name}
+	md := &dataset.Meta{
+		Title:       p.Title,
+		Description: p.Description,
+		HomeURL:     p.Homepage,
+		Identifier:  p.ID,
+		Keywords:    p.Keywords,
+		Version:     p.Version,
+	}
+	if p.Created != "" {
+		un = append(un, Unmapped{"/created", "meta has no creation date"})
+	}
+	if p.Image != "" {
+		un = append(un, Unmapped{"/image", "meta has no image"})
+	}
+	if p.Profile != "" && p.Profile != "data-package" && p.Profile != "tabular-data-package" {
+		un = append(un, Unmapped{"/profile", fmt.Sprintf("unsupported profile: %s", p.Profile)})
+	}
+
+	for i, l := range p.Licenses {
+		if i > 0 {
+			un = append(un, Unmapped{fmt.Sprintf("/licenses/%d", i), "meta holds a single license"})
+			continue
+		}
+		md.License = &dataset.License{Type: l.Name, URL: l.Path}
+		if l.Name == "" {
+			md.License.Type = l.Title
+		} else if l.Title != "" {
+			un = append(un, Unmapped{"/licenses/0/title", "license title is dropped when a name is present"})
+		}
+	}
+
+	for i, c := range p.Contributors {
+		md.Contributors = append(md.Contributors, &dataset.User{Fullname: c.Title, Email: c.Email})
+		if c.Path != "" {
+			un = append(un, Unmapped{fmt.Sprintf("/contributors/%d/path", i), "users have no url"})
+		}
+		if c.Role != "" {
+			un = append(un, Unmapped{fmt.Sprintf("/contributors/%d/role", i), "users have no role"})
+		}
+		if c.Organization != "" {
+			un = append(un, Unmapped{fmt.Sprintf("/contributors/%d/organization", i), "users have no organization"})
+		}
+	}
+
+	for _, s := range p.Sources {
+		md.Citations = append(md.Citations, &dataset.Citation{Name: s.Title, URL: s.Path, Email: s.Email})
+	}
+
+	if !md.IsEmpty() {
+		ds.Meta = md
+	}
+
+	for i, r := range p.Resources {
+		if i > 0 {
+			un = append(un, Unmapped{fmt.Sprintf("/resources/%d", i), "datasets have a single body"})
+			continue
+		}
+		resourceUnmapped, err := resourceToDataset(ds, r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resources/0: %s", err.Error())
+		}
+		un = append(un, resourceUnmapped...)
+	}
+
+	return ds, un, nil
+}
+
+func resourceToDataset(ds *dataset.Dataset, r *Resource) ([]Unmapped, error) {
+	var un []Unmapped
+	add := func(path, reason string) {
+		un = append(un, Unmapped{"/resources/0" + path, reason})
+	}
+	for _, key := range r.unknown {
+		add("/"+key, "unknown property")
+	}
+
+	st := &dataset.Structure{
+		Format:   resourceFormat(r),
+		Encoding: r.Encoding,
+		Length:   r.Bytes,
+	}
+	if st.Format == "" {
+		return nil, fmt.Errorf("couldn't determine resource format")
+	}
+
+	if r.Data != nil {
+		ds.Body = r.Data
+	}
+	for i, path := range r.Path {
+		if i == 0 {
+			ds.BodyPath = path
+			continue
+		}
+		add(fmt.Sprintf("/path/%d", i), "multi-part bodies aren't supported")
+	}
+	if r.Title != "" {
+		add("/title", "body has no title")
+	}
+	if r.Description != "" {
+		add("/description", "body has no description")
+	}
+	if r.Hash != "" {
+		add("/hash", "structure checksums are multihashes of the stored body")
+	}
+	for i := range r.Licenses {
+		add(fmt.Sprintf("/licenses/%d", i), "body has no license")
+	}
+	for i := range r.Sources {
+		add(fmt.Sprintf("/sources/%d", i), "body has no sources")
+	}
+
+	if st.Format == "csv" {
+		st.FormatConfig = dialectToFormatConfig(r.Dialect, add)
+	} else if r.Dialect != nil {
+		add("/dialect", "dialects only apply to csv")
+	}
+
+	if r.Schema != nil {
+		st.Schema = tableSchemaToSchema(r.Schema, add)
+	} else {
+		st.Schema = dataset.BaseSchemaArray
+	}
+
+	ds.Structure = st
+	return un, nil
+}
+
+// resourceFormat gives the format of a resource, using format, mediatype &
+// path in that order
+func resourceFormat(r *Resource) string {
+	if r.Format != "" {
+		return strings.ToLower(r.Format)
+	}
+	if df, err := dataset.ParseMediaType(r.Mediatype); err == nil {
+		return df.String()
+	}
+	if len(r.Path) > 0 {
+		return strings.TrimPrefix(strings.ToLower(filepath.Ext(r.Path[0])), ".")
+	}
+	if r.Data != nil {
+		return "json"
+	}
+	return ""
+}
+
+func dialectToFormatConfig(d *Dialect, add func(path, reason string)) map[string]interface{} {
+	// headers are on by default in the csv dialect spec
+	cfg := map[string]interface{}{"headerRow": true}
+	if d == nil {
+		return cfg
+	}
+
+	if d.Header != nil {
+		cfg["headerRow"] = *d.Header
+	}
+	if d.Delimiter != "" && d.Delimiter != "," {
+		if len(d.Delimiter) == 1 {
+			cfg["separator"] = d.Delimiter
+		} else {
+			add("/dialect/delimiter", "separator must be a single character")
+		}
+	}
+	if d.DoubleQuote != nil && !*d.DoubleQuote {
+		add("/dialect/doubleQuote", "csv quotes are always doubled")
+	}
+	if d.QuoteChar != "" && d.QuoteChar != `"` {
+		add("/dialect/quoteChar", "csv quote character is always '\"'")
+	}
+	if d.LineTerminator != "" && d.LineTerminator != "\r\n" && d.LineTerminator != "\n" {
+		add("/dialect/lineTerminator", "csv line terminators are always newlines")
+	}
+	if d.EscapeChar != "" {
+		add("/dialect/escapeChar", "csv has no escape character")
+	}
+	if d.NullSequence != "" {
+		add("/dialect/nullSequence", "csv has no null sequence")
+	}
+	if d.CommentChar != "" {
+		add("/dialect/commentChar", "csv has no comment character")
+	}
+	if d.SkipInitialSpace != nil && *d.SkipInitialSpace {
+		add("/dialect/skipInitialSpace", "csv doesn't skip initial space")
+	}
+	if d.CaseSensitiveHeader != nil {
+		add("/dialect/caseSensitiveHeader", "headers are always case sensitive")
+	}
+	return cfg
+}
+
+// fieldTypes maps table schema types to JSON schema types & formats
+var fieldTypes = map[string][2]string{
+	"string":   {"string", ""},
+	"number":   {"number", ""},
+	"integer":  {"integer", ""},
+	"boolean":  {"boolean", ""},
+	"object":   {"object", ""},
+	"array":    {"array", ""},
+	"date":     {"string", "date"},
+	"time":     {"string", "time"},
+	"datetime": {"string", "date-time"},
+}
+
+// lossyFieldTypes are table schema types without a JSON schema equivalent,
+// mapped to the closest JSON schema type
+var lossyFieldTypes = map[string]string{
+	"year":      "integer",
+	"yearmonth": "string",
+	"duration":  "string",
+	"geopoint":  "string",
+	"geojson":   "object",
+}
+
+// stringFormats are table schema string formats that match JSON schema formats
+var stringFormats = map[string]bool{
+	"email": true,
+	"uri":   true,
+	"uuid":  true,
+}
+
+func tableSchemaToSchema(ts *TableSchema, add func(path, reason string)) map[string]interface{} {
+	cols := make([]interface{}, len(ts.Fields))
+	for i, f := range ts.Fields {
+		path := fmt.Sprintf("/schema/fields/%d", i)
+		for _, key := range f.unknown {
+			add(path+"/"+key, "unknown property")
+		}
+
+		col := map[string]interface{}{"title": f.Name}
+		if f.Description != "" {
+			col["description"] = f.Description
+		}
+		if f.Title != "" {
+			add(path+"/title", "columns have no display title")
+		}
+
+		typ := f.Type
+		if typ == "" {
+			typ = "string"
+		}
+		if t, ok := fieldTypes[typ]; ok {
+			col["type"] = t[0]
+			if t[1] != "" {
+				col["format"] = t[1]
+			}
+		} else if t, ok := lossyFieldTypes[typ]; ok {
+			col["type"] = t
+			add(path+"/type", fmt.Sprintf("%s type is stored as %s", typ, t))
+		} else if typ != "any" {
+			add(path+"/type", fmt.Sprintf("unknown type: %s", typ))
+		}
+
+		if f.Format != "" && f.Format != "default" && f.Format != "any" {
+			if typ == "string" && stringFormats[f.Format] {
+				col["format"] = f.Format
+			} else {
+				add(path+"/format", fmt.Sprintf("unsupported %s format: %s", typ, f.Format))
+			}
+		}
+
+		if c := f.Constraints; c != nil {
+			if c.Required != nil {
+				add(path+"/constraints/required", "columns can't be required")
+			}
+			if c.Unique != nil {
+				add(path+"/constraints/unique", "columns can't be unique, use a primary key")
+			}
+			if c.MinLength != nil {
+				col["minLength"] = *c.MinLength
+			}
+			if c.MaxLength != nil {
+				col["maxLength"] = *c.MaxLength
+			}
+			if c.Minimum != nil {
+				col["minimum"] = c.Minimum
+			}
+			if c.Maximum != nil {
+				col["maximum"] = c.Maximum
+			}
+			if c.Pattern != "" {
+				col["pattern"] = c.Pattern
+			}
+			if c.Enum != nil {
+				col["enum"] = c.Enum
+			}
+		}
+		cols[i] = col
+	}
+
+	sch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": cols,
+		},
+	}
+	if len(ts.PrimaryKey) > 0 {
+		pk := make([]interface{}, len(ts.PrimaryKey))
+		for i, key := range ts.PrimaryKey {
+			pk[i] = key
+		}
+		sch["primaryKey"] = pk
+	}
+	if len(ts.MissingValues) > 0 && !(len(ts.MissingValues) == 1 && ts.MissingValues[0] == "") {
+		add("/schema/missingValues", "missing values are always empty")
+	}
+	for i := range ts.ForeignKeys {
+		add(fmt.Sprintf("/schema/foreignKeys/%d", i), "foreign keys aren't supported")
+	}
+	return sch
+}
+
+// FromDataset converts a dataset to a data package with a single resource for
+// the dataset body. FromDataset reports each dataset value that has no data
+// package equivalent
+func FromDataset(ds *dataset.Dataset) (*Package, []Unmapped, error) {
+	if ds == nil {
+		return nil, nil, fmt.Errorf("dataset is required")
+	}
+	var un []Unmapped
+	add := func(path, reason string) {
+		un = append(un, Unmapped{path, reason})
+	}
+
+	p := &Package{Profile: "data-package", Name: ds.Name}
+	if md := ds.Meta; md != nil {
+		metaToPackage(p, md, add)
+	}
+
+	if ds.Structure != nil || ds.BodyPath != "" || ds.Body != nil {
+		r, err := bodyToResource(ds, add)
+		if err != nil {
+			return nil, nil, err
+		}
+		if r.Profile == "tabular-data-resource" {
+			p.Profile = "tabular-data-package"
+		}
+		p.Resources = []*Resource{r}
+	}
+
+	if ds.Commit != nil {
+		add("/commit", "data packages have no commit")
+	}
+	if ds.Transform != nil {
+		add("/transform", "data packages have no transform")
+	}
+	if ds.Viz != nil {
+		add("/viz", "data packages have no viz")
+	}
+	if ds.Readme != nil {
+		add("/readme", "data packages have no readme")
+	}
+	if ds.Stats != nil {
+		add("/stats", "data packages have no stats")
+	}
+	return p, un, nil
+}
+
+func metaToPackage(p *Package, md *dataset.Meta, add func(path, reason string)) {
+	p.Title = md.Title
+	p.Description = md.Description
+	p.Homepage = md.HomeURL
+	p.ID = md.Identifier
+	p.Keywords = md.Keywords
+	p.Version = md.Version
+
+	if md.License != nil {
+		p.Licenses = []*License{{Name: md.License.Type, Path: md.License.URL}}
+	}
+	for i, u := range md.Contributors {
+		if u == nil {
+			continue
+		}
+		p.Contributors = append(p.Contributors, &Contributor{Title: u.Fullname, Email: u.Email})
+		if u.ID != "" {
+			add(fmt.Sprintf("/meta/contributors/%d/id", i), "contributors have no identifier")
+		}
+	}
+	for _, c := range md.Citations {
+		if c == nil {
+			continue
+		}
+		p.Sources = append(p.Sources, &Source{Title: c.Name, Path: c.URL, Email: c.Email})
+	}
+
+	unmappedMeta := map[string]bool{
+		"accessURL":          md.AccessURL != "",
+		"accrualPeriodicity": md.AccrualPeriodicity != "",
+		"downloadURL":        md.DownloadURL != "",
+		"language":           len(md.Language) > 0,
+		"readmeURL":          md.ReadmeURL != "",
+		"theme":              len(md.Theme) > 0,
+	}
+	for key := range md.Meta() {
+		unmappedMeta[key] = true
+	}
+	keys := make([]string, 0, len(unmappedMeta))
+	for key, set := range unmappedMeta {
+		if set {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add("/meta/"+key, "data packages have no equivalent field")
+	}
+}
+
+func bodyToResource(ds *dataset.Dataset, add func(path, reason string)) (*Resource, error) {
+	name := ds.Name
+	if name == "" {
+		name = "body"
+	}
+	r := &Resource{Profile: "data-resource", Name: name}
+
+	st := ds.Structure
+	if st == nil {
+		st = &dataset.Structure{}
+	}
+	r.Format = st.Format
+	if df, err := dataset.ParseDataFormatString(st.Format); err == nil {
+		r.Mediatype = df.MediaType()
+	}
+	r.Encoding = st.Encoding
+	r.Bytes = st.Length
+
+	switch {
+	case ds.Body != nil:
+		r.Data = ds.Body
+	case ds.BodyPath != "":
+		r.Path = Paths{ds.BodyPath}
+	case st.Format != "":
+		r.Path = Paths{"body." + st.Format}
+	}
+
+	if st.Checksum != "" {
+		add("/structure/checksum", "checksums are multihashes, not data package hashes")
+	}
+	if st.Compression != "" {
+		add("/structure/compression", "data packages have no compression")
+	}
+
+	if st.Format == "csv" {
+		opts, err := dataset.NewCSVOptions(st.FormatConfig)
+		if err != nil {
+			return nil, fmt.Errorf("structure formatConfig: %s", err.Error())
+		}
+		header := opts.HeaderRow
+		r.Dialect = &Dialect{Header: &header}
+		if opts.Separator != 0 && opts.Separator != ',' {
+			r.Dialect.Delimiter = string(opts.Separator)
+		}
+		if opts.LazyQuotes {
+			add("/structure/formatConfig/lazyQuotes", "dialects have no lazy quoting")
+		}
+		if opts.VariadicFields {
+			add("/structure/formatConfig/variadicFields", "table schemas have a fixed number of fields")
+		}
+	} else if len(st.FormatConfig) > 0 {
+		add("/structure/formatConfig", fmt.Sprintf("%s format configuration has no data package equivalent", st.Format))
+	}
+
+	if st.Schema != nil {
+		ts, err := schemaToTableSchema(st.Schema, add)
+		if err != nil {
+			return nil, err
+		}
+		if ts != nil {
+			r.Profile = "tabular-data-resource"
+			r.Schema = ts
+		}
+	}
+	return r, nil
+}
+
+// reverse lookups for JSON schema type & format pairs
+var (
+	formatFieldTypes = map[string]string{"date": "date", "time": "time", "date-time": "datetime"}
+	columnKeys       = map[string]bool{
+		"title": true, "type": true, "format": true, "description": true,
+		"minLength": true, "maxLength": true, "minimum": true, "maximum": true,
+		"pattern": true, "enum": true,
+	}
+)
+
+func schemaToTableSchema(sch map[string]interface{}, add func(path, reason string)) (*TableSchema, error) {
+	items, _ := sch["items"].(map[string]interface{})
+	cols, ok := items["items"].([]interface{})
+	if !ok {
+		add("/structure/schema", "only tabular schemas map to table schemas")
+		return nil, nil
+	}
+
+	ts := &TableSchema{Fields: make([]*Field, len(cols))}
+	for i, c := range cols {
+		path := fmt.Sprintf("/structure/schema/items/items/%d", i)
+		col, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("schema column %d must be an object", i)
+		}
+		f := &Field{}
+		f.Name, _ = col["title"].(string)
+		f.Description, _ = col["description"].(string)
+
+		typ, format := columnType(col["type"]), ""
+		if s, ok := col["format"].(string); ok {
+			format = s
+		}
+		switch {
+		case typ == "":
+			f.Type = "any"
+			if col["type"] != nil {
+				add(path+"/type", "multiple types are stored as any")
+			}
+		case typ == "null":
+			f.Type = "any"
+			add(path+"/type", "null type is stored as any")
+		case typ == "string" && formatFieldTypes[format] != "":
+			f.Type = formatFieldTypes[format]
+		case typ == "string" && stringFormats[format]:
+			f.Type = "string"
+			f.Format = format
+		default:
+			f.Type = typ
+			if format != "" {
+				add(path+"/format", fmt.Sprintf("unsupported format: %s", format))
+			}
+		}
+
+		cons := &Constraints{}
+		if v, ok := col["minLength"]; ok {
+			n := toInt(v)
+			cons.MinLength = &n
+		}
+		if v, ok := col["maxLength"]; ok {
+			n := toInt(v)
+			cons.MaxLength = &n
+		}
+		cons.Minimum = col["minimum"]
+		cons.Maximum = col["maximum"]
+		cons.Pattern, _ = col["pattern"].(string)
+		cons.Enum, _ = col["enum"].([]interface{})
+		if cons.MinLength != nil || cons.MaxLength != nil || cons.Minimum != nil ||
+			cons.Maximum != nil || cons.Pattern != "" || cons.Enum != nil {
+			f.Constraints = cons
+		}
+
+		keys := make([]string, 0, len(col))
+		for key := range col {
+			if !columnKeys[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			add(path+"/"+key, "table schema fields have no equivalent property")
+		}
+		ts.Fields[i] = f
+	}
+
+	if pk, ok := sch["primaryKey"].([]interface{}); ok {
+		for _, key := range pk {
+			if s, ok := key.(string); ok {
+				ts.PrimaryKey = append(ts.PrimaryKey, s)
+			}
+		}
+	}
+	return ts, nil
+}
+
+// columnType gives a single JSON schema type for a column, ignoring "null" in
+// type lists. columns with more than one type return ""
+func columnType(t interface{}) string {
+	switch x := t.(type) {
+	case string:
+		return x
+	case []interface{}:
+		typ := ""
+		for _, v := range x {
+			s, _ := v.(string)
+			if s == "null" {
+				continue
+			}
+			if typ != "" {
+				return ""
+			}
+			typ = s
+		}
+		return typ
+	}
+	return ""
+}
+
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
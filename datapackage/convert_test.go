@@ -0,0 +1,234 @@
+package datapackage
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/qri-io/dataset"
+)
+
+func loadPackage(t *testing.T) *Package {
+	data, err := ioutil.ReadFile("testdata/datapackage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestToDataset(t *testing.T) {
+	ds, un, err := ToDataset(loadPackage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectUnmapped := []Unmapped{
+		{"/x-portal", "unknown property"},
+		{"/licenses/0/title", "license title is dropped when a name is present"},
+		{"/contributors/0/role", "users have no role"},
+		{"/resources/0/schema/fields/0/constraints/required", "columns can't be required"},
+		{"/resources/0/schema/fields/2/type", "year type is stored as integer"},
+		{"/resources/1", "datasets have a single body"},
+	}
+	if diff := cmp.Diff(expectUnmapped, un); diff != "" {
+		t.Errorf("unmapped mismatch (-want +got):\n%s", diff)
+	}
+
+	expectMeta := &dataset.Meta{
+		Title:        "Country, Regional and World GDP",
+		Description:  "Country, regional and world GDP in current US Dollars",
+		HomeURL:      "https://data.worldbank.org/indicator/NY.GDP.MKTP.CD",
+		Version:      "2011",
+		Keywords:     []string{"gdp", "economics"},
+		License:      &dataset.License{Type: "ODC-PDDL-1.0", URL: "http://opendatacommons.org/licenses/pddl/"},
+		Contributors: []*dataset.User{{Fullname: "Rufus Pollock", Email: "rufus@example.com"}},
+		Citations:    []*dataset.Citation{{Name: "World Bank", URL: "http://data.worldbank.org/indicator/NY.GDP.MKTP.CD"}},
+	}
+	if diff := cmp.Diff(expectMeta, ds.Meta, cmp.AllowUnexported(dataset.Meta{})); diff != "" {
+		t.Errorf("meta mismatch (-want +got):\n%s", diff)
+	}
+
+	if ds.BodyPath != "data/gdp.csv" {
+		t.Errorf("bodyPath mismatch. expected: %s, got: %s", "data/gdp.csv", ds.BodyPath)
+	}
+
+	expectStructure := &dataset.Structure{
+		Format:       "csv",
+		Encoding:     "utf-8",
+		Length:       1024,
+		FormatConfig: map[string]interface{}{"headerRow": true, "separator": ";"},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "Country Name", "type": "string"},
+					map[string]interface{}{"title": "Country Code", "type": "string", "minLength": 3, "maxLength": 3, "pattern": "[A-Z]{3}"},
+					map[string]interface{}{"title": "Year", "type": "integer"},
+					map[string]interface{}{"title": "Updated", "type": "string", "format": "date-time"},
+					map[string]interface{}{"title": "Value", "type": "number", "description": "GDP in USD", "minimum": float64(0)},
+				},
+			},
+			"primaryKey": []interface{}{"Country Code", "Year"},
+		},
+	}
+	if diff := cmp.Diff(expectStructure, ds.Structure); diff != "" {
+		t.Errorf("structure mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, _, err := ToDataset(nil); err == nil || err.Error() != "data package is required" {
+		t.Errorf("expected nil package error, got: %v", err)
+	}
+	_, _, err = ToDataset(&Package{Resources: []*Resource{{Name: "a"}}})
+	if err == nil || err.Error() != "resources/0: couldn't determine resource format" {
+		t.Errorf("expected format error, got: %v", err)
+	}
+}
+
+func TestToDatasetInlineData(t *testing.T) {
+	p, err := Unmarshal([]byte(`{
+		"name": "inline",
+		"resources": [{
+			"name": "inline",
+			"data": [["a", 1], ["b", 2]],
+			"dialect": { "delimiter": ";" },
+			"schema": { "fields": [{ "name": "id", "type": "any" }, { "name": "pt", "type": "geopoint", "rdfType": "x" }] }
+		}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, un, err := ToDataset(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.Format != "json" {
+		t.Errorf("expected json format, got: %s", ds.Structure.Format)
+	}
+	if diff := cmp.Diff([]interface{}{[]interface{}{"a", float64(1)}, []interface{}{"b", float64(2)}}, ds.Body); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+	expect := []Unmapped{
+		{"/resources/0/dialect", "dialects only apply to csv"},
+		{"/resources/0/schema/fields/1/rdfType", "unknown property"},
+		{"/resources/0/schema/fields/1/type", "geopoint type is stored as string"},
+	}
+	if diff := cmp.Diff(expect, un); diff != "" {
+		t.Errorf("unmapped mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromDataset(t *testing.T) {
+	ds := &dataset.Dataset{
+		Name:     "gdp",
+		BodyPath: "/ipfs/QmBody",
+		Commit:   &dataset.Commit{Title: "initial commit"},
+		Meta: &dataset.Meta{
+			Title:              "GDP",
+			AccrualPeriodicity: "R/P1Y",
+			Language:           []string{"en"},
+			License:            &dataset.License{Type: "CC-BY-4.0"},
+			Contributors:       []*dataset.User{{ID: "QmUser", Fullname: "Ada"}},
+		},
+		Structure: &dataset.Structure{
+			Format:       "csv",
+			Checksum:     "QmChecksum",
+			FormatConfig: map[string]interface{}{"headerRow": true, "lazyQuotes": true, "separator": "\t"},
+			Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": []interface{}{
+						map[string]interface{}{"title": "day", "type": "string", "format": "date"},
+						map[string]interface{}{"title": "email", "type": "string", "format": "email"},
+						map[string]interface{}{"title": "count", "type": []interface{}{"integer", "null"}, "minimum": float64(0), "multipleOf": float64(2)},
+						map[string]interface{}{"title": "note", "type": []interface{}{"string", "number"}},
+					},
+				},
+				"primaryKey": []interface{}{"day"},
+			},
+		},
+	}
+	ds.Meta.Set("custom", "value")
+
+	p, un, err := FromDataset(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := true
+	expect := &Package{
+		Profile:      "tabular-data-package",
+		Name:         "gdp",
+		Title:        "GDP",
+		Licenses:     []*License{{Name: "CC-BY-4.0"}},
+		Contributors: []*Contributor{{Title: "Ada"}},
+		Resources: []*Resource{{
+			Profile:   "tabular-data-resource",
+			Name:      "gdp",
+			Path:      Paths{"/ipfs/QmBody"},
+			Format:    "csv",
+			Mediatype: "text/csv",
+			Dialect:   &Dialect{Delimiter: "\t", Header: &header},
+			Schema: &TableSchema{
+				Fields: []*Field{
+					{Name: "day", Type: "date"},
+					{Name: "email", Type: "string", Format: "email"},
+					{Name: "count", Type: "integer", Constraints: &Constraints{Minimum: float64(0)}},
+					{Name: "note", Type: "any"},
+				},
+				PrimaryKey: PrimaryKey{"day"},
+			},
+		}},
+	}
+	if diff := cmp.Diff(expect, p, cmp.AllowUnexported(Package{}, Resource{}, Field{})); diff != "" {
+		t.Errorf("package mismatch (-want +got):\n%s", diff)
+	}
+
+	expectUnmapped := []Unmapped{
+		{"/meta/contributors/0/id", "contributors have no identifier"},
+		{"/meta/accrualPeriodicity", "data packages have no equivalent field"},
+		{"/meta/custom", "data packages have no equivalent field"},
+		{"/meta/language", "data packages have no equivalent field"},
+		{"/structure/checksum", "checksums are multihashes, not data package hashes"},
+		{"/structure/formatConfig/lazyQuotes", "dialects have no lazy quoting"},
+		{"/structure/schema/items/items/2/multipleOf", "table schema fields have no equivalent property"},
+		{"/structure/schema/items/items/3/type", "multiple types are stored as any"},
+		{"/commit", "data packages have no commit"},
+	}
+	if diff := cmp.Diff(expectUnmapped, un); diff != "" {
+		t.Errorf("unmapped mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, _, err := FromDataset(nil); err == nil || err.Error() != "dataset is required" {
+		t.Errorf("expected nil dataset error, got: %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ds, _, err := ToDataset(loadPackage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, un, err := FromDataset(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(un) != 0 {
+		t.Errorf("expected no unmapped values, got: %v", un)
+	}
+
+	got, _, err := ToDataset(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(ds, got, cmp.AllowUnexported(dataset.Dataset{}, dataset.Meta{}, dataset.Structure{}), cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,266 @@
+// Package datapackage converts between datasets and Frictionless Data
+// Packages (datapackage.json) with Table Schema resource descriptions.
+// See https://specs.frictionlessdata.io/data-package for the data package
+// specification
+package datapackage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Package is a Frictionless Data Package descriptor
+type Package struct {
+	Profile      string         `json:"profile,omitempty"`
+	Name         string         `json:"name,omitempty"`
+	ID           string         `json:"id,omitempty"`
+	Title        string         `json:"title,omitempty"`
+	Description  string         `json:"description,omitempty"`
+	Homepage     string         `json:"homepage,omitempty"`
+	Version      string         `json:"version,omitempty"`
+	Created      string         `json:"created,omitempty"`
+	Image        string         `json:"image,omitempty"`
+	Keywords     []string       `json:"keywords,omitempty"`
+	Licenses     []*License     `json:"licenses,omitempty"`
+	Contributors []*Contributor `json:"contributors,omitempty"`
+	Sources      []*Source      `json:"sources,omitempty"`
+	Resources    []*Resource    `json:"resources"`
+
+	// unknown property names encountered while decoding
+	unknown []string
+}
+
+// License is a data package license
+type License struct {
+	Name  string `json:"name,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// Contributor is a person or organization that contributed to a package
+type Contributor struct {
+	Title        string `json:"title"`
+	Email        string `json:"email,omitempty"`
+	Path         string `json:"path,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// Source is a raw source for a package
+type Source struct {
+	Title string `json:"title"`
+	Path  string `json:"path,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// Resource describes a single data file or inline data in a package
+type Resource struct {
+	Profile     string       `json:"profile,omitempty"`
+	Name        string       `json:"name"`
+	Path        Paths        `json:"path,omitempty"`
+	Data        interface{}  `json:"data,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Format      string       `json:"format,omitempty"`
+	Mediatype   string       `json:"mediatype,omitempty"`
+	Encoding    string       `json:"encoding,omitempty"`
+	Bytes       int          `json:"bytes,omitempty"`
+	Hash        string       `json:"hash,omitempty"`
+	Dialect     *Dialect     `json:"dialect,omitempty"`
+	Schema      *TableSchema `json:"schema,omitempty"`
+	Licenses    []*License   `json:"licenses,omitempty"`
+	Sources     []*Source    `json:"sources,omitempty"`
+
+	unknown []string
+}
+
+// Paths is the path of a resource, which may be split across multiple files.
+// A single path encodes as a string, multiple paths as an array
+type Paths []string
+
+// MarshalJSON implements the json.Marshaler interface for Paths
+func (p Paths) MarshalJSON() ([]byte, error) {
+	if len(p) == 1 {
+		return json.Marshal(p[0])
+	}
+	return json.Marshal([]string(p))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Paths
+func (p *Paths) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*p = Paths{path}
+		return nil
+	}
+	paths := []string{}
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return fmt.Errorf("path must be a string or array of strings")
+	}
+	*p = Paths(paths)
+	return nil
+}
+
+// Dialect is a CSV dialect description
+type Dialect struct {
+	Delimiter           string `json:"delimiter,omitempty"`
+	DoubleQuote         *bool  `json:"doubleQuote,omitempty"`
+	LineTerminator      string `json:"lineTerminator,omitempty"`
+	QuoteChar           string `json:"quoteChar,omitempty"`
+	EscapeChar          string `json:"escapeChar,omitempty"`
+	NullSequence        string `json:"nullSequence,omitempty"`
+	SkipInitialSpace    *bool  `json:"skipInitialSpace,omitempty"`
+	Header              *bool  `json:"header,omitempty"`
+	CommentChar         string `json:"commentChar,omitempty"`
+	CaseSensitiveHeader *bool  `json:"caseSensitiveHeader,omitempty"`
+}
+
+// TableSchema describes the fields of tabular data
+type TableSchema struct {
+	Fields        []*Field      `json:"fields"`
+	PrimaryKey    PrimaryKey    `json:"primaryKey,omitempty"`
+	MissingValues []string      `json:"missingValues,omitempty"`
+	ForeignKeys   []interface{} `json:"foreignKeys,omitempty"`
+}
+
+// PrimaryKey lists the fields that uniquely identify a row. A single field
+// may be encoded as a string
+type PrimaryKey []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface for PrimaryKey
+func (pk *PrimaryKey) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*pk = PrimaryKey{key}
+		return nil
+	}
+	keys := []string{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("primaryKey must be a string or array of strings")
+	}
+	*pk = PrimaryKey(keys)
+	return nil
+}
+
+// Field describes a single column of tabular data
+type Field struct {
+	Name        string       `json:"name"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Type        string       `json:"type,omitempty"`
+	Format      string       `json:"format,omitempty"`
+	Constraints *Constraints `json:"constraints,omitempty"`
+
+	unknown []string
+}
+
+// Constraints restrict the values a field may hold
+type Constraints struct {
+	Required  *bool         `json:"required,omitempty"`
+	Unique    *bool         `json:"unique,omitempty"`
+	MinLength *int          `json:"minLength,omitempty"`
+	MaxLength *int          `json:"maxLength,omitempty"`
+	Minimum   interface{}   `json:"minimum,omitempty"`
+	Maximum   interface{}   `json:"maximum,omitempty"`
+	Pattern   string        `json:"pattern,omitempty"`
+	Enum      []interface{} `json:"enum,omitempty"`
+}
+
+type _package Package
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Package,
+// recording property names that aren't part of the specification
+func (p *Package) UnmarshalJSON(data []byte) error {
+	_p := _package{}
+	if err := json.Unmarshal(data, &_p); err != nil {
+		return err
+	}
+	unknown, err := unknownKeys(data, _p)
+	if err != nil {
+		return err
+	}
+	_p.unknown = unknown
+	*p = Package(_p)
+	return nil
+}
+
+type _resource Resource
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Resource,
+// recording property names that aren't part of the specification
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	_r := _resource{}
+	if err := json.Unmarshal(data, &_r); err != nil {
+		return err
+	}
+	unknown, err := unknownKeys(data, _r)
+	if err != nil {
+		return err
+	}
+	_r.unknown = unknown
+	*r = Resource(_r)
+	return nil
+}
+
+type _field Field
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Field,
+// recording property names that aren't part of the specification
+func (f *Field) UnmarshalJSON(data []byte) error {
+	_f := _field{}
+	if err := json.Unmarshal(data, &_f); err != nil {
+		return err
+	}
+	unknown, err := unknownKeys(data, _f)
+	if err != nil {
+		return err
+	}
+	_f.unknown = unknown
+	*f = Field(_f)
+	return nil
+}
+
+// unknownKeys lists the keys of a JSON object that don't round-trip through
+// v, which must be a struct decoded from the same data
+func unknownKeys(data []byte, v interface{}) ([]string, error) {
+	all := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]json.RawMessage{}
+	if err := json.Unmarshal(encoded, &known); err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for key := range all {
+		if _, ok := known[key]; !ok && !isZeroJSON(all[key]) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// isZeroJSON checks for values that are dropped by omitempty
+func isZeroJSON(raw json.RawMessage) bool {
+	switch string(raw) {
+	case `""`, `0`, `false`, `null`, `[]`, `{}`:
+		return true
+	}
+	return false
+}
+
+// Unmarshal decodes a datapackage.json descriptor
+func Unmarshal(data []byte) (*Package, error) {
+	p := &Package{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("decoding data package: %s", err.Error())
+	}
+	return p, nil
+}
@@ -0,0 +1,72 @@
+package datapackage
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnmarshal(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/datapackage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"x-portal"}, p.unknown); diff != "" {
+		t.Errorf("unknown package keys mismatch (-want +got):\n%s", diff)
+	}
+	if len(p.Resources) != 2 {
+		t.Fatalf("expected 2 resources, got: %d", len(p.Resources))
+	}
+	r := p.Resources[0]
+	if diff := cmp.Diff(Paths{"data/gdp.csv"}, r.Path); diff != "" {
+		t.Errorf("path mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(PrimaryKey{"Country Code", "Year"}, r.Schema.PrimaryKey); diff != "" {
+		t.Errorf("primary key mismatch (-want +got):\n%s", diff)
+	}
+
+	cases := []struct {
+		data string
+		err  string
+	}{
+		{`{"resources":[{"name":"a","path":["a.csv","b.csv"]}]}`, ""},
+		{`{"resources":[{"name":"a","schema":{"fields":[],"primaryKey":"id"}}]}`, ""},
+		{`{"resources":[{"name":"a","path":5}]}`, "decoding data package: path must be a string or array of strings"},
+		{`{"resources":[{"name":"a","schema":{"fields":[],"primaryKey":5}}]}`, "decoding data package: primaryKey must be a string or array of strings"},
+		{`[]`, "decoding data package: json: cannot unmarshal array into Go value of type datapackage._package"},
+	}
+
+	for i, c := range cases {
+		_, err := Unmarshal([]byte(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestPathsMarshalJSON(t *testing.T) {
+	cases := []struct {
+		p      Paths
+		expect string
+	}{
+		{Paths{"a.csv"}, `"a.csv"`},
+		{Paths{"a.csv", "b.csv"}, `["a.csv","b.csv"]`},
+	}
+
+	for i, c := range cases {
+		got, err := c.p.MarshalJSON()
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+		if string(got) != c.expect {
+			t.Errorf("case %d expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
@@ -74,6 +74,8 @@ type Dataset struct {
 	ProfileID string `json:"profileID,omitempty"`
 	// Readme is a path to the readme file for this dataset
 	Readme *Readme `json:"readme,omitempty"`
+	// Stats holds statistical profiles of the dataset body
+	Stats *Stats `json:"stats,omitempty"`
 	// Number of versions this dataset has, transient
 	NumVersions int `json:"numVersions,omitempty"`
 	// Qri is a key for both identifying this document type, and versioning the
@@ -102,6 +104,7 @@ func (ds *Dataset) IsEmpty() bool {
 		ds.Structure == nil &&
 		ds.Transform == nil &&
 		ds.Readme == nil &&
+		ds.Stats == nil &&
 		ds.Viz == nil
 }
 
@@ -161,6 +164,9 @@ func (ds *Dataset) DropDerivedValues() {
 	if ds.Readme != nil {
 		ds.Readme.DropDerivedValues()
 	}
+	if ds.Stats != nil {
+		ds.Stats.DropDerivedValues()
+	}
 	if ds.Viz != nil {
 		ds.Viz.DropDerivedValues()
 	}
@@ -301,6 +307,11 @@ func (ds *Dataset) Assign(datasets ...*Dataset) {
 		} else if ds.Readme != nil {
 			ds.Readme.Assign(d.Readme)
 		}
+		if ds.Stats == nil && d.Stats != nil {
+			ds.Stats = d.Stats
+		} else if ds.Stats != nil {
+			ds.Stats.Assign(d.Stats)
+		}
 
 		// TODO - wut dis?
 		ds.Commit.Assign(d.Commit)
@@ -334,6 +345,11 @@ func (ds *Dataset) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	data, err := upgradeJSON(data, KindDataset)
+	if err != nil {
+		return err
+	}
+
 	d := _dataset{}
 	if err := json.Unmarshal(data, &d); err != nil {
 		return fmt.Errorf("unmarshaling dataset: %s", err.Error())
@@ -344,13 +360,16 @@ func (ds *Dataset) UnmarshalJSON(data []byte) error {
 
 // UnmarshalDataset tries to extract a dataset type from an empty
 // interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
-func UnmarshalDataset(v interface{}) (*Dataset, error) {
+func UnmarshalDataset(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Dataset, error) {
 	switch r := v.(type) {
 	case *Dataset:
 		return r, nil
 	case Dataset:
 		return &r, nil
 	case []byte:
+		if err := checkStrict(KindDataset, r, configs); err != nil {
+			return nil, err
+		}
 		dataset := &Dataset{}
 		err := json.Unmarshal(r, dataset)
 		return dataset, err
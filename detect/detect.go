@@ -10,6 +10,7 @@ import (
 
 	logger "github.com/ipfs/go-log"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/csvw"
 )
 
 var (
@@ -19,7 +20,10 @@ var (
 )
 
 // FromFile takes a filepath & tries to work out the corresponding dataset
-// for the sake of speed, it only works with files that have a recognized extension
+// for the sake of speed, it only works with files that have a recognized extension.
+// CSV files described by CSV on the Web metadata next to the file use the
+// metadata instead of guessing. Metadata that can't be read or doesn't
+// describe a valid structure is ignored
 func FromFile(path string) (st *dataset.Structure, err error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -32,10 +36,40 @@ func FromFile(path string) (st *dataset.Structure, err error) {
 		return nil, err
 	}
 
+	if format == dataset.CSVDataFormat {
+		t, err := csvw.Locate(path)
+		if err != nil {
+			log.Debugf("ignoring csvw metadata for %s: %s", path, err.Error())
+		} else if t != nil {
+			// check the description is valid before reading any data, so
+			// detection can fall back to reading the file
+			if _, err := csvw.Structure(t); err != nil {
+				log.Debugf("ignoring csvw metadata for %s: %s", path, err.Error())
+			} else {
+				st, _, err = FromCSVW(t, f)
+				return st, err
+			}
+		}
+	}
+
 	st, _, err = FromReader(format, f)
 	return st, err
 }
 
+// FromCSVW creates a structure from a CSV on the Web table description. The
+// reader is only used to detect a schema when the table describes no columns,
+// in which case the dialect's separator & header row are respected
+func FromCSVW(t *csvw.Table, data io.Reader) (st *dataset.Structure, n int, err error) {
+	if st, err = csvw.Structure(t); err != nil {
+		return nil, 0, err
+	}
+	if t.TableSchema != nil && len(t.TableSchema.Columns) > 0 {
+		return st, 0, nil
+	}
+	st.Schema, n, err = CSVSchema(st, data)
+	return st, n, err
+}
+
 // FromReader detects a dataset structure from a reader and data format, returning a detected dataset
 // structure, the number of bytes read from the reader, and any error
 func FromReader(format dataset.DataFormat, data io.Reader) (st *dataset.Structure, n int, err error) {
@@ -28,6 +28,11 @@ func TestFromFile(t *testing.T) {
 		{"testdata/invalid.cbor", "", "invalid top-level type for CBOR data. cbor datasets must begin with either an array or map"},
 		{"testdata/cbor_object.cbor", "testdata/cbor_object.structure.json", ""},
 		{"testdata/cbor_array.cbor", "testdata/cbor_array.structure.json", ""},
+
+		{"testdata/cities.csv", "testdata/cities.structure.json", ""},
+		{"testdata/csvw/readings.csv", "testdata/csvw/readings.structure.json", ""},
+		// malformed metadata falls back to detecting from data
+		{"testdata/broken.csv", "testdata/broken.structure.json", ""},
 	}
 
 	for i, c := range cases {
@@ -56,10 +56,22 @@ func CSVSchema(resource *dataset.Structure, data io.Reader) (schema map[string]i
 	r.TrimLeadingSpace = true
 	r.LazyQuotes = true
 
-	opt := map[string]interface{}{
-		// TODO - for now we're going to assume lazy quotes. we should scan the entire file
-		// for unescaped quotes & only set this to true if that's the case.
-		"lazyQuotes": true,
+	// a separator & header row that are already configured take precedence
+	// over detection
+	preset, err := dataset.NewCSVOptions(resource.FormatConfig)
+	if err != nil {
+		return nil, 0, err
+	}
+	_, headerSet := resource.FormatConfig["headerRow"]
+
+	// detected options are set on top of the preset ones
+	opt := preset.Map()
+	// TODO - for now we're going to assume lazy quotes. we should scan the entire file
+	// for unescaped quotes & only set this to true if that's the case.
+	opt["lazyQuotes"] = true
+	if preset.Separator != 0 {
+		r.Comma = preset.Separator
+		opt["separator"] = string(preset.Separator)
 	}
 	resource.FormatConfig = opt
 
@@ -79,7 +91,7 @@ func CSVSchema(resource *dataset.Structure, data io.Reader) (schema map[string]i
 		types[i] = map[vals.Type]int{}
 	}
 
-	if possibleCsvHeaderRow(header) {
+	if headerSet && preset.HeaderRow || !headerSet && possibleCsvHeaderRow(header) {
 		for i, f := range fields {
 			f.Title = varName.CreateVarNameFromString(header[i])
 			f.Type = vals.TypeUnknown
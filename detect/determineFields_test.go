@@ -154,3 +154,22 @@ func runTestCase(t *testing.T, description string, input []byte, expect map[stri
 		t.Errorf("mismatch for \"%s\" (-want +got):\n%s\n", description, diff)
 	}
 }
+
+func TestCSVSchemaPresetFormatConfig(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"separator": ";", "variadicFields": true},
+	}
+	if _, _, err := CSVSchema(st, bytes.NewReader([]byte("a;b\n1;2\n"))); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"headerRow":      true,
+		"lazyQuotes":     true,
+		"separator":      ";",
+		"variadicFields": true,
+	}
+	if diff := cmp.Diff(expect, st.FormatConfig); diff != "" {
+		t.Errorf("format config mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,321 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/jsonschema"
+)
+
+// DocumentSchema is a draft-07 JSON Schema for dataset documents. The schema
+// for each component is listed under definitions. Components may also be a
+// path string referencing a stored component. Meta allows fields beyond the
+// ones listed here, all other objects are closed
+const DocumentSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Dataset",
+  "type": ["object", "string"],
+  "properties": {
+    "body": true,
+    "bodyBytes": { "type": "string" },
+    "bodyPath": { "type": "string" },
+    "commit": { "$ref": "#/definitions/commit" },
+    "meta": { "$ref": "#/definitions/meta" },
+    "name": { "type": "string" },
+    "numVersions": { "type": "integer" },
+    "path": { "type": "string" },
+    "peername": { "type": "string" },
+    "previousPath": { "type": "string" },
+    "profileID": { "type": "string" },
+    "qri": { "type": "string", "pattern": "^ds:[0-9]+$" },
+    "readme": { "$ref": "#/definitions/readme" },
+    "stats": { "$ref": "#/definitions/stats" },
+    "structure": { "$ref": "#/definitions/structure" },
+    "transform": { "$ref": "#/definitions/transform" },
+    "viz": { "$ref": "#/definitions/viz" }
+  },
+  "additionalProperties": false,
+  "definitions": {
+    "commit": {
+      "title": "Commit",
+      "type": ["object", "string"],
+      "properties": {
+        "author": { "$ref": "#/definitions/user" },
+        "message": { "type": "string" },
+        "path": { "type": "string" },
+        "qri": { "type": "string", "pattern": "^cm:[0-9]+$" },
+        "signature": { "type": "string" },
+        "timestamp": { "type": "string", "format": "date-time" },
+        "title": { "type": "string" }
+      },
+      "additionalProperties": false
+    },
+    "meta": {
+      "title": "Meta",
+      "type": ["object", "string"],
+      "properties": {
+        "accessURL": { "type": "string" },
+        "accrualPeriodicity": { "type": "string" },
+        "citations": {
+          "type": ["array", "null"],
+          "items": { "$ref": "#/definitions/citation" }
+        },
+        "contributors": {
+          "type": "array",
+          "items": { "$ref": "#/definitions/user" }
+        },
+        "description": { "type": "string" },
+        "downloadURL": { "type": "string" },
+        "homeURL": { "type": "string" },
+        "identifier": { "type": "string" },
+        "keywords": { "type": "array", "items": { "type": "string" } },
+        "language": { "type": "array", "items": { "type": "string" } },
+        "license": { "$ref": "#/definitions/license" },
+        "path": { "type": "string" },
+        "qri": { "type": "string", "pattern": "^md:[0-9]+$" },
+        "readmeURL": { "type": "string" },
+        "theme": { "type": "array", "items": { "type": "string" } },
+        "title": { "type": "string" },
+        "version": { "type": "string" }
+      }
+    },
+    "readme": {
+      "title": "Readme",
+      "type": ["object", "string"],
+      "properties": {
+        "format": { "type": "string" },
+        "path": { "type": "string" },
+        "qri": { "type": "string", "pattern": "^rm:[0-9]+$" },
+        "renderedPath": { "type": "string" },
+        "scriptBytes": { "type": "string" },
+        "scriptPath": { "type": "string" }
+      },
+      "additionalProperties": false
+    },
+    "stats": {
+      "title": "Stats",
+      "type": ["object", "string"],
+      "properties": {
+        "path": { "type": "string" },
+        "qri": { "type": "string", "pattern": "^sa:[0-9]+$" },
+        "stats": true
+      },
+      "additionalProperties": false
+    },
+    "structure": {
+      "title": "Structure",
+      "type": ["object", "string"],
+      "properties": {
+        "checksum": { "type": "string" },
+        "compression": { "type": "string" },
+        "depth": { "type": "integer" },
+        "encoding": { "type": "string" },
+        "entries": { "type": "integer" },
+        "errCount": { "type": "integer" },
+        "format": { "type": "string" },
+        "formatConfig": { "type": "object" },
+        "length": { "type": "integer" },
+        "path": { "type": "string" },
+        "qri": { "type": "string", "pattern": "^st:[0-9]+$" },
+        "schema": { "type": "object" },
+        "strict": { "type": "boolean" }
+      },
+      "additionalProperties": false
+    },
+    "transform": {
+      "title": "Transform",
+      "type": ["object", "string"],
+      "properties": {
+        "config": { "type": "object" },
+        "path": { "type": "string" },
+        "qri": { "type": "string", "pattern": "^tf:[0-9]+$" },
+        "resources": {
+          "type": "object",
+          "additionalProperties": { "$ref": "#/definitions/transformResource" }
+        },
+        "scriptBytes": { "type": "string" },
+        "scriptPath": { "type": "string" },
+        "secrets": {
+          "type": "object",
+          "additionalProperties": { "type": "string" }
+        },
+        "syntax": { "type": "string" },
+        "syntaxVersion": { "type": "string" }
+      },
+      "additionalProperties": false
+    },
+    "viz": {
+      "title": "Viz",
+      "type": ["object", "string"],
+      "properties": {
+        "format": { "type": "string" },
+        "path": { "type": "string" },
+        "qri": { "type": "string", "pattern": "^vz:[0-9]+$" },
+        "renderedPath": { "type": "string" },
+        "scriptBytes": { "type": "string" },
+        "scriptPath": { "type": "string" }
+      },
+      "additionalProperties": false
+    },
+    "citation": {
+      "type": "object",
+      "properties": {
+        "email": { "type": "string" },
+        "name": { "type": "string" },
+        "url": { "type": "string" }
+      },
+      "additionalProperties": false
+    },
+    "license": {
+      "type": "object",
+      "properties": {
+        "type": { "type": "string" },
+        "url": { "type": "string" }
+      },
+      "additionalProperties": false
+    },
+    "transformResource": {
+      "type": ["object", "string"],
+      "properties": {
+        "path": { "type": "string" }
+      },
+      "additionalProperties": false
+    },
+    "user": {
+      "type": "object",
+      "properties": {
+        "email": { "type": "string" },
+        "id": { "type": "string" },
+        "name": { "type": "string" }
+      },
+      "additionalProperties": false
+    }
+  }
+}`
+
+// schemaDefinitions maps kind types to their definition in DocumentSchema
+var schemaDefinitions = map[string]string{
+	KindCommit.Type():    "commit",
+	KindMeta.Type():      "meta",
+	KindReadme.Type():    "readme",
+	KindStats.Type():     "stats",
+	KindStructure.Type(): "structure",
+	KindTransform.Type(): "transform",
+	KindViz.Type():       "viz",
+}
+
+// compiled schemas, keyed by kind type
+var documentSchemas = map[string]*jsonschema.RootSchema{}
+
+func init() {
+	for _, typ := range append([]string{KindDataset.Type()}, sortedKeys(schemaDefinitions)...) {
+		data, err := JSONSchema(typ)
+		if err != nil {
+			panic(err)
+		}
+		documentSchemas[typ] = jsonschema.Must(string(data))
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// JSONSchema gives a standalone draft-07 JSON Schema for a document kind type,
+// one of "ds", "cm", "md", "rm", "sa", "st", "tf" or "vz"
+func JSONSchema(kindType string) ([]byte, error) {
+	if kindType == KindDataset.Type() {
+		return []byte(DocumentSchema), nil
+	}
+	def, ok := schemaDefinitions[kindType]
+	if !ok {
+		return nil, fmt.Errorf("no schema for kind type: '%s'", kindType)
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(DocumentSchema), &doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema":     doc["$schema"],
+		"$ref":        "#/definitions/" + def,
+		"definitions": doc["definitions"],
+	}, "", "  ")
+}
+
+// SchemaError lists the problems found checking a document against it's JSON
+// schema. Each problem is located by a JSON pointer
+type SchemaError []jsonschema.ValError
+
+// Error implements the error interface
+func (e SchemaError) Error() string {
+	strs := make([]string, len(e))
+	for i, ve := range e {
+		strs[i] = ve.Error()
+	}
+	return strings.Join(strs, "; ")
+}
+
+// ValidateJSON checks JSON data against the schema for a document kind type,
+// returning a SchemaError listing unknown fields & type mismatches
+func ValidateJSON(kindType string, data []byte) error {
+	rs, ok := documentSchemas[kindType]
+	if !ok {
+		return fmt.Errorf("no schema for kind type: '%s'", kindType)
+	}
+	errs, err := rs.ValidateBytes(data)
+	if err != nil {
+		return err
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	for i, ve := range errs {
+		// DocumentSchema only uses false schemas to close objects
+		if ve.Message == "cannot match schema" {
+			errs[i].Message = "unknown field"
+			errs[i].InvalidValue = nil
+		}
+		if errs[i].PropertyPath == "" {
+			errs[i].PropertyPath = "/"
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].PropertyPath < errs[j].PropertyPath })
+	return SchemaError(errs)
+}
+
+// UnmarshalCfg configures the Unmarshal functions of this package
+type UnmarshalCfg struct {
+	// Strict rejects encoded documents with unknown fields or values of the
+	// wrong type, checking against DocumentSchema
+	Strict bool
+}
+
+// Strict is an unmarshal option that turns on strict decoding
+func Strict(cfg *UnmarshalCfg) {
+	cfg.Strict = true
+}
+
+// checkStrict validates document data when strict decoding is configured
+func checkStrict(kind Kind, data []byte, configs []func(cfg *UnmarshalCfg)) error {
+	cfg := &UnmarshalCfg{}
+	for _, opt := range configs {
+		opt(cfg)
+	}
+	if !cfg.Strict {
+		return nil
+	}
+	data, err := upgradeJSON(data, kind)
+	if err != nil {
+		return err
+	}
+	return ValidateJSON(kind.Type(), data)
+}
@@ -0,0 +1,141 @@
+package dataset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestJSONSchema(t *testing.T) {
+	for _, typ := range []string{"ds", "cm", "md", "rm", "sa", "st", "tf", "vz"} {
+		data, err := JSONSchema(typ)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", typ, err)
+			continue
+		}
+		sch := map[string]interface{}{}
+		if err := json.Unmarshal(data, &sch); err != nil {
+			t.Errorf("%s: invalid schema json: %s", typ, err)
+		}
+		if sch["$schema"] != "http://json-schema.org/draft-07/schema#" {
+			t.Errorf("%s: expected draft-07 schema. got: %v", typ, sch["$schema"])
+		}
+	}
+
+	if _, err := JSONSchema("zz"); err == nil || err.Error() != "no schema for kind type: 'zz'" {
+		t.Errorf("expected unknown type error. got: %s", err)
+	}
+}
+
+func TestValidateJSONTestdata(t *testing.T) {
+	cases := []struct {
+		typ  string
+		path string
+		err  string
+	}{
+		{"ds", "testdata/datasets/continent-codes.json", ""},
+		{"ds", "testdata/datasets/hours.json", ""},
+		{"md", "testdata/metadata/airport-codes.json", ""},
+		{"md", "testdata/metadata/continent-codes.json", ""},
+		{"md", "testdata/metadata/hours.json", ""},
+		{"st", "testdata/structures/continent-codes.json", ""},
+		// these files contain fields from older, unversioned documents
+		{"ds", "testdata/datasets/airport-codes.json", "/meta/citations/0/web: unknown field"},
+		{"ds", "testdata/datasets/complete.json", "/abstract: unknown field; /abstractTransform: unknown field; /transform/data: unknown field; /transform/structure: unknown field"},
+		{"st", "testdata/structures/hours.json", "/formatOptions: unknown field"},
+	}
+
+	for _, c := range cases {
+		data, err := ioutil.ReadFile(c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ValidateJSON(c.typ, data)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%s: error mismatch. expected: '%s', got: '%s'", c.path, c.err, err)
+		}
+	}
+}
+
+func TestValidateJSONMarshaledDataset(t *testing.T) {
+	ds := &Dataset{
+		BodyPath:     "/ipfs/QmBody",
+		PreviousPath: "/ipfs/QmPrev",
+		Commit:       &Commit{Title: "initial", Timestamp: time.Date(2001, 01, 01, 01, 01, 01, 0, time.UTC), Author: &User{ID: "QmAuthor"}},
+		Meta:         &Meta{Title: "title", Keywords: []string{"a"}, License: &License{Type: "CC-BY-4.0"}, Citations: []*Citation{{Name: "cite"}}},
+		Structure:    &Structure{Format: "csv", FormatConfig: map[string]interface{}{"headerRow": true}, Schema: map[string]interface{}{"type": "array"}, Length: 10},
+		Transform:    &Transform{Syntax: "starlark", Resources: map[string]*TransformResource{"a": {Path: "/ipfs/QmA"}}, Secrets: map[string]string{"key": "value"}},
+		Viz:          &Viz{Format: "html", ScriptBytes: []byte("<html></html>")},
+		Readme:       NewReadmeRef("/ipfs/QmReadme"),
+		Stats:        &Stats{Stats: []interface{}{map[string]interface{}{"count": 2}}},
+	}
+	data, err := json.Marshal(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateJSON("ds", data); err != nil {
+		t.Errorf("marshaled dataset failed validation: %s", err)
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	cases := []struct {
+		description string
+		data        string
+		err         string
+	}{
+		{"valid", `{"qri":"ds:0","structure":{"format":"csv","length":2},"meta":{"title":"a","custom":true}}`, ""},
+		{"path reference", `"/ipfs/QmFoo"`, ""},
+		{"unknown field", `{"strucutre":{"format":"csv"}}`, "/strucutre: unknown field"},
+		{"nested unknown field", `{"structure":{"fromat":"csv"}}`, "/structure/fromat: unknown field"},
+		{"type mismatch", `{"structure":{"format":1}}`, "/structure/format: 1 type should be string"},
+		{"integer mismatch", `{"structure":{"length":1.5}}`, "/structure/length: 1.5 type should be integer"},
+		{"multiple errors sorted by location", `{"viz":{"format":true},"commit":{"title":["a"]}}`, `/commit/title: ["a"] type should be string; /viz/format: true type should be string`},
+		{"deep location", `{"commit":{"author":{"id":5}}}`, "/commit/author/id: 5 type should be string"},
+		{"kind mismatch", `{"qri":"st:0"}`, `/qri: "st:0" regexp pattrn ^ds:[0-9]+$ mismatch on string: st:0`},
+		{"newer kind", `{"qri":"ds:99"}`, "unsupported kind ds:99: newer than current spec version 0"},
+	}
+
+	for _, c := range cases {
+		_, err := UnmarshalDataset([]byte(c.data), Strict)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%s: error mismatch. expected: '%s', got: '%s'", c.description, c.err, err)
+		}
+	}
+
+	// without strict typos are silently dropped
+	ds, err := UnmarshalDataset([]byte(`{"strucutre":{"format":"csv"}}`))
+	if err != nil {
+		t.Errorf("unexpected non-strict error: %s", err)
+	} else if ds.Structure != nil {
+		t.Errorf("expected misspelled structure to be dropped")
+	}
+}
+
+func TestUnmarshalComponentsStrict(t *testing.T) {
+	cases := []struct {
+		description string
+		unmarshal   func(data []byte) error
+		data        string
+		err         string
+	}{
+		{"commit", func(d []byte) error { _, err := UnmarshalCommit(d, Strict); return err }, `{"titel":"a"}`, "/titel: unknown field"},
+		{"commit timestamp", func(d []byte) error { _, err := UnmarshalCommit(d, Strict); return err }, `{"timestamp":5}`, "/timestamp: 5 type should be string"},
+		{"meta", func(d []byte) error { _, err := UnmarshalMeta(d, Strict); return err }, `{"keywords":"a"}`, `/keywords: "a" type should be array`},
+		{"meta license", func(d []byte) error { _, err := UnmarshalMeta(d, Strict); return err }, `{"license":{"typ":"a"}}`, "/license/typ: unknown field"},
+		{"readme", func(d []byte) error { _, err := UnmarshalReadme(d, Strict); return err }, `{"scriptPath":"a","x":1}`, "/x: unknown field"},
+		{"stats", func(d []byte) error { _, err := UnmarshalStats(d, Strict); return err }, `{"stats":[],"path":1}`, "/path: 1 type should be string"},
+		{"structure", func(d []byte) error { _, err := UnmarshalStructure(d, Strict); return err }, `{"format":"json","schema":"nope"}`, `/schema: "nope" type should be object`},
+		{"transform", func(d []byte) error { _, err := UnmarshalTransform(d, Strict); return err }, `{"secrets":{"a":1}}`, "/secrets/a: 1 type should be string"},
+		{"viz", func(d []byte) error { _, err := UnmarshalViz(d, Strict); return err }, `{"format":"html","scirptPath":"a"}`, "/scirptPath: unknown field"},
+		{"valid viz", func(d []byte) error { _, err := UnmarshalViz(d, Strict); return err }, `{"format":"html","scriptPath":"a"}`, ""},
+	}
+
+	for _, c := range cases {
+		err := c.unmarshal([]byte(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%s: error mismatch. expected: '%s', got: '%s'", c.description, c.err, err)
+		}
+	}
+}
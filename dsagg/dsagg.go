@@ -0,0 +1,338 @@
+// Package dsagg computes grouped aggregates like counts, sums & means over the
+// entries of a dataset body. Aggregation reads from a dsio.EntryReader and
+// produces a new tabular EntryReader with one row per group:
+//
+//	spec, err := dsagg.ParseSpec([]string{"state"}, "count", "sum(population)", "mean(age)")
+//	r, err := dsagg.Apply(bodyReader, spec)
+//
+// Partial results are held in memory for up to Spec.MaxGroups groups, beyond
+// which they are spilled to disk & merged, so high-cardinality groupings
+// don't need to fit in memory
+package dsagg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	logger "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+)
+
+var log = logger.Logger("dsagg")
+
+// group is the set of partial aggregate states for one distinct combination
+// of group column values
+type group struct {
+	values []interface{}
+	states []*state
+}
+
+// aggregator holds the working state of an aggregation
+type aggregator struct {
+	spec      *Spec
+	groupCols []dsio.Column
+	aggCols   []*dsio.Column
+	outTypes  []vals.Type
+	maxGroups int
+	groups    map[string]*group
+	partials  *dsio.StructuredRowBuffer
+}
+
+// partialsStructure describes the rows used to spill partial results to disk
+var partialsStructure = &dataset.Structure{
+	Format: dataset.JSONDataFormat.String(),
+	Schema: map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "key", "type": "string"},
+				map[string]interface{}{"title": "values", "type": "array"},
+				map[string]interface{}{"title": "states", "type": "array"},
+			},
+		},
+	},
+}
+
+// Apply reads all entries from r, returning a reader of aggregated rows with
+// a tabular structure. Output rows consist of the group columns followed by
+// aggregates in the order they're specified, sorted by group values
+func Apply(r dsio.EntryReader, spec *Spec) (dsio.EntryReader, error) {
+	if spec == nil || len(spec.Aggregates) == 0 {
+		return nil, fmt.Errorf("at least one aggregate is required")
+	}
+
+	a, err := newAggregator(r.Structure(), spec)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	if err := dsio.EachEntry(r, func(_ int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		return a.add(ent)
+	}); err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	return a.finish()
+}
+
+func newAggregator(st *dataset.Structure, spec *Spec) (*aggregator, error) {
+	a := &aggregator{
+		spec:      spec,
+		maxGroups: spec.MaxGroups,
+		groups:    map[string]*group{},
+	}
+	if a.maxGroups <= 0 {
+		a.maxGroups = DefaultMaxGroups
+	}
+
+	var err error
+	if a.groupCols, err = dsio.FindColumns(st, spec.GroupBy...); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, col := range a.groupCols {
+		seen[col.Title] = true
+	}
+
+	a.aggCols = make([]*dsio.Column, len(spec.Aggregates))
+	a.outTypes = make([]vals.Type, len(spec.Aggregates))
+	for i, agg := range spec.Aggregates {
+		if !agg.Func.valid() {
+			return nil, fmt.Errorf("invalid aggregate function: '%s'", agg.Func)
+		}
+		in := vals.TypeUnknown
+		if agg.Column != "" {
+			cols, err := dsio.FindColumns(st, agg.Column)
+			if err != nil {
+				return nil, err
+			}
+			a.aggCols[i] = &cols[0]
+			in = cols[0].Type
+		} else if agg.Func != FuncCount {
+			return nil, fmt.Errorf("aggregate function %s requires a column", agg.Func)
+		}
+		a.outTypes[i] = agg.outputType(in)
+
+		title := agg.Title()
+		if seen[title] {
+			return nil, fmt.Errorf("duplicate output column: %s", title)
+		}
+		seen[title] = true
+	}
+
+	return a, nil
+}
+
+// Structure gives the tabular structure of aggregated output
+func (a *aggregator) Structure() *dataset.Structure {
+	items := make([]interface{}, 0, len(a.groupCols)+len(a.spec.Aggregates))
+	for _, col := range a.groupCols {
+		items = append(items, column(col.Title, col.Type))
+	}
+	for i, agg := range a.spec.Aggregates {
+		items = append(items, column(agg.Title(), a.outTypes[i]))
+	}
+
+	return &dataset.Structure{
+		Format: dataset.JSONDataFormat.String(),
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": items,
+			},
+		},
+	}
+}
+
+func column(title string, t vals.Type) map[string]interface{} {
+	col := map[string]interface{}{"title": title}
+	if t != vals.TypeUnknown {
+		col["type"] = t.String()
+	}
+	return col
+}
+
+func (a *aggregator) newGroup(values []interface{}) *group {
+	g := &group{values: values, states: make([]*state, len(a.spec.Aggregates))}
+	for i, agg := range a.spec.Aggregates {
+		t := vals.TypeUnknown
+		if col := a.aggCols[i]; col != nil {
+			t = col.Type
+		}
+		g.states[i] = newState(agg, t)
+	}
+	return g
+}
+
+// add folds one entry into its group
+func (a *aggregator) add(ent dsio.Entry) error {
+	values := make([]interface{}, len(a.groupCols))
+	for i, col := range a.groupCols {
+		values[i] = col.Value(ent.Value)
+	}
+	key, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("encoding group key: %s", err.Error())
+	}
+
+	g, ok := a.groups[string(key)]
+	if !ok {
+		if len(a.groups) >= a.maxGroups {
+			if err := a.spill(); err != nil {
+				return err
+			}
+		}
+		g = a.newGroup(values)
+		a.groups[string(key)] = g
+	}
+
+	for i, agg := range a.spec.Aggregates {
+		if col := a.aggCols[i]; col != nil {
+			g.states[i].add(agg.Func, col.Value(ent.Value), false)
+		} else {
+			g.states[i].add(agg.Func, nil, true)
+		}
+	}
+	return nil
+}
+
+// spill writes all in-memory groups to the partials buffer, which is sorted
+// by group key & itself spills to disk when necessary
+func (a *aggregator) spill() error {
+	if a.partials == nil {
+		var err error
+		if a.partials, err = dsio.NewStructuredRowBuffer(partialsStructure, func(cfg *dsio.StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"key"}
+			cfg.TempDir = a.spec.TempDir
+		}); err != nil {
+			return err
+		}
+	}
+
+	for key, g := range a.groups {
+		states := make([]interface{}, len(g.states))
+		for i, s := range g.states {
+			states[i] = s.encode()
+		}
+		if err := a.partials.WriteEntry(dsio.Entry{Value: []interface{}{key, g.values, states}}); err != nil {
+			return err
+		}
+	}
+	a.groups = map[string]*group{}
+	return nil
+}
+
+// finish writes final results to a sorted output buffer
+func (a *aggregator) finish() (dsio.EntryReader, error) {
+	out, err := dsio.NewStructuredRowBuffer(a.Structure(), func(cfg *dsio.StructuredRowBufferCfg) {
+		cfg.OrderBy = a.spec.GroupBy
+		cfg.TempDir = a.spec.TempDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.groupCols) == 0 && len(a.groups) == 0 && a.partials == nil {
+		// aggregating an empty body without grouping still produces a row
+		a.groups[""] = a.newGroup(nil)
+	}
+
+	if a.partials == nil {
+		for _, g := range a.groups {
+			if err := out.WriteEntry(dsio.Entry{Value: a.row(g)}); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := a.mergePartials(out); err != nil {
+		out.Close()
+		return nil, err
+	}
+
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// mergePartials combines spilled partial results. partials are sorted by key,
+// so all partial states for a group are adjacent
+func (a *aggregator) mergePartials(out dsio.EntryWriter) error {
+	if err := a.spill(); err != nil {
+		return err
+	}
+	if err := a.partials.Close(); err != nil {
+		return err
+	}
+	defer a.partials.Close()
+
+	var (
+		prevKey string
+		cur     *group
+	)
+	for {
+		ent, err := a.partials.ReadEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		rec, ok := ent.Value.([]interface{})
+		if !ok || len(rec) != 3 {
+			return fmt.Errorf("invalid partial aggregate")
+		}
+		key, _ := rec[0].(string)
+		values, _ := rec[1].([]interface{})
+		states, _ := rec[2].([]interface{})
+		if len(states) != len(a.spec.Aggregates) {
+			return fmt.Errorf("invalid partial aggregate")
+		}
+
+		if cur == nil || key != prevKey {
+			if cur != nil {
+				if err := out.WriteEntry(dsio.Entry{Value: a.row(cur)}); err != nil {
+					return err
+				}
+			}
+			cur = a.newGroup(values)
+			prevKey = key
+		}
+
+		for i, sv := range states {
+			s, err := decodeState(sv)
+			if err != nil {
+				return err
+			}
+			cur.states[i].merge(s)
+		}
+	}
+
+	if cur != nil {
+		return out.WriteEntry(dsio.Entry{Value: a.row(cur)})
+	}
+	return nil
+}
+
+// row creates an output row from a group
+func (a *aggregator) row(g *group) []interface{} {
+	row := make([]interface{}, 0, len(a.groupCols)+len(g.states))
+	row = append(row, g.values...)
+	for len(row) < len(a.groupCols) {
+		row = append(row, nil)
+	}
+	for i, s := range g.states {
+		row = append(row, s.result(a.spec.Aggregates[i], a.outTypes[i]))
+	}
+	return row
+}
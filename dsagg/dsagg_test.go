@@ -0,0 +1,148 @@
+package dsagg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+var citiesStructure = &dataset.Structure{
+	Format: "json",
+	Schema: map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "country", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+				map[string]interface{}{"title": "avg_age", "type": "number"},
+			},
+		},
+	},
+}
+
+const citiesBody = `[
+["toronto","canada",40000000,55.5],
+["new york","usa",8500000,44.4],
+["chicago","usa",300000,44.4],
+["chatham","canada",35000,65.25],
+["raleigh","usa",250000,null],
+["new york","usa",8500000,44.4]
+]`
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		description string
+		groupBy     []string
+		aggregates  []string
+		maxGroups   int
+		titles      []string
+		expect      string
+		err         string
+	}{
+		{"count rows",
+			nil, []string{"count"}, 0,
+			[]string{"count"},
+			`[[6]]`, ""},
+		{"ungrouped aggregates",
+			nil, []string{"sum(pop)", "min(city)", "max(avg_age)", "count(avg_age)", "count_distinct(city)"}, 0,
+			[]string{"sum_pop", "min_city", "max_avg_age", "count_avg_age", "count_distinct_city"},
+			`[[57585000,"chatham",65.25,5,5]]`, ""},
+		{"group by country",
+			[]string{"country"}, []string{"count", "sum(pop)", "mean(avg_age) as age"}, 0,
+			[]string{"country", "count", "sum_pop", "age"},
+			`[["canada",2,40035000,60.375],["usa",4,17550000,44.4]]`, ""},
+		{"group by multiple columns",
+			[]string{"country", "city"}, []string{"count"}, 0,
+			[]string{"country", "city", "count"},
+			`[["canada","chatham",1],["canada","toronto",1],["usa","chicago",1],["usa","new york",2],["usa","raleigh",1]]`, ""},
+		{"spilling partial groups",
+			[]string{"city"}, []string{"count", "sum(pop)", "count_distinct(country)"}, 1,
+			[]string{"city", "count", "sum_pop", "count_distinct_country"},
+			`[["chatham",1,35000,1],["chicago",1,300000,1],["new york",2,17000000,1],["raleigh",1,250000,1],["toronto",1,40000000,1]]`, ""},
+
+		{"missing group column",
+			[]string{"state"}, []string{"count"}, 0, nil, "", "couldn't find column: state"},
+		{"missing aggregate column",
+			nil, []string{"sum(height)"}, 0, nil, "", "couldn't find column: height"},
+		{"duplicate titles",
+			[]string{"city"}, []string{"count as city"}, 0, nil, "", "duplicate output column: city"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			spec, err := ParseSpec(c.groupBy, c.aggregates...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			spec.MaxGroups = c.maxGroups
+
+			r, err := dsio.NewJSONReader(citiesStructure, strings.NewReader(citiesBody))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := Apply(r, spec)
+			if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+				t.Fatalf("error mismatch. expected: '%s', got: '%v'", c.err, err)
+			}
+			if c.err != "" {
+				return
+			}
+
+			cols, err := dsio.Columns(out.Structure())
+			if err != nil {
+				t.Fatal(err)
+			}
+			titles := make([]string, len(cols))
+			for i, col := range cols {
+				titles[i] = col.Title
+			}
+			if diff := cmp.Diff(c.titles, titles); diff != "" {
+				t.Errorf("output column mismatch (-want +got):\n%s", diff)
+			}
+
+			rows := []interface{}{}
+			if err := dsio.EachEntry(out, func(_ int, ent dsio.Entry, err error) error {
+				rows = append(rows, ent.Value)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.expect != string(got) {
+				t.Errorf("result mismatch.\nwant: %s\ngot:  %s", c.expect, string(got))
+			}
+		})
+	}
+}
+
+func TestApplyEmptyBody(t *testing.T) {
+	spec, err := ParseSpec(nil, "count", "sum(pop)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := dsio.NewJSONReader(citiesStructure, strings.NewReader(`[]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Apply(r, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ent, err := out.ReadEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{int64(0), nil}, ent.Value); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
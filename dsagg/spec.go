@@ -0,0 +1,137 @@
+package dsagg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset/vals"
+)
+
+// Func is the name of an aggregate function
+type Func string
+
+const (
+	// FuncCount counts rows, or non-null values when given a column
+	FuncCount Func = "count"
+	// FuncCountDistinct counts distinct non-null values of a column
+	FuncCountDistinct Func = "count_distinct"
+	// FuncSum adds numeric values of a column
+	FuncSum Func = "sum"
+	// FuncMean averages numeric values of a column
+	FuncMean Func = "mean"
+	// FuncMin gives the smallest value of a column
+	FuncMin Func = "min"
+	// FuncMax gives the largest value of a column
+	FuncMax Func = "max"
+)
+
+// valid checks if a function name is a supported aggregate
+func (f Func) valid() bool {
+	switch f {
+	case FuncCount, FuncCountDistinct, FuncSum, FuncMean, FuncMin, FuncMax:
+		return true
+	}
+	return false
+}
+
+// Aggregate is a single aggregate function applied to a column
+type Aggregate struct {
+	// Func is the function to apply
+	Func Func
+	// Column is the title of the column to aggregate. Only count may omit
+	// a column, counting all rows in the group
+	Column string
+	// As is the title of the output column. defaults to func_column,
+	// or just the function name when no column is given
+	As string
+}
+
+// Title gives the output column title for this aggregate
+func (a Aggregate) Title() string {
+	if a.As != "" {
+		return a.As
+	}
+	if a.Column == "" {
+		return string(a.Func)
+	}
+	return fmt.Sprintf("%s_%s", a.Func, a.Column)
+}
+
+// outputType gives the type of values this aggregate produces, given the
+// type of the input column
+func (a Aggregate) outputType(in vals.Type) vals.Type {
+	switch a.Func {
+	case FuncCount, FuncCountDistinct:
+		return vals.TypeInteger
+	case FuncSum:
+		if in == vals.TypeInteger {
+			return vals.TypeInteger
+		}
+		return vals.TypeNumber
+	case FuncMean:
+		return vals.TypeNumber
+	default:
+		return in
+	}
+}
+
+// ParseAggregate parses a string of the form "func(column)" into an
+// Aggregate. "count" and "count()" count all rows. A trailing " as name"
+// sets the output column title
+func ParseAggregate(str string) (Aggregate, error) {
+	a := Aggregate{}
+	str = strings.TrimSpace(str)
+	if i := strings.Index(strings.ToLower(str), " as "); i > 0 {
+		a.As = strings.TrimSpace(str[i+4:])
+		str = strings.TrimSpace(str[:i])
+	}
+
+	if open := strings.Index(str, "("); open >= 0 {
+		if !strings.HasSuffix(str, ")") {
+			return a, fmt.Errorf("invalid aggregate '%s': missing closing parenthesis", str)
+		}
+		a.Column = strings.TrimSpace(str[open+1 : len(str)-1])
+		str = str[:open]
+	}
+
+	a.Func = Func(strings.ToLower(strings.TrimSpace(str)))
+	if !a.Func.valid() {
+		return a, fmt.Errorf("invalid aggregate function: '%s'", a.Func)
+	}
+	if a.Column == "" && a.Func != FuncCount {
+		return a, fmt.Errorf("aggregate function %s requires a column", a.Func)
+	}
+	return a, nil
+}
+
+// Spec describes a grouped aggregation over a body
+type Spec struct {
+	// GroupBy lists the titles of columns to group rows by. With no group
+	// columns the whole body is aggregated into a single row
+	GroupBy []string
+	// Aggregates lists the functions to compute for each group
+	Aggregates []Aggregate
+	// MaxGroups caps the number of groups held in memory before partial
+	// results are spilled to disk. defaults to DefaultMaxGroups
+	MaxGroups int
+	// TempDir is the directory spilled results are written to, defaults to
+	// the system temp directory
+	TempDir string
+}
+
+// DefaultMaxGroups is the default number of groups held in memory
+const DefaultMaxGroups = 100000
+
+// ParseSpec creates a spec from a list of group columns and aggregate
+// strings like "count", "sum(amount)", "mean(amount) as avg"
+func ParseSpec(groupBy []string, aggregates ...string) (*Spec, error) {
+	s := &Spec{GroupBy: groupBy}
+	for _, str := range aggregates {
+		a, err := ParseAggregate(str)
+		if err != nil {
+			return nil, err
+		}
+		s.Aggregates = append(s.Aggregates, a)
+	}
+	return s, nil
+}
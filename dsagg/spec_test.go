@@ -0,0 +1,42 @@
+package dsagg
+
+import (
+	"testing"
+)
+
+func TestParseAggregate(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect Aggregate
+		title  string
+		err    string
+	}{
+		{"count", Aggregate{Func: FuncCount}, "count", ""},
+		{"count()", Aggregate{Func: FuncCount}, "count", ""},
+		{"COUNT(city)", Aggregate{Func: FuncCount, Column: "city"}, "count_city", ""},
+		{"sum(pop)", Aggregate{Func: FuncSum, Column: "pop"}, "sum_pop", ""},
+		{" mean( avg_age ) as age ", Aggregate{Func: FuncMean, Column: "avg_age", As: "age"}, "age", ""},
+		{"count_distinct(city)", Aggregate{Func: FuncCountDistinct, Column: "city"}, "count_distinct_city", ""},
+
+		{"median(pop)", Aggregate{}, "", "invalid aggregate function: 'median'"},
+		{"sum", Aggregate{}, "", "aggregate function sum requires a column"},
+		{"sum(pop", Aggregate{}, "", "invalid aggregate 'sum(pop': missing closing parenthesis"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseAggregate(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d result mismatch. expected: %#v, got: %#v", i, c.expect, got)
+		}
+		if got.Title() != c.title {
+			t.Errorf("case %d title mismatch. expected: %s, got: %s", i, c.title, got.Title())
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package dsagg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+)
+
+// state accumulates the partial result of one aggregate for one group.
+// states can be merged, which allows partial results to be spilled to disk
+// and combined later
+type state struct {
+	// n counts rows for count(), and non-null values otherwise
+	n        int64
+	isum     int64
+	fsum     float64
+	floats   bool
+	min      interface{}
+	max      interface{}
+	distinct map[string]bool
+	// typ is the type min & max compare values as
+	typ vals.Type
+}
+
+// newState creates an empty state for an aggregate over a column of type t
+func newState(a Aggregate, t vals.Type) *state {
+	s := &state{typ: compareType(t)}
+	if a.Func == FuncCountDistinct {
+		s.distinct = map[string]bool{}
+	}
+	return s
+}
+
+// add folds one value into the state. countRow counts a whole row for
+// count() aggregates, ignoring the value
+func (s *state) add(f Func, v interface{}, countRow bool) {
+	if countRow {
+		s.n++
+		return
+	}
+	if v == nil {
+		return
+	}
+
+	switch f {
+	case FuncCount:
+		s.n++
+	case FuncCountDistinct:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		s.n++
+		s.distinct[string(data)] = true
+	case FuncSum, FuncMean:
+		switch x := v.(type) {
+		case int:
+			s.addInt(int64(x))
+		case int64:
+			s.addInt(x)
+		case float64:
+			s.addFloat(x)
+		case string:
+			if vals.IsInteger([]byte(x)) {
+				if i, err := vals.ParseInteger([]byte(x)); err == nil {
+					s.addInt(i)
+				}
+			} else if num, err := vals.ParseNumber([]byte(x)); err == nil {
+				s.addFloat(num)
+			}
+		}
+	case FuncMin, FuncMax:
+		s.n++
+		if s.min == nil || dsio.CompareValues(v, s.min, s.typ) < 0 {
+			s.min = v
+		}
+		if s.max == nil || dsio.CompareValues(v, s.max, s.typ) > 0 {
+			s.max = v
+		}
+	}
+}
+
+func (s *state) addInt(i int64) {
+	s.n++
+	s.isum += i
+}
+
+func (s *state) addFloat(f float64) {
+	s.n++
+	s.floats = true
+	s.fsum += f
+}
+
+// merge combines another partial state into this one
+func (s *state) merge(o *state) {
+	s.n += o.n
+	s.isum += o.isum
+	s.fsum += o.fsum
+	s.floats = s.floats || o.floats
+	if o.min != nil && (s.min == nil || dsio.CompareValues(o.min, s.min, s.typ) < 0) {
+		s.min = o.min
+	}
+	if o.max != nil && (s.max == nil || dsio.CompareValues(o.max, s.max, s.typ) > 0) {
+		s.max = o.max
+	}
+	if o.distinct != nil {
+		if s.distinct == nil {
+			s.distinct = map[string]bool{}
+		}
+		for k := range o.distinct {
+			s.distinct[k] = true
+		}
+	}
+}
+
+// result gives the final value of an aggregate
+func (s *state) result(a Aggregate, t vals.Type) interface{} {
+	switch a.Func {
+	case FuncCount:
+		return s.n
+	case FuncCountDistinct:
+		return int64(len(s.distinct))
+	case FuncSum:
+		if s.n == 0 {
+			return nil
+		}
+		if t == vals.TypeInteger && !s.floats {
+			return s.isum
+		}
+		return s.fsum + float64(s.isum)
+	case FuncMean:
+		if s.n == 0 {
+			return nil
+		}
+		return (s.fsum + float64(s.isum)) / float64(s.n)
+	case FuncMin:
+		return s.min
+	case FuncMax:
+		return s.max
+	}
+	return nil
+}
+
+// encode converts a state to a value that can be written with a dsio writer
+func (s *state) encode() []interface{} {
+	var distinct []interface{}
+	if s.distinct != nil {
+		keys := make([]string, 0, len(s.distinct))
+		for k := range s.distinct {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		distinct = make([]interface{}, len(keys))
+		for i, k := range keys {
+			distinct[i] = k
+		}
+	}
+	return []interface{}{s.n, s.isum, s.fsum, s.floats, s.min, s.max, distinct}
+}
+
+// decodeState reverses state.encode
+func decodeState(v interface{}) (*state, error) {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) != 7 {
+		return nil, fmt.Errorf("invalid aggregate state")
+	}
+	s := &state{min: arr[4], max: arr[5]}
+	s.n, _ = arr[0].(int64)
+	s.isum, _ = arr[1].(int64)
+	s.fsum, _ = arr[2].(float64)
+	s.floats, _ = arr[3].(bool)
+	if keys, ok := arr[6].([]interface{}); ok {
+		s.distinct = map[string]bool{}
+		for _, k := range keys {
+			if str, ok := k.(string); ok {
+				s.distinct[str] = true
+			}
+		}
+	}
+	return s, nil
+}
+
+// compareType picks the type min & max compare column values of type t as.
+// columns of other types compare numerically where values are numbers, and
+// by their byte representation otherwise
+func compareType(t vals.Type) vals.Type {
+	switch t {
+	case vals.TypeString, vals.TypeInteger, vals.TypeNumber:
+		return t
+	}
+	return vals.TypeNumber
+}
@@ -0,0 +1,424 @@
+// Package dsarchive reads & writes datasets as self-contained archives.
+// A zip archive holds a dataset document and every file it references, using
+// this layout:
+//
+//	dataset.json     the dataset document, component paths are preserved
+//	ref.txt          optional human-readable dataset reference
+//	body.[format]    body file, named for the structure format. eg: body.csv
+//	transform.star   transform script
+//	viz.html         viz template script
+//	index.html       rendered viz
+//	readme.md        readme script
+//	readme.html      rendered readme
+//
+// All files other than dataset.json are optional
+package dsarchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	logger "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+var log = logger.Logger("dsarchive")
+
+const (
+	// DatasetFilename is the archive filename of the dataset document
+	DatasetFilename = "dataset.json"
+	// RefFilename is the archive filename of the dataset reference
+	RefFilename = "ref.txt"
+	// BodyFilenamePrefix starts the archive filename of the body file, which is
+	// suffixed with the body format
+	BodyFilenamePrefix = "body."
+	// TransformScriptFilename is the archive filename of the transform script
+	TransformScriptFilename = "transform.star"
+	// VizScriptFilename is the archive filename of the viz template
+	VizScriptFilename = "viz.html"
+	// VizRenderedFilename is the archive filename of the rendered viz
+	VizRenderedFilename = "index.html"
+	// ReadmeScriptFilename is the archive filename of the readme script
+	ReadmeScriptFilename = "readme.md"
+	// ReadmeRenderedFilename is the archive filename of the rendered readme
+	ReadmeRenderedFilename = "readme.html"
+)
+
+// ZipCfg configures writing zip archives
+type ZipCfg struct {
+	// Ref is a human-readable reference to the dataset, written to ref.txt
+	Ref string
+	// Resolver opens files that aren't already open on the dataset
+	Resolver qfs.PathResolver
+}
+
+// WriteZip writes a dataset and it's files to w as a zip archive. The body
+// file is streamed into the archive and consumed, WriteZip closes it and
+// unsets it on the dataset. Other files open on the dataset are read and
+// replaced with in-memory copies. Files that aren't open are opened with the
+// configured resolver, if one is set. Archives are deterministic: writing the
+// same dataset twice gives the same bytes
+func WriteZip(ctx context.Context, w io.Writer, ds *dataset.Dataset, configs ...func(cfg *ZipCfg)) error {
+	if ds == nil {
+		return fmt.Errorf("dataset is required")
+	}
+	cfg := &ZipCfg{}
+	for _, opt := range configs {
+		opt(cfg)
+	}
+
+	if cfg.Resolver != nil {
+		if err := openFiles(ctx, ds, cfg.Resolver); err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	docData, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding dataset: %s", err.Error())
+	}
+	if err := writeZipFile(zw, DatasetFilename, docData); err != nil {
+		return err
+	}
+	if cfg.Ref != "" {
+		if err := writeZipFile(zw, RefFilename, []byte(cfg.Ref)); err != nil {
+			return err
+		}
+	}
+
+	if f := ds.BodyFile(); f != nil {
+		name := bodyFilename(ds)
+		ds.SetBodyFile(nil)
+		defer f.Close()
+		w, err := createZipFile(zw, name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, f); err != nil {
+			return fmt.Errorf("writing %s: %s", name, err.Error())
+		}
+	}
+
+	if ds.Transform != nil && ds.Transform.ScriptFile() != nil {
+		data, err := readFile(ds.Transform.ScriptFile())
+		if err != nil {
+			return fmt.Errorf("reading transform script: %s", err.Error())
+		}
+		if err := writeZipFile(zw, TransformScriptFilename, data); err != nil {
+			return err
+		}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes(TransformScriptFilename, data))
+	}
+
+	if ds.Viz != nil {
+		if f := ds.Viz.ScriptFile(); f != nil {
+			data, err := readFile(f)
+			if err != nil {
+				return fmt.Errorf("reading viz script: %s", err.Error())
+			}
+			if err := writeZipFile(zw, VizScriptFilename, data); err != nil {
+				return err
+			}
+			ds.Viz.SetScriptFile(qfs.NewMemfileBytes(VizScriptFilename, data))
+		}
+		if f := ds.Viz.RenderedFile(); f != nil {
+			data, err := readFile(f)
+			if err != nil {
+				return fmt.Errorf("reading rendered viz: %s", err.Error())
+			}
+			if err := writeZipFile(zw, VizRenderedFilename, data); err != nil {
+				return err
+			}
+			ds.Viz.SetRenderedFile(qfs.NewMemfileBytes(VizRenderedFilename, data))
+		}
+	}
+
+	if ds.Readme != nil {
+		if f := ds.Readme.ScriptFile(); f != nil {
+			data, err := readFile(f)
+			if err != nil {
+				return fmt.Errorf("reading readme script: %s", err.Error())
+			}
+			if err := writeZipFile(zw, ReadmeScriptFilename, data); err != nil {
+				return err
+			}
+			ds.Readme.SetScriptFile(qfs.NewMemfileBytes(ReadmeScriptFilename, data))
+		}
+		if f := ds.Readme.RenderedFile(); f != nil {
+			data, err := readFile(f)
+			if err != nil {
+				return fmt.Errorf("reading rendered readme: %s", err.Error())
+			}
+			if err := writeZipFile(zw, ReadmeRenderedFilename, data); err != nil {
+				return err
+			}
+			ds.Readme.SetRenderedFile(qfs.NewMemfileBytes(ReadmeRenderedFilename, data))
+		}
+	}
+
+	return zw.Close()
+}
+
+// openFiles opens any dataset files that aren't already open
+func openFiles(ctx context.Context, ds *dataset.Dataset, resolver qfs.PathResolver) error {
+	if ds.BodyFile() == nil {
+		if err := ds.OpenBodyFile(ctx, resolver); err != nil && err != dataset.ErrInlineBody {
+			return err
+		}
+	}
+	if ds.Transform != nil && ds.Transform.ScriptFile() == nil {
+		if err := ds.Transform.OpenScriptFile(ctx, resolver); err != nil {
+			return fmt.Errorf("opening transform script: %s", err.Error())
+		}
+	}
+	if ds.Viz != nil {
+		if ds.Viz.ScriptFile() == nil {
+			if err := ds.Viz.OpenScriptFile(ctx, resolver); err != nil {
+				return fmt.Errorf("opening viz script: %s", err.Error())
+			}
+		}
+		if ds.Viz.RenderedFile() == nil {
+			if err := ds.Viz.OpenRenderedFile(ctx, resolver); err != nil {
+				return fmt.Errorf("opening rendered viz: %s", err.Error())
+			}
+		}
+	}
+	if ds.Readme != nil {
+		if ds.Readme.ScriptFile() == nil {
+			if err := ds.Readme.OpenScriptFile(ctx, resolver); err != nil {
+				return fmt.Errorf("opening readme script: %s", err.Error())
+			}
+		}
+		if ds.Readme.RenderedFile() == nil {
+			if err := ds.Readme.OpenRenderedFile(ctx, resolver); err != nil {
+				return fmt.Errorf("opening rendered readme: %s", err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// bodyFilename names the body file using the structure format, falling back
+// to the extension of the open body file
+func bodyFilename(ds *dataset.Dataset) string {
+	if ds.Structure != nil && ds.Structure.Format != "" {
+		return BodyFilenamePrefix + ds.Structure.Format
+	}
+	if ext := filepath.Ext(ds.BodyFile().FileName()); ext != "" {
+		return BodyFilenamePrefix + strings.TrimPrefix(ext, ".")
+	}
+	return strings.TrimSuffix(BodyFilenamePrefix, ".")
+}
+
+func readFile(f qfs.File) ([]byte, error) {
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+func createZipFile(zw *zip.Writer, name string) (io.Writer, error) {
+	// leave the modification time unset to keep archives deterministic
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %s", name, err.Error())
+	}
+	return w, nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := createZipFile(zw, name)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %s", name, err.Error())
+	}
+	return nil
+}
+
+const (
+	// DefaultMaxZipFileBytes is the default limit on the uncompressed size of
+	// a single file read from a zip archive
+	DefaultMaxZipFileBytes = 256 << 20
+	// DefaultMaxZipTotalBytes is the default limit on the uncompressed size of
+	// all files read from a zip archive
+	DefaultMaxZipTotalBytes = 1 << 30
+)
+
+// ReadZipCfg configures reading zip archives. Archive files are held in
+// memory, limits guard against archives that decompress to more data than
+// expected
+type ReadZipCfg struct {
+	// MaxFileBytes limits the uncompressed size of each file read from the
+	// archive. zero or less means no limit
+	MaxFileBytes int64
+	// MaxTotalBytes limits the combined uncompressed size of all files read
+	// from the archive. zero or less means no limit
+	MaxTotalBytes int64
+}
+
+// DefaultReadZipCfg sets the default limits for reading zip archives
+func DefaultReadZipCfg() *ReadZipCfg {
+	return &ReadZipCfg{
+		MaxFileBytes:  DefaultMaxZipFileBytes,
+		MaxTotalBytes: DefaultMaxZipTotalBytes,
+	}
+}
+
+// ReadZip loads a dataset from a zip archive, returning the dataset and the
+// reference stored in the archive, if any. Archive files are set on the
+// returned dataset as in-memory files, creating components as needed. Files
+// that aren't part of the archive layout are skipped
+func ReadZip(r io.ReaderAt, size int64, configs ...func(cfg *ReadZipCfg)) (ds *dataset.Dataset, ref string, err error) {
+	cfg := DefaultReadZipCfg()
+	for _, opt := range configs {
+		opt(cfg)
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading zip: %s", err.Error())
+	}
+
+	files := map[string][]byte{}
+	bodyName := ""
+	lim := &zipLimits{cfg: cfg}
+	for _, f := range zr.File {
+		isBody := strings.HasPrefix(f.Name, BodyFilenamePrefix) || f.Name == strings.TrimSuffix(BodyFilenamePrefix, ".")
+		if !isBody && !archiveFilenames[f.Name] {
+			continue
+		}
+		if isBody {
+			if bodyName != "" {
+				return nil, "", fmt.Errorf("archive has more than one body file: %s, %s", bodyName, f.Name)
+			}
+			bodyName = f.Name
+		}
+		data, err := lim.read(f)
+		if err != nil {
+			log.Debug(err.Error())
+			return nil, "", err
+		}
+		files[f.Name] = data
+	}
+
+	docData, ok := files[DatasetFilename]
+	if !ok {
+		return nil, "", fmt.Errorf("archive is missing %s", DatasetFilename)
+	}
+	ds = &dataset.Dataset{}
+	if err := json.Unmarshal(docData, ds); err != nil {
+		return nil, "", fmt.Errorf("decoding %s: %s", DatasetFilename, err.Error())
+	}
+
+	if data, ok := files[RefFilename]; ok {
+		ref = strings.TrimSpace(string(data))
+	}
+	if bodyName != "" {
+		ds.SetBodyFile(qfs.NewMemfileBytes(bodyName, files[bodyName]))
+	}
+	if data, ok := files[TransformScriptFilename]; ok {
+		if ds.Transform == nil {
+			ds.Transform = &dataset.Transform{}
+		}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes(TransformScriptFilename, data))
+	}
+	if data, ok := files[VizScriptFilename]; ok {
+		if ds.Viz == nil {
+			ds.Viz = &dataset.Viz{}
+		}
+		ds.Viz.SetScriptFile(qfs.NewMemfileBytes(VizScriptFilename, data))
+	}
+	if data, ok := files[VizRenderedFilename]; ok {
+		if ds.Viz == nil {
+			ds.Viz = &dataset.Viz{}
+		}
+		ds.Viz.SetRenderedFile(qfs.NewMemfileBytes(VizRenderedFilename, data))
+	}
+	if data, ok := files[ReadmeScriptFilename]; ok {
+		if ds.Readme == nil {
+			ds.Readme = &dataset.Readme{}
+		}
+		ds.Readme.SetScriptFile(qfs.NewMemfileBytes(ReadmeScriptFilename, data))
+	}
+	if data, ok := files[ReadmeRenderedFilename]; ok {
+		if ds.Readme == nil {
+			ds.Readme = &dataset.Readme{}
+		}
+		ds.Readme.SetRenderedFile(qfs.NewMemfileBytes(ReadmeRenderedFilename, data))
+	}
+
+	return ds, ref, nil
+}
+
+// ReadZipBytes loads a dataset from zip archive bytes
+func ReadZipBytes(data []byte, configs ...func(cfg *ReadZipCfg)) (*dataset.Dataset, string, error) {
+	return ReadZip(bytes.NewReader(data), int64(len(data)), configs...)
+}
+
+// archiveFilenames are the names of files ReadZip reads, other than the body
+var archiveFilenames = map[string]bool{
+	DatasetFilename:         true,
+	RefFilename:             true,
+	TransformScriptFilename: true,
+	VizScriptFilename:       true,
+	VizRenderedFilename:     true,
+	ReadmeScriptFilename:    true,
+	ReadmeRenderedFilename:  true,
+}
+
+// zipLimits reads archive files, enforcing size limits on the data actually
+// decompressed. sizes declared in zip headers aren't trusted
+type zipLimits struct {
+	cfg *ReadZipCfg
+	// n counts bytes read so far
+	n int64
+}
+
+func (l *zipLimits) read(f *zip.File) ([]byte, error) {
+	max, total := l.cfg.MaxFileBytes, l.cfg.MaxTotalBytes
+	if max > 0 && f.UncompressedSize64 > uint64(max) {
+		return nil, fmt.Errorf("%s is larger than the %d byte file size limit", f.Name, max)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %s", f.Name, err.Error())
+	}
+	defer rc.Close()
+
+	// read one byte past the lowest limit to detect exceeding it
+	var r io.Reader = rc
+	limit := int64(-1)
+	if max > 0 {
+		limit = max
+	}
+	if total > 0 && (limit < 0 || total-l.n < limit) {
+		limit = total - l.n
+	}
+	if limit >= 0 {
+		r = io.LimitReader(rc, limit+1)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", f.Name, err.Error())
+	}
+	if max > 0 && int64(len(data)) > max {
+		return nil, fmt.Errorf("%s is larger than the %d byte file size limit", f.Name, max)
+	}
+	l.n += int64(len(data))
+	if total > 0 && l.n > total {
+		return nil, fmt.Errorf("archive is larger than the %d byte size limit", total)
+	}
+	return data, nil
+}
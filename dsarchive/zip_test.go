@@ -0,0 +1,301 @@
+package dsarchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+// mapResolver resolves paths to in-memory files
+type mapResolver map[string]string
+
+func (r mapResolver) Get(ctx context.Context, path string) (qfs.File, error) {
+	data, ok := r[path]
+	if !ok {
+		return nil, fmt.Errorf("not found: %s", path)
+	}
+	return qfs.NewMemfileBytes(path, []byte(data)), nil
+}
+
+func fileString(t *testing.T, f qfs.File) string {
+	if f == nil {
+		t.Fatal("expected file, got nil")
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReadZipLegacy(t *testing.T) {
+	data, err := ioutil.ReadFile("../testdata/zip/exported.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, ref, err := ReadZipBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref != "peer/ref@a/ipfs/b" {
+		t.Errorf("ref mismatch. got: %s", ref)
+	}
+	if ds.Path != "/map/QmeaDMGiGpKSGTHPe1yGY9KduUumXqGe7qZpZEPXt16eNS" {
+		t.Errorf("path mismatch. got: %s", ds.Path)
+	}
+	if ds.BodyFile().FileName() != "body.csv" {
+		t.Errorf("body filename mismatch. got: %s", ds.BodyFile().FileName())
+	}
+	expect := map[string]string{
+		"body":       "movie\nup\nthe incredibles",
+		"transform":  "def transform(ds):\nreturn ds\n",
+		"viz script": "<html>template</html>\n",
+		"viz render": "<html>rendered</html<\n",
+	}
+	got := map[string]string{
+		"body":       fileString(t, ds.BodyFile()),
+		"transform":  fileString(t, ds.Transform.ScriptFile()),
+		"viz script": fileString(t, ds.Viz.ScriptFile()),
+		"viz render": fileString(t, ds.Viz.RenderedFile()),
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("file mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func testDataset() (*dataset.Dataset, mapResolver) {
+	ds := &dataset.Dataset{
+		Path:     "/map/QmDataset",
+		BodyPath: "/map/QmBody",
+		Qri:      dataset.KindDataset.String(),
+		Commit:   &dataset.Commit{Qri: dataset.KindCommit.String(), Title: "initial commit", Timestamp: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Meta:     &dataset.Meta{Qri: dataset.KindMeta.String(), Title: "archive test"},
+		Structure: &dataset.Structure{
+			Qri:    dataset.KindStructure.String(),
+			Format: "csv",
+			Schema: map[string]interface{}{"type": "array"},
+		},
+		Transform: &dataset.Transform{Qri: dataset.KindTransform.String(), Syntax: "starlark", ScriptPath: "/map/QmTransform"},
+		Viz:       &dataset.Viz{Qri: dataset.KindViz.String(), Format: "html", ScriptPath: "/map/QmVizScript", RenderedPath: "/map/QmVizRendered"},
+		Readme:    &dataset.Readme{Qri: dataset.KindReadme.String(), Format: "md", ScriptPath: "/map/QmReadme"},
+	}
+	resolver := mapResolver{
+		"/map/QmBody":        "a,b\n1,2\n",
+		"/map/QmTransform":   "def transform(ds, ctx):\n  pass\n",
+		"/map/QmVizScript":   "<html>{{ ds.meta.title }}</html>",
+		"/map/QmVizRendered": "<html>archive test</html>",
+		"/map/QmReadme":      "# archive test",
+	}
+	return ds, resolver
+}
+
+func TestZipRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ds, resolver := testDataset()
+	hashes, err := dataset.CanonicalHashes(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	err = WriteZip(ctx, buf, ds, func(cfg *ZipCfg) {
+		cfg.Ref = "peer/archive@QmProfile/map/QmDataset"
+		cfg.Resolver = resolver
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the body is consumed by writing, other files opened for writing must
+	// remain readable
+	if ds.BodyFile() != nil {
+		t.Errorf("expected body file to be unset after writing")
+	}
+	if got := fileString(t, ds.Viz.ScriptFile()); got != resolver["/map/QmVizScript"] {
+		t.Errorf("expected viz script to be readable after writing. got: %q", got)
+	}
+
+	got, ref, err := ReadZipBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != "peer/archive@QmProfile/map/QmDataset" {
+		t.Errorf("ref mismatch. got: %s", ref)
+	}
+	if err := dataset.CompareDatasets(ds, got); err != nil {
+		t.Errorf("dataset mismatch: %s", err)
+	}
+	gotHashes, err := dataset.CanonicalHashes(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(hashes, gotHashes); diff != "" {
+		t.Errorf("hash mismatch (-want +got):\n%s", diff)
+	}
+
+	files := map[string]string{
+		"/map/QmBody":        fileString(t, got.BodyFile()),
+		"/map/QmTransform":   fileString(t, got.Transform.ScriptFile()),
+		"/map/QmVizScript":   fileString(t, got.Viz.ScriptFile()),
+		"/map/QmVizRendered": fileString(t, got.Viz.RenderedFile()),
+		"/map/QmReadme":      fileString(t, got.Readme.ScriptFile()),
+	}
+	if diff := cmp.Diff(map[string]string(resolver), files); diff != "" {
+		t.Errorf("file mismatch (-want +got):\n%s", diff)
+	}
+	if got.Readme.RenderedFile() != nil {
+		t.Errorf("expected no rendered readme")
+	}
+}
+
+func TestWriteZipDeterministic(t *testing.T) {
+	ctx := context.Background()
+	a, resolver := testDataset()
+	b, _ := testDataset()
+
+	bufA, bufB := &bytes.Buffer{}, &bytes.Buffer{}
+	withResolver := func(cfg *ZipCfg) { cfg.Resolver = resolver }
+	if err := WriteZip(ctx, bufA, a, withResolver); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteZip(ctx, bufB, b, withResolver); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bufA.Bytes(), bufB.Bytes()) {
+		t.Errorf("expected writing the same dataset twice to produce identical archives")
+	}
+}
+
+func TestWriteZipErrors(t *testing.T) {
+	ctx := context.Background()
+	ds, _ := testDataset()
+
+	cases := []struct {
+		ds       *dataset.Dataset
+		resolver qfs.PathResolver
+		err      string
+	}{
+		{nil, nil, "dataset is required"},
+		{ds, mapResolver{}, "opening dataset.bodyPath '/map/QmBody': not found: /map/QmBody"},
+	}
+
+	for i, c := range cases {
+		err := WriteZip(ctx, &bytes.Buffer{}, c.ds, func(cfg *ZipCfg) { cfg.Resolver = c.resolver })
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestReadZipErrors(t *testing.T) {
+	noDoc := &bytes.Buffer{}
+	zw := zip.NewWriter(noDoc)
+	if err := writeZipFile(zw, "body.csv", []byte("a,b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	badDoc := &bytes.Buffer{}
+	zw = zip.NewWriter(badDoc)
+	if err := writeZipFile(zw, DatasetFilename, []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	twoBodies := &bytes.Buffer{}
+	zw = zip.NewWriter(twoBodies)
+	for _, name := range []string{DatasetFilename, "body.csv", "body.json"} {
+		if err := writeZipFile(zw, name, []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		data []byte
+		err  string
+	}{
+		{[]byte("not a zip"), "reading zip: zip: not a valid zip file"},
+		{twoBodies.Bytes(), "archive has more than one body file: body.csv, body.json"},
+		{noDoc.Bytes(), "archive is missing dataset.json"},
+		{badDoc.Bytes(), "decoding dataset.json: unexpected end of JSON input"},
+	}
+	for i, c := range cases {
+		_, _, err := ReadZipBytes(c.data)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestReadZipLimits(t *testing.T) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{DatasetFilename, []byte(`{"qri":"ds:0"}`)},
+		{"body.csv", bytes.Repeat([]byte("a,b\n"), 100)},
+		{"unused.bin", bytes.Repeat([]byte("x"), 1000)},
+	}
+	for _, f := range files {
+		if err := writeZipFile(zw, f.name, f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		cfg func(cfg *ReadZipCfg)
+		err string
+	}{
+		{func(cfg *ReadZipCfg) {}, ""},
+		// files that aren't part of the archive layout don't count
+		{func(cfg *ReadZipCfg) { cfg.MaxFileBytes = 400; cfg.MaxTotalBytes = 414 }, ""},
+		{func(cfg *ReadZipCfg) { cfg.MaxFileBytes = 0; cfg.MaxTotalBytes = 0 }, ""},
+		{func(cfg *ReadZipCfg) { cfg.MaxFileBytes = 399 }, "body.csv is larger than the 399 byte file size limit"},
+		{func(cfg *ReadZipCfg) { cfg.MaxTotalBytes = 413 }, "archive is larger than the 413 byte size limit"},
+	}
+	for i, c := range cases {
+		_, _, err := ReadZipBytes(buf.Bytes(), c.cfg)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestBodyFilenameFromFile(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte("[]")))
+	if err := WriteZip(ctx, buf, ds); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, err := ReadZipBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.BodyFile().FileName() != "body.json" {
+		t.Errorf("expected body filename from open file extension. got: %s", got.BodyFile().FileName())
+	}
+}
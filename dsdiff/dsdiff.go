@@ -211,6 +211,35 @@ func DiffViz(a, b *dataset.Viz) (*SubDiff, error) {
 	return DiffJSON(aBytes, bBytes, emptyDiff.kind)
 }
 
+// DiffStats diffs the dataset.Stats structs of two datasets
+func DiffStats(a, b *dataset.Stats) (*SubDiff, error) {
+	var emptyDiff = &SubDiff{kind: "stats"}
+
+	if a == nil {
+		a = &dataset.Stats{}
+	}
+	if b == nil {
+		b = &dataset.Stats{}
+	}
+
+	if len(a.Path) > 1 && len(b.Path) > 1 {
+		if a.Path == b.Path {
+			return emptyDiff, nil
+		}
+	} else if a.IsEmpty() && b.IsEmpty() {
+		return emptyDiff, nil
+	}
+	aBytes, err := a.MarshalJSONObject()
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling stats a: %s", err.Error())
+	}
+	bBytes, err := b.MarshalJSONObject()
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling stats b: %s", err.Error())
+	}
+	return DiffJSON(aBytes, bBytes, emptyDiff.kind)
+}
+
 //DiffJSON diffs two json byte slices and returns a SubDiff pointer
 func DiffJSON(a, b []byte, kind string) (*SubDiff, error) {
 	differ := jdiff.New()
@@ -235,7 +264,7 @@ type StructuredDataTuple struct {
 // of a dataset.  It calls each of the Diff{Component} functions and
 // adds the option for including de-referenced dataset.Data via
 // the StructuredDataTuple
-// TODO (kasey): This function only diffs: Structure, Meta, Transform, Viz, and Data (in JSON or as a Dataset)
+// TODO (kasey): This function only diffs: Structure, Meta, Transform, Viz, Stats, and Data (in JSON or as a Dataset)
 func DiffDatasets(a, b *dataset.Dataset, deRefData *StructuredDataTuple) (map[string]*SubDiff, error) {
 	result := make(map[string]*SubDiff)
 
@@ -295,6 +324,16 @@ func DiffDatasets(a, b *dataset.Dataset, deRefData *StructuredDataTuple) (map[st
 			result[vizDiffs.kind] = vizDiffs
 		}
 	}
+	// diff stats
+	if a.Stats != nil || b.Stats != nil {
+		statsDiffs, err := DiffStats(a.Stats, b.Stats)
+		if err != nil {
+			return nil, err
+		}
+		if statsDiffs.Diff != nil {
+			result[statsDiffs.kind] = statsDiffs
+		}
+	}
 	return result, nil
 }
 
@@ -306,6 +345,7 @@ func DiffDatasets(a, b *dataset.Dataset, deRefData *StructuredDataTuple) (map[st
 //   3. dataset.Transform
 //   4. dataset.Meta
 //   5. Dataset.Viz
+//   6. Dataset.Stats
 func MapDiffsToString(m map[string]*SubDiff, how string) (string, error) {
 	keys := []string{
 		"structure",
@@ -313,6 +353,7 @@ func MapDiffsToString(m map[string]*SubDiff, how string) (string, error) {
 		"transform",
 		"meta",
 		"viz",
+		"stats",
 	}
 	// for _, key := range keys {
 	// 	val, ok := m[key]
@@ -250,3 +250,41 @@ func TestDiffTransform(t *testing.T) {
 		t.Errorf("error: expected not to have been modified")
 	}
 }
+
+func TestDiffStats(t *testing.T) {
+	a := &dataset.Stats{Stats: []interface{}{map[string]interface{}{"title": "a", "count": 2}}}
+	b := &dataset.Stats{Stats: []interface{}{map[string]interface{}{"title": "a", "count": 3}}}
+
+	diff, err := DiffStats(a, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Diff.Modified() {
+		t.Errorf("error: expected not to have been modified")
+	}
+
+	diff, err = DiffStats(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Diff.Modified() {
+		t.Errorf("error: expected modification")
+	}
+
+	// nil stats compare as empty
+	diff, err = DiffStats(nil, &dataset.Stats{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Diff != nil {
+		t.Errorf("error: expected empty diff")
+	}
+
+	diffs, err := DiffDatasets(&dataset.Dataset{Stats: a}, &dataset.Dataset{Stats: b}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := diffs["stats"]; !ok {
+		t.Errorf("expected dataset diff to include stats")
+	}
+}
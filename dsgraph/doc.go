@@ -1,6 +1,13 @@
 // Package dsgraph is a placeholder package for linking
 // queries, resources, and metadata until proper
 // packaging & architectural decisions can be made
+//
+// Lineage builds a graph of the datasets and transforms that feed
+// into a dataset, which can be exported with DOT or JSON.
+// ParallelWalk visits large graphs concurrently, visiting
+// shared descendants once.
+// Indexes connect resources, transforms and results across
+// many datasets, and persist to a go-datastore Datastore
 package dsgraph
 
 import (
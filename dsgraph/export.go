@@ -0,0 +1,102 @@
+package dsgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// graphNodes lists each node reachable from graph once, in depth-first order,
+// along with every link between them. Unlike Walk, nodes with more than one
+// parent are only visited once. Links with an end outside the listed nodes,
+// like links to a nil node, are dropped
+func graphNodes(graph *Node) (nodes []*Node, links []Link) {
+	seen := map[*Node]bool{}
+	var all []Link
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+		nodes = append(nodes, n)
+		for _, l := range n.Links {
+			all = append(all, l)
+			visit(l.To)
+		}
+	}
+	visit(graph)
+
+	for _, l := range all {
+		if seen[l.From] && seen[l.To] {
+			links = append(links, l)
+		}
+	}
+	return nodes, links
+}
+
+// DOT encodes a graph in the graphviz DOT language. Links point from each
+// dataset to its inputs
+func DOT(graph *Node) []byte {
+	nodes, links := graphNodes(graph)
+	ids := make(map[*Node]string, len(nodes))
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("digraph lineage {\n")
+	for i, n := range nodes {
+		ids[n] = fmt.Sprintf("n%d", i)
+		shape := "box"
+		if n.Type == NtTransform {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(buf, "  %s [label=%s, shape=%s];\n", ids[n], dotString(n.Path), shape)
+	}
+	for _, l := range links {
+		fmt.Fprintf(buf, "  %s -> %s [label=%s];\n", ids[l.From], ids[l.To], dotString(string(l.Type)))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// dotString quotes a string for use as a DOT ID
+func dotString(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// jsonGraph is the JSON encoding of a graph. Links reference nodes by index
+type jsonGraph struct {
+	Nodes []jsonNode `json:"nodes"`
+	Links []jsonLink `json:"links"`
+}
+
+type jsonNode struct {
+	Type NodeType `json:"type"`
+	Path string   `json:"path"`
+}
+
+type jsonLink struct {
+	Type LinkType `json:"type"`
+	From int      `json:"from"`
+	To   int      `json:"to"`
+}
+
+// JSON encodes a graph as a list of nodes and a list of links between them.
+// Links reference nodes by their position in the node list. The first node
+// is the root of the graph
+func JSON(graph *Node) ([]byte, error) {
+	nodes, links := graphNodes(graph)
+	idx := make(map[*Node]int, len(nodes))
+
+	g := jsonGraph{Nodes: []jsonNode{}, Links: []jsonLink{}}
+	for i, n := range nodes {
+		idx[n] = i
+		g.Nodes = append(g.Nodes, jsonNode{Type: n.Type, Path: n.Path})
+	}
+	for _, l := range links {
+		g.Links = append(g.Links, jsonLink{Type: l.Type, From: idx[l.From], To: idx[l.To]})
+	}
+	return json.Marshal(g)
+}
@@ -1,5 +1,9 @@
 package dsgraph
 
+import (
+	"context"
+)
+
 var walkParallelism = 4
 
 // NodeType specifies different types of qri nodes
@@ -54,36 +58,41 @@ ADDITIONS:
 	}
 }
 
-// TODO - still considering if links need to be typed or not
-// type LinkType string
+// LinkType specifies the relationship a link describes
+type LinkType string
 
-// var (
-// 	LtPrevious      = LinkType("previous")
-// 	LtResource      = LinkType("resource")
-// 	LtDsData        = LinkType("dataset_data")
-// 	LtDsCommit      = LinkType("dataset_commit")
-// 	LtAbstStructure = LinkType("abst_structure")
-// 	LtTransform         = LinkType("transform")
-// 	LtAbstTransform     = LinkType("abst_transform")
-// 	LtNamespaceTip  = LinkType("namespace_tip")
-// )
+var (
+	// LtPrevious links a dataset to the previous version of itself
+	LtPrevious = LinkType("previous")
+	// LtResource links a transform to a dataset it reads from
+	LtResource = LinkType("resource")
+	// LtTransform links a dataset to the transform that produced it
+	LtTransform = LinkType("transform")
+	// TODO - still considering which other links need types
+	// LtDsData        = LinkType("dataset_data")
+	// LtDsCommit      = LinkType("dataset_commit")
+	// LtAbstStructure = LinkType("abst_structure")
+	// LtAbstTransform     = LinkType("abst_transform")
+	// LtNamespaceTip  = LinkType("namespace_tip")
+)
 
 // Link is a typed, directional connection from one
 // node to another
 type Link struct {
-	// Type     LinkType
+	Type     LinkType
 	From, To *Node
 }
 
 // Equal checks for field-level equality with another Link
 func (a Link) Equal(b Link) bool {
-	return a.From.Equal(b.From) && a.To.Equal(b.To)
+	return a.Type == b.Type && a.From.Equal(b.From) && a.To.Equal(b.To)
 }
 
 // FilterNodeTypes returns a slice of node pointers from a graph that match
-// the provided NodeType's
+// the provided NodeType's. Each matching node is returned once, in depth-first
+// order
 func FilterNodeTypes(graph *Node, nodetypes ...NodeType) (nodes []*Node) {
-	Walk(graph, 0, func(n *Node) error {
+	ParallelWalk(context.Background(), graph, func(n *Node, depth int) error {
 		if n != nil {
 			for _, nt := range nodetypes {
 				if n.Type == nt {
@@ -93,11 +102,17 @@ func FilterNodeTypes(graph *Node, nodetypes ...NodeType) (nodes []*Node) {
 			}
 		}
 		return nil
+	}, func(cfg *WalkCfg) {
+		// visit one node at a time to keep results in order
+		cfg.Parallelism = 1
+		cfg.Order = DepthFirst
 	})
 	return
 }
 
-// Walk visits node and all descendants with a provided visit function
+// Walk visits node and all descendants with a provided visit function. Nodes
+// reachable through more than one path are visited once per path, and Walk
+// doesn't terminate on graphs with cycles. Use ParallelWalk for those graphs
 func Walk(node *Node, depth int, visit func(n *Node) error) error {
 	if err := visit(node); err != nil {
 		return err
@@ -0,0 +1,194 @@
+package dsgraph
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+)
+
+// BodyDatasets connects body checksums to datasets with that body
+type BodyDatasets map[datastore.Key][]datastore.Key
+
+// AddDataset adds a dataset with the body checksum key
+func (bd BodyDatasets) AddDataset(checksum, ds datastore.Key) {
+	for _, d := range bd[checksum] {
+		if d.Equal(ds) {
+			return
+		}
+	}
+	bd[checksum] = append(bd[checksum], ds)
+}
+
+// Datasets lists the datasets with the body checksum key
+func (bd BodyDatasets) Datasets(checksum datastore.Key) []datastore.Key {
+	return bd[checksum]
+}
+
+// MarshalJSON implements the json.Marshaler interface for BodyDatasets
+func (bd BodyDatasets) MarshalJSON() ([]byte, error) {
+	strmap := map[string]interface{}{}
+	for key, vals := range bd {
+		strs := make([]string, len(vals))
+		for i, v := range vals {
+			strs[i] = v.String()
+		}
+		strmap[key.String()] = strs
+	}
+	return json.Marshal(strmap)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for BodyDatasets
+func (bd *BodyDatasets) UnmarshalJSON(data []byte) error {
+	strmap := map[string][]datastore.Key{}
+	if err := json.Unmarshal(data, &strmap); err != nil {
+		return err
+	}
+
+	r := BodyDatasets{}
+	for key, vals := range strmap {
+		r[datastore.NewKey(key)] = vals
+	}
+	*bd = r
+	return nil
+}
+
+// datastore keys indexes are persisted under
+var (
+	resourceMetaKey       = datastore.NewKey("/dsgraph/resource_meta")
+	resourceTransformsKey = datastore.NewKey("/dsgraph/resource_transforms")
+	transformResultsKey   = datastore.NewKey("/dsgraph/transform_results")
+	bodyDatasetsKey       = datastore.NewKey("/dsgraph/body_datasets")
+)
+
+// Indexes groups the resource indexes built from a set of datasets. Resources
+// are dataset paths, transforms are keyed by transform path. Indexes are not
+// safe for concurrent use
+type Indexes struct {
+	// ResourceMeta connects datasets to their meta component
+	ResourceMeta ResourceMeta
+	// ResourceTransforms connects datasets to the transforms that read them
+	ResourceTransforms ResourceTransforms
+	// TransformResults connects transforms to the datasets they produced
+	TransformResults TransformResults
+	// BodyDatasets connects body checksums to datasets with that body
+	BodyDatasets BodyDatasets
+}
+
+// NewIndexes creates an empty set of indexes
+func NewIndexes() *Indexes {
+	return &Indexes{
+		ResourceMeta:       ResourceMeta{},
+		ResourceTransforms: ResourceTransforms{},
+		TransformResults:   TransformResults{},
+		BodyDatasets:       BodyDatasets{},
+	}
+}
+
+// AddDataset indexes a saved dataset's meta, body checksum, and transform
+// resources
+func (idx *Indexes) AddDataset(ds *dataset.Dataset) error {
+	if ds.Path == "" {
+		return fmt.Errorf("dataset has no path")
+	}
+	if ds.Transform != nil {
+		for name, res := range ds.Transform.Resources {
+			if res == nil || res.Path == "" {
+				return fmt.Errorf("transform resource '%s' has no path", name)
+			}
+		}
+	}
+	dsKey := datastore.NewKey(ds.Path)
+
+	if ds.Meta != nil && ds.Meta.Path != "" {
+		idx.ResourceMeta.SetMeta(dsKey, datastore.NewKey(ds.Meta.Path))
+	}
+	if ds.Structure != nil && ds.Structure.Checksum != "" {
+		idx.BodyDatasets.AddDataset(datastore.NewKey(ds.Structure.Checksum), dsKey)
+	}
+	if ds.Transform != nil {
+		tfKey := dsKey.ChildString("transform")
+		if ds.Transform.Path != "" {
+			tfKey = datastore.NewKey(ds.Transform.Path)
+		}
+		for _, res := range ds.Transform.Resources {
+			idx.ResourceTransforms.AddTransform(datastore.NewKey(res.Path), tfKey)
+		}
+		idx.TransformResults.AddResult(tfKey, dsKey)
+	}
+	return nil
+}
+
+// TransformsConsuming lists transforms that read the dataset at path
+func (idx *Indexes) TransformsConsuming(path string) []datastore.Key {
+	return idx.ResourceTransforms.Transforms(datastore.NewKey(path))
+}
+
+// DatasetsFromBody lists datasets produced from a body checksum: datasets
+// with that body come first, followed by datasets produced by transforms that
+// read them, and so on downstream
+func (idx *Indexes) DatasetsFromBody(checksum string) []datastore.Key {
+	seen := map[datastore.Key]bool{}
+	var datasets []datastore.Key
+	add := func(keys []datastore.Key) {
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				datasets = append(datasets, k)
+			}
+		}
+	}
+
+	add(idx.BodyDatasets.Datasets(datastore.NewKey(checksum)))
+	for i := 0; i < len(datasets); i++ {
+		for _, tf := range idx.ResourceTransforms.Transforms(datasets[i]) {
+			add(idx.TransformResults.Results(tf))
+		}
+	}
+	return datasets
+}
+
+// Save writes indexes to a datastore
+func (idx *Indexes) Save(store datastore.Datastore) error {
+	for key, index := range idx.stored() {
+		data, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+		if err := store.Put(key, data); err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("saving index %s: %s", key, err)
+		}
+	}
+	return nil
+}
+
+// LoadIndexes reads indexes from a datastore. Indexes missing from the store
+// are empty
+func LoadIndexes(store datastore.Datastore) (*Indexes, error) {
+	idx := NewIndexes()
+	for key, index := range idx.stored() {
+		data, err := store.Get(key)
+		if err == datastore.ErrNotFound {
+			continue
+		} else if err != nil {
+			log.Debug(err.Error())
+			return nil, fmt.Errorf("loading index %s: %s", key, err)
+		}
+		if err := json.Unmarshal(data, index); err != nil {
+			return nil, fmt.Errorf("decoding index %s: %s", key, err)
+		}
+	}
+	return idx, nil
+}
+
+// stored maps each index to the key it's persisted under
+func (idx *Indexes) stored() map[datastore.Key]interface{} {
+	return map[datastore.Key]interface{}{
+		resourceMetaKey:       &idx.ResourceMeta,
+		resourceTransformsKey: &idx.ResourceTransforms,
+		transformResultsKey:   &idx.TransformResults,
+		bodyDatasetsKey:       &idx.BodyDatasets,
+	}
+}
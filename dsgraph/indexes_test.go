@@ -0,0 +1,170 @@
+package dsgraph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+)
+
+func keys(strs ...string) []datastore.Key {
+	ks := make([]datastore.Key, len(strs))
+	for i, s := range strs {
+		ks[i] = datastore.NewKey(s)
+	}
+	return ks
+}
+
+// indexedDatasets are a raw dataset, two datasets derived from it, and one
+// derived from those
+func indexedDatasets() []*dataset.Dataset {
+	return []*dataset.Dataset{
+		{
+			Path:      "/map/raw",
+			Meta:      &dataset.Meta{Path: "/map/raw_meta"},
+			Structure: &dataset.Structure{Checksum: "QmRawBody"},
+		},
+		{
+			Path:      "/map/raw_copy",
+			Structure: &dataset.Structure{Checksum: "QmRawBody"},
+		},
+		{
+			Path: "/map/clean",
+			Transform: &dataset.Transform{
+				Path:      "/map/clean_tf",
+				Resources: map[string]*dataset.TransformResource{"raw": {Path: "/map/raw"}},
+			},
+		},
+		{
+			Path: "/map/report",
+			Transform: &dataset.Transform{
+				Resources: map[string]*dataset.TransformResource{
+					"clean": {Path: "/map/clean"},
+					"raw":   {Path: "/map/raw"},
+				},
+			},
+		},
+	}
+}
+
+func newTestIndexes(t *testing.T) *Indexes {
+	idx := NewIndexes()
+	for _, ds := range indexedDatasets() {
+		if err := idx.AddDataset(ds); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx
+}
+
+func TestIndexesQueries(t *testing.T) {
+	idx := newTestIndexes(t)
+
+	consuming := idx.TransformsConsuming("/map/raw")
+	if diff := cmp.Diff(keys("/map/clean_tf", "/map/report/transform"), consuming); diff != "" {
+		t.Errorf("transforms consuming mismatch (-want +got):\n%s", diff)
+	}
+	if got := idx.TransformsConsuming("/map/report"); len(got) != 0 {
+		t.Errorf("expected no transforms consuming report, got: %v", got)
+	}
+
+	produced := idx.DatasetsFromBody("QmRawBody")
+	if diff := cmp.Diff(keys("/map/raw", "/map/raw_copy", "/map/clean", "/map/report"), produced); diff != "" {
+		t.Errorf("datasets from body mismatch (-want +got):\n%s", diff)
+	}
+	if got := idx.DatasetsFromBody("QmUnknown"); len(got) != 0 {
+		t.Errorf("expected no datasets for unknown checksum, got: %v", got)
+	}
+
+	if meta, ok := idx.ResourceMeta.Meta(datastore.NewKey("/map/raw")); !ok || meta.String() != "/map/raw_meta" {
+		t.Errorf("expected raw meta key, got: '%s' %t", meta, ok)
+	}
+
+	if err := idx.AddDataset(&dataset.Dataset{}); err == nil || err.Error() != "dataset has no path" {
+		t.Errorf("expected missing path error, got: %v", err)
+	}
+	err := idx.AddDataset(&dataset.Dataset{Path: "/map/x", Transform: &dataset.Transform{
+		Resources: map[string]*dataset.TransformResource{"y": {}},
+	}})
+	if err == nil || err.Error() != "transform resource 'y' has no path" {
+		t.Errorf("expected missing resource path error, got: %v", err)
+	}
+	if got := idx.TransformResults.Results(datastore.NewKey("/map/x/transform")); len(got) != 0 {
+		t.Errorf("expected failed add to leave indexes unchanged, got: %v", got)
+	}
+}
+
+func TestIndexesJSON(t *testing.T) {
+	idx := newTestIndexes(t)
+
+	cases := []struct {
+		description string
+		index       interface{}
+		empty       interface{}
+	}{
+		{"resource meta", &idx.ResourceMeta, &ResourceMeta{}},
+		{"resource transforms", &idx.ResourceTransforms, &ResourceTransforms{}},
+		{"transform results", &idx.TransformResults, &TransformResults{}},
+		{"body datasets", &idx.BodyDatasets, &BodyDatasets{}},
+	}
+
+	for _, c := range cases {
+		data, err := json.Marshal(c.index)
+		if err != nil {
+			t.Errorf("case '%s' marshal error: %s", c.description, err)
+			continue
+		}
+		if err := json.Unmarshal(data, c.empty); err != nil {
+			t.Errorf("case '%s' unmarshal error: %s", c.description, err)
+			continue
+		}
+		if diff := cmp.Diff(c.index, c.empty); diff != "" {
+			t.Errorf("case '%s' round trip mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+
+	data, err := json.Marshal(idx.BodyDatasets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"/QmRawBody":["/map/raw","/map/raw_copy"]}`
+	if string(data) != expect {
+		t.Errorf("body datasets JSON mismatch. expected: %s, got: %s", expect, string(data))
+	}
+}
+
+func TestIndexesPersistence(t *testing.T) {
+	store := datastore.NewMapDatastore()
+
+	empty, err := LoadIndexes(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(NewIndexes(), empty); diff != "" {
+		t.Errorf("expected empty indexes from an empty store (-want +got):\n%s", diff)
+	}
+
+	idx := newTestIndexes(t)
+	if err := idx.Save(store); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadIndexes(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(idx, loaded); diff != "" {
+		t.Errorf("loaded indexes mismatch (-want +got):\n%s", diff)
+	}
+	if got := loaded.DatasetsFromBody("QmRawBody"); len(got) != 4 {
+		t.Errorf("expected 4 datasets from loaded indexes, got: %v", got)
+	}
+
+	if err := store.Put(transformResultsKey, []byte(`[`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadIndexes(store); err == nil {
+		t.Error("expected corrupt index to error")
+	}
+}
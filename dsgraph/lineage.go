@@ -0,0 +1,270 @@
+package dsgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+// PackageFile is the name of the dataset document within a dataset directory
+const PackageFile = "dataset.json"
+
+// LineageCfg configures how a lineage graph is built
+type LineageCfg struct {
+	// FollowPrevious includes previous versions of each dataset in the graph
+	FollowPrevious bool
+	// MaxDepth limits the number of links followed from the root dataset.
+	// zero or less means no limit
+	MaxDepth int
+}
+
+// DefaultLineageCfg follows all resource and previous version links
+func DefaultLineageCfg() *LineageCfg {
+	return &LineageCfg{FollowPrevious: true}
+}
+
+// Lineage builds a graph of the datasets that feed into ds. The root node is
+// ds, linked to its transform, which links to each of the datasets named in
+// Transform.Resources. Previous versions are linked through PreviousPath.
+// Resource and previous datasets are loaded with resolver and followed
+// recursively, datasets reached by more than one path share a single node.
+// Lineage errors if the graph contains a cycle
+func Lineage(ctx context.Context, ds *dataset.Dataset, resolver qfs.PathResolver, configs ...func(cfg *LineageCfg)) (*Node, error) {
+	cfg := DefaultLineageCfg()
+	for _, opt := range configs {
+		opt(cfg)
+	}
+
+	b := &lineageBuilder{
+		ctx:      ctx,
+		resolver: resolver,
+		cfg:      cfg,
+		nodes:    map[string]*Node{},
+		depths:   map[string]int{},
+		visiting: map[string]bool{},
+	}
+	root, err := b.dataset(ds, ds.Path, 0)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+	return root, nil
+}
+
+type lineageBuilder struct {
+	ctx      context.Context
+	resolver qfs.PathResolver
+	cfg      *LineageCfg
+	// nodes that have been fully built, keyed by type and path
+	nodes map[string]*Node
+	// depth each node was built at
+	depths map[string]int
+	// nodes currently being built, for cycle detection
+	visiting map[string]bool
+	stack    []string
+}
+
+func nodeKey(t NodeType, path string) string {
+	return fmt.Sprintf("%s:%s", t, path)
+}
+
+// enter marks a node as being built, erroring if it's already on the stack
+func (b *lineageBuilder) enter(key, path string) error {
+	if b.visiting[key] {
+		return b.cycle(path)
+	}
+	b.visiting[key] = true
+	b.stack = append(b.stack, path)
+	return nil
+}
+
+// cycle describes the path from the first visit of path back to itself
+func (b *lineageBuilder) cycle(path string) error {
+	cycle := []string{path}
+	for i := len(b.stack) - 1; i >= 0; i-- {
+		cycle = append([]string{b.stack[i]}, cycle...)
+		if b.stack[i] == path {
+			break
+		}
+	}
+	return fmt.Errorf("cycle detected: %s", strings.Join(cycle, " -> "))
+}
+
+func (b *lineageBuilder) exit(key string) {
+	delete(b.visiting, key)
+	b.stack = b.stack[:len(b.stack)-1]
+}
+
+// built returns the node for key if one has been built. done is false if the
+// node must be rebuilt: nodes first reached deeper than depth may be missing
+// links cut off by MaxDepth. Rebuilt nodes keep their pointer, so links to them
+// stay valid
+func (b *lineageBuilder) built(key string, depth int) (n *Node, done bool) {
+	n, ok := b.nodes[key]
+	if !ok {
+		return nil, false
+	}
+	return n, b.cfg.MaxDepth <= 0 || b.depths[key] <= depth
+}
+
+// finish records a built node
+func (b *lineageBuilder) finish(key string, n *Node, depth int) {
+	b.nodes[key] = n
+	b.depths[key] = depth
+}
+
+func (b *lineageBuilder) dataset(ds *dataset.Dataset, path string, depth int) (*Node, error) {
+	key := nodeKey(NtDataset, path)
+	if b.visiting[key] {
+		return nil, b.cycle(path)
+	}
+	n, done := b.built(key, depth)
+	if done {
+		return n, nil
+	}
+	if err := b.enter(key, path); err != nil {
+		return nil, err
+	}
+	defer b.exit(key)
+
+	if n == nil {
+		n = &Node{Type: NtDataset, Path: path}
+	}
+	n.Links = nil
+	if b.cfg.MaxDepth > 0 && depth >= b.cfg.MaxDepth {
+		b.finish(key, n, depth)
+		return n, nil
+	}
+
+	if ds.Transform != nil {
+		tfPath := ds.Transform.Path
+		if tfPath == "" {
+			tfPath = strings.TrimSuffix(path, "/"+PackageFile) + "/transform"
+		}
+		tf, err := b.transform(ds.Transform, tfPath, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n.AddLinks(Link{Type: LtTransform, From: n, To: tf})
+	}
+
+	if b.cfg.FollowPrevious && ds.PreviousPath != "" {
+		prev, err := b.resolve(ds.PreviousPath, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n.AddLinks(Link{Type: LtPrevious, From: n, To: prev})
+	}
+
+	b.finish(key, n, depth)
+	return n, nil
+}
+
+func (b *lineageBuilder) transform(tf *dataset.Transform, path string, depth int) (*Node, error) {
+	key := nodeKey(NtTransform, path)
+	if b.visiting[key] {
+		return nil, b.cycle(path)
+	}
+	n, done := b.built(key, depth)
+	if done {
+		return n, nil
+	}
+	if err := b.enter(key, path); err != nil {
+		return nil, err
+	}
+	defer b.exit(key)
+
+	if n == nil {
+		n = &Node{Type: NtTransform, Path: path}
+	}
+	n.Links = nil
+	if b.cfg.MaxDepth > 0 && depth >= b.cfg.MaxDepth {
+		b.finish(key, n, depth)
+		return n, nil
+	}
+
+	if isTransformRef(tf) {
+		loaded := &dataset.Transform{}
+		if err := b.load(tf.Path, loaded); err != nil {
+			return nil, fmt.Errorf("loading transform '%s': %s", tf.Path, err)
+		}
+		tf = loaded
+	}
+
+	// visit resources in name order so graphs are built deterministically
+	names := make([]string, 0, len(tf.Resources))
+	for name := range tf.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		res := tf.Resources[name]
+		if res == nil || res.Path == "" {
+			return nil, fmt.Errorf("transform '%s' resource '%s' has no path", path, name)
+		}
+		dep, err := b.resolve(res.Path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n.AddLinks(Link{Type: LtResource, From: n, To: dep})
+	}
+
+	b.finish(key, n, depth)
+	return n, nil
+}
+
+// resolve loads the dataset at path and adds it to the graph
+func (b *lineageBuilder) resolve(path string, depth int) (*Node, error) {
+	key := nodeKey(NtDataset, path)
+	if b.visiting[key] {
+		return nil, b.cycle(path)
+	}
+	if n, done := b.built(key, depth); done {
+		return n, nil
+	}
+	if b.cfg.MaxDepth > 0 && depth >= b.cfg.MaxDepth {
+		return b.dataset(&dataset.Dataset{}, path, depth)
+	}
+
+	ds := &dataset.Dataset{}
+	if err := b.load(path, ds); err != nil {
+		return nil, fmt.Errorf("loading dataset '%s': %s", path, err)
+	}
+	return b.dataset(ds, path, depth)
+}
+
+// load reads and decodes a JSON document from the resolver. Directory paths
+// load the dataset document within the directory
+func (b *lineageBuilder) load(path string, v interface{}) error {
+	if b.resolver == nil {
+		return fmt.Errorf("no resolver")
+	}
+	f, err := b.resolver.Get(b.ctx, path)
+	if err != nil {
+		return err
+	}
+	if f.IsDirectory() {
+		f.Close()
+		if f, err = b.resolver.Get(b.ctx, strings.TrimSuffix(path, "/")+"/"+PackageFile); err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// isTransformRef checks if a transform is a reference to a transform path
+func isTransformRef(tf *dataset.Transform) bool {
+	return tf.Path != "" && tf.ScriptFile() == nil && tf.IsEmpty()
+}
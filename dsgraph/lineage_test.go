@@ -0,0 +1,222 @@
+package dsgraph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+// mapResolver resolves paths to in-memory files
+type mapResolver map[string]string
+
+func (r mapResolver) Get(ctx context.Context, path string) (qfs.File, error) {
+	data, ok := r[path]
+	if !ok {
+		return nil, fmt.Errorf("not found: %s", path)
+	}
+	return qfs.NewMemfileBytes(path, []byte(data)), nil
+}
+
+// lineageResolver holds a small lineage: gdp_per_capita is computed from gdp
+// and population. gdp has a previous version, and gdp_per_capita's previous
+// version also read from population
+var lineageResolver = mapResolver{
+	"/map/gdp":     `{"transform":{"path":"/map/gdp_tf"},"previousPath":"/map/gdp_v1"}`,
+	"/map/gdp_tf":  `{"syntax":"starlark","resources":{"raw":"/map/raw"}}`,
+	"/map/raw":     `{"meta":{"title":"raw"}}`,
+	"/map/gdp_v1":  `{"meta":{"title":"gdp"}}`,
+	"/map/pop":     `{"meta":{"title":"population"}}`,
+	"/map/prev_pc": `{"transform":{"syntax":"starlark","resources":{"pop":{"path":"/map/pop"}}}}`,
+}
+
+func perCapita() *dataset.Dataset {
+	return &dataset.Dataset{
+		Path:         "/map/per_capita",
+		PreviousPath: "/map/prev_pc",
+		Transform: &dataset.Transform{
+			Path:   "/map/per_capita_tf",
+			Syntax: "starlark",
+			Resources: map[string]*dataset.TransformResource{
+				"gdp": {Path: "/map/gdp"},
+				"pop": {Path: "/map/pop"},
+			},
+		},
+	}
+}
+
+func TestLineage(t *testing.T) {
+	ctx := context.Background()
+	graph, err := Lineage(ctx, perCapita(), lineageResolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `digraph lineage {
+  n0 [label="/map/per_capita", shape=box];
+  n1 [label="/map/per_capita_tf", shape=ellipse];
+  n2 [label="/map/gdp", shape=box];
+  n3 [label="/map/gdp_tf", shape=ellipse];
+  n4 [label="/map/raw", shape=box];
+  n5 [label="/map/gdp_v1", shape=box];
+  n6 [label="/map/pop", shape=box];
+  n7 [label="/map/prev_pc", shape=box];
+  n8 [label="/map/prev_pc/transform", shape=ellipse];
+  n0 -> n1 [label="transform"];
+  n1 -> n2 [label="resource"];
+  n2 -> n3 [label="transform"];
+  n3 -> n4 [label="resource"];
+  n2 -> n5 [label="previous"];
+  n1 -> n6 [label="resource"];
+  n0 -> n7 [label="previous"];
+  n7 -> n8 [label="transform"];
+  n8 -> n6 [label="resource"];
+}
+`
+	if got := string(DOT(graph)); got != expect {
+		t.Errorf("DOT mismatch. expected:\n%s\ngot:\n%s", expect, got)
+	}
+
+	data, err := JSON(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectJSON := `{"nodes":[{"type":"dataset","path":"/map/per_capita"},{"type":"transform","path":"/map/per_capita_tf"},{"type":"dataset","path":"/map/gdp"},{"type":"transform","path":"/map/gdp_tf"},{"type":"dataset","path":"/map/raw"},{"type":"dataset","path":"/map/gdp_v1"},{"type":"dataset","path":"/map/pop"},{"type":"dataset","path":"/map/prev_pc"},{"type":"transform","path":"/map/prev_pc/transform"}],"links":[{"type":"transform","from":0,"to":1},{"type":"resource","from":1,"to":2},{"type":"transform","from":2,"to":3},{"type":"resource","from":3,"to":4},{"type":"previous","from":2,"to":5},{"type":"resource","from":1,"to":6},{"type":"previous","from":0,"to":7},{"type":"transform","from":7,"to":8},{"type":"resource","from":8,"to":6}]}`
+	if string(data) != expectJSON {
+		t.Errorf("JSON mismatch. expected:\n%s\ngot:\n%s", expectJSON, string(data))
+	}
+
+	if got := len(FilterNodeTypes(graph, NtTransform)); got != 3 {
+		t.Errorf("expected 3 transform nodes, got %d", got)
+	}
+}
+
+func TestLineageConfig(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		description string
+		cfg         func(cfg *LineageCfg)
+		nodes       int
+	}{
+		{"no previous", func(cfg *LineageCfg) { cfg.FollowPrevious = false }, 6},
+		{"depth 1", func(cfg *LineageCfg) { cfg.MaxDepth = 1 }, 3},
+		{"depth 2", func(cfg *LineageCfg) { cfg.MaxDepth = 2 }, 6},
+	}
+
+	for _, c := range cases {
+		graph, err := Lineage(ctx, perCapita(), lineageResolver, c.cfg)
+		if err != nil {
+			t.Errorf("case '%s' unexpected error: %s", c.description, err)
+			continue
+		}
+		if nodes, _ := graphNodes(graph); len(nodes) != c.nodes {
+			t.Errorf("case '%s' expected %d nodes, got %d:\n%s", c.description, c.nodes, len(nodes), DOT(graph))
+		}
+	}
+}
+
+func TestLineageShallowerPath(t *testing.T) {
+	// x is first reached at MaxDepth through the transform, then again one
+	// link from the root through the previous version
+	ds := &dataset.Dataset{
+		Path:         "/map/root",
+		PreviousPath: "/map/x",
+		Transform: &dataset.Transform{
+			Path:      "/map/root_tf",
+			Syntax:    "starlark",
+			Resources: map[string]*dataset.TransformResource{"x": {Path: "/map/x"}},
+		},
+	}
+	resolver := mapResolver{
+		"/map/x": `{"previousPath":"/map/y"}`,
+		"/map/y": `{}`,
+	}
+	graph, err := Lineage(context.Background(), ds, resolver, func(cfg *LineageCfg) { cfg.MaxDepth = 2 })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `digraph lineage {
+  n0 [label="/map/root", shape=box];
+  n1 [label="/map/root_tf", shape=ellipse];
+  n2 [label="/map/x", shape=box];
+  n3 [label="/map/y", shape=box];
+  n0 -> n1 [label="transform"];
+  n1 -> n2 [label="resource"];
+  n2 -> n3 [label="previous"];
+  n0 -> n2 [label="previous"];
+}
+`
+	if got := string(DOT(graph)); got != expect {
+		t.Errorf("DOT mismatch. expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
+
+func TestExportDanglingLinks(t *testing.T) {
+	graph := &Node{Type: NtDataset, Path: "/map/a"}
+	b := &Node{Type: NtDataset, Path: "/map/b"}
+	graph.Links = []Link{
+		{Type: LtPrevious, From: graph, To: nil},
+		{Type: LtPrevious, From: graph, To: b},
+	}
+
+	expect := "digraph lineage {\n  n0 [label=\"/map/a\", shape=box];\n  n1 [label=\"/map/b\", shape=box];\n  n0 -> n1 [label=\"previous\"];\n}\n"
+	if got := string(DOT(graph)); got != expect {
+		t.Errorf("DOT mismatch. expected:\n%s\ngot:\n%s", expect, got)
+	}
+
+	data, err := JSON(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectJSON := `{"nodes":[{"type":"dataset","path":"/map/a"},{"type":"dataset","path":"/map/b"}],"links":[{"type":"previous","from":0,"to":1}]}`
+	if string(data) != expectJSON {
+		t.Errorf("JSON mismatch. expected:\n%s\ngot:\n%s", expectJSON, string(data))
+	}
+}
+
+func TestLineageErrors(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		description string
+		ds          *dataset.Dataset
+		resolver    mapResolver
+		err         string
+	}{
+		{"missing resource", &dataset.Dataset{Path: "/map/a", Transform: &dataset.Transform{
+			Resources: map[string]*dataset.TransformResource{"b": {Path: "/map/b"}},
+		}}, mapResolver{}, "loading dataset '/map/b': not found: /map/b"},
+		{"missing transform", &dataset.Dataset{Path: "/map/a", Transform: dataset.NewTransformRef("/map/tf")},
+			mapResolver{}, "loading transform '/map/tf': not found: /map/tf"},
+		{"empty resource path", &dataset.Dataset{Path: "/map/a", Transform: &dataset.Transform{
+			Resources: map[string]*dataset.TransformResource{"b": {}},
+		}}, mapResolver{}, "transform '/map/a/transform' resource 'b' has no path"},
+		{"resource cycle", &dataset.Dataset{Path: "/map/a", Transform: &dataset.Transform{
+			Path:      "/map/a_tf",
+			Resources: map[string]*dataset.TransformResource{"b": {Path: "/map/b"}},
+		}}, mapResolver{
+			"/map/b":    `{"transform":{"path":"/map/b_tf"}}`,
+			"/map/b_tf": `{"syntax":"starlark","resources":{"a":"/map/a"}}`,
+		}, "cycle detected: /map/a -> /map/a_tf -> /map/b -> /map/b_tf -> /map/a"},
+		{"previous cycle", &dataset.Dataset{Path: "/map/a", PreviousPath: "/map/b"}, mapResolver{
+			"/map/b": `{"previousPath":"/map/a"}`,
+		}, "cycle detected: /map/a -> /map/b -> /map/a"},
+	}
+
+	for _, c := range cases {
+		_, err := Lineage(ctx, c.ds, c.resolver)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case '%s' error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+		}
+	}
+}
+
+func TestDOTEscaping(t *testing.T) {
+	graph := &Node{Type: NtDataset, Path: `a "quoted"\path`}
+	expect := "digraph lineage {\n  n0 [label=\"a \\\"quoted\\\"\\\\path\", shape=box];\n}\n"
+	if got := string(DOT(graph)); got != expect {
+		t.Errorf("result mismatch. expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
@@ -1,35 +1,46 @@
 package dsgraph
 
-// import (
-// 	"encoding/json"
-// 	"github.com/ipfs/go-datastore"
-// )
-
-// // ResourceMeta adds metadata to a resource key
-// type ResourceMeta map[datastore.Key]datastore.Key
-
-// func (rm ResourceMeta) SetMeta(resource, meta datastore.Key) {
-// 	rm[resource] = meta
-// }
-
-// func (rm ResourceMeta) MarshalJSON() ([]byte, error) {
-// 	rmmap := map[string]interface{}{}
-// 	for key, val := range rm {
-// 		rmmap[key.String()] = val.String()
-// 	}
-// 	return json.Marshal(rmmap)
-// }
-
-// func (rm *ResourceMeta) UnmarshalJSON(data []byte) error {
-// 	rmmap := map[string]datastore.Key{}
-// 	if err := json.Unmarshal(data, &rmmap); err != nil {
-// 		return err
-// 	}
-
-// 	r := ResourceMeta{}
-// 	for key, val := range rmmap {
-// 		r[datastore.NewKey(key)] = val
-// 	}
-// 	*rm = r
-// 	return nil
-// }
+import (
+	"encoding/json"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// ResourceMeta adds metadata to a resource key
+type ResourceMeta map[datastore.Key]datastore.Key
+
+// SetMeta sets the metadata key for a resource
+func (rm ResourceMeta) SetMeta(resource, meta datastore.Key) {
+	rm[resource] = meta
+}
+
+// Meta gets the metadata key for a resource, returning false if the resource
+// has no metadata
+func (rm ResourceMeta) Meta(resource datastore.Key) (datastore.Key, bool) {
+	meta, ok := rm[resource]
+	return meta, ok
+}
+
+// MarshalJSON implements the json.Marshaler interface for ResourceMeta
+func (rm ResourceMeta) MarshalJSON() ([]byte, error) {
+	rmmap := map[string]interface{}{}
+	for key, val := range rm {
+		rmmap[key.String()] = val.String()
+	}
+	return json.Marshal(rmmap)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for ResourceMeta
+func (rm *ResourceMeta) UnmarshalJSON(data []byte) error {
+	rmmap := map[string]datastore.Key{}
+	if err := json.Unmarshal(data, &rmmap); err != nil {
+		return err
+	}
+
+	r := ResourceMeta{}
+	for key, val := range rmmap {
+		r[datastore.NewKey(key)] = val
+	}
+	*rm = r
+	return nil
+}
@@ -1,46 +1,55 @@
 package dsgraph
 
-// import (
-// 	"encoding/json"
-// 	"github.com/ipfs/go-datastore"
-// )
-
-// // ResourceQueries connects a resource path to queries
-// // that consume the resource
-// type ResourceQueries map[datastore.Key][]datastore.Key
-
-// func (qr ResourceQueries) AddTransform(resource, transform datastore.Key) {
-// 	for _, r := range qr[resource] {
-// 		if r.Equal(transform) {
-// 			return
-// 		}
-// 	}
-// 	qr[resource] = append(qr[resource], transform)
-// }
-
-// func (qr ResourceQueries) MarshalJSON() ([]byte, error) {
-// 	strmap := map[string]interface{}{}
-// 	for key, vals := range qr {
-// 		strs := make([]string, len(vals))
-// 		for i, v := range vals {
-// 			strs[i] = v.String()
-// 		}
-// 		strmap[key.String()] = strs
-// 	}
-// 	return json.Marshal(strmap)
-// }
-
-// func (qr *ResourceQueries) UnmarshalJSON(data []byte) error {
-// 	strmap := map[string][]datastore.Key{}
-// 	if err := json.Unmarshal(data, &strmap); err != nil {
-// 		return err
-// 	}
-
-// 	r := ResourceQueries{}
-
-// 	for key, vals := range strmap {
-// 		r[datastore.NewKey(key)] = vals
-// 	}
-// 	*qr = r
-// 	return nil
-// }
+import (
+	"encoding/json"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// ResourceTransforms connects a resource path to transforms
+// that consume the resource
+type ResourceTransforms map[datastore.Key][]datastore.Key
+
+// AddTransform adds a transform that consumes resource
+func (rt ResourceTransforms) AddTransform(resource, transform datastore.Key) {
+	for _, t := range rt[resource] {
+		if t.Equal(transform) {
+			return
+		}
+	}
+	rt[resource] = append(rt[resource], transform)
+}
+
+// Transforms lists the transforms that consume resource
+func (rt ResourceTransforms) Transforms(resource datastore.Key) []datastore.Key {
+	return rt[resource]
+}
+
+// MarshalJSON implements the json.Marshaler interface for ResourceTransforms
+func (rt ResourceTransforms) MarshalJSON() ([]byte, error) {
+	strmap := map[string]interface{}{}
+	for key, vals := range rt {
+		strs := make([]string, len(vals))
+		for i, v := range vals {
+			strs[i] = v.String()
+		}
+		strmap[key.String()] = strs
+	}
+	return json.Marshal(strmap)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for ResourceTransforms
+func (rt *ResourceTransforms) UnmarshalJSON(data []byte) error {
+	strmap := map[string][]datastore.Key{}
+	if err := json.Unmarshal(data, &strmap); err != nil {
+		return err
+	}
+
+	r := ResourceTransforms{}
+
+	for key, vals := range strmap {
+		r[datastore.NewKey(key)] = vals
+	}
+	*rt = r
+	return nil
+}
@@ -18,6 +18,11 @@ func (qr TransformResults) AddResult(transform, result datastore.Key) {
 	qr[transform] = append(qr[transform], result)
 }
 
+// Results lists the results of a transform
+func (qr TransformResults) Results(transform datastore.Key) []datastore.Key {
+	return qr[transform]
+}
+
 // MarshalJSON implements the json.Marshaler interface for TransformResults
 func (qr TransformResults) MarshalJSON() ([]byte, error) {
 	qrmap := map[string]interface{}{}
@@ -0,0 +1,167 @@
+package dsgraph
+
+import (
+	"context"
+	"sync"
+)
+
+// WalkOrder sets the order a walk visits nodes in
+type WalkOrder string
+
+var (
+	// BreadthFirst visits all nodes at one depth before moving deeper
+	BreadthFirst = WalkOrder("bfs")
+	// DepthFirst visits descendants of a node before its siblings. with a
+	// Parallelism of one nodes are visited in depth-first preorder
+	DepthFirst = WalkOrder("dfs")
+)
+
+// WalkCfg configures a ParallelWalk
+type WalkCfg struct {
+	// Parallelism is the number of nodes visited concurrently. values less
+	// than one visit nodes one at a time
+	Parallelism int
+	// MaxDepth stops the walk from visiting nodes more than MaxDepth links
+	// away from the root. zero or less means no limit
+	MaxDepth int
+	// Order is the order nodes are scheduled for visiting. nodes are visited
+	// in exactly this order when Parallelism is one
+	Order WalkOrder
+}
+
+// DefaultWalkCfg visits nodes breadth-first, walkParallelism at a time
+func DefaultWalkCfg() *WalkCfg {
+	return &WalkCfg{
+		Parallelism: walkParallelism,
+		Order:       BreadthFirst,
+	}
+}
+
+type walkTask struct {
+	node  *Node
+	depth int
+}
+
+type walkResult struct {
+	walkTask
+	err error
+}
+
+// ParallelWalk visits node and all descendants with a provided visit function,
+// calling visit from up to cfg.Parallelism goroutines at once. Unlike Walk,
+// each node is visited only once, making ParallelWalk safe for graphs with
+// shared descendants and cycles. Depth is the number of links between the
+// root and the node along the path the walk visited it by, the shortest path
+// for breadth-first walks. Depth-first walks with a MaxDepth don't visit
+// descendants of nodes first reached at MaxDepth, even if they're reachable by
+// a shorter path. The walk stops at the first
+// error returned by visit, or when ctx is done, returning that error after any
+// in-progress visits finish
+func ParallelWalk(ctx context.Context, node *Node, visit func(n *Node, depth int) error, configs ...func(cfg *WalkCfg)) error {
+	cfg := DefaultWalkCfg()
+	for _, opt := range configs {
+		opt(cfg)
+	}
+	if cfg.Parallelism < 1 {
+		cfg.Parallelism = 1
+	}
+	if node == nil {
+		return nil
+	}
+
+	tasks := make(chan walkTask)
+	results := make(chan walkResult)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < cfg.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				results <- walkResult{walkTask: t, err: visit(t.node, t.depth)}
+			}
+		}()
+	}
+
+	var (
+		err      error
+		done     = ctx.Done()
+		seen     = map[*Node]bool{}
+		frontier = []walkTask{{node: node}}
+		inflight = 0
+	)
+
+	for (len(frontier) > 0 && err == nil) || inflight > 0 {
+		var (
+			send chan walkTask
+			next walkTask
+			idx  int
+		)
+		if len(frontier) > 0 && err == nil {
+			send = tasks
+			if cfg.Order == DepthFirst {
+				idx = len(frontier) - 1
+				// depth-first walks mark nodes seen when they're visited, a node
+				// can be queued more than once before that
+				if seen[frontier[idx].node] {
+					frontier = frontier[:idx]
+					continue
+				}
+			}
+			next = frontier[idx]
+		}
+
+		select {
+		case send <- next:
+			if idx == 0 {
+				frontier = frontier[1:]
+			} else {
+				frontier = frontier[:idx]
+			}
+			seen[next.node] = true
+			inflight++
+		case res := <-results:
+			inflight--
+			if res.err != nil {
+				if err == nil {
+					err = res.err
+				}
+				continue
+			}
+			if cfg.MaxDepth > 0 && res.depth >= cfg.MaxDepth {
+				continue
+			}
+			children := make([]walkTask, 0, len(res.node.Links))
+			for _, l := range res.node.Links {
+				if l.To != nil && !seen[l.To] {
+					// breadth-first walks mark nodes seen when queued, so each
+					// node is visited at the depth it's first reached
+					if cfg.Order != DepthFirst {
+						seen[l.To] = true
+					}
+					children = append(children, walkTask{node: l.To, depth: res.depth + 1})
+				}
+			}
+			if cfg.Order == DepthFirst {
+				// push children in reverse so the first link is visited first
+				for i := len(children) - 1; i >= 0; i-- {
+					frontier = append(frontier, children[i])
+				}
+			} else {
+				frontier = append(frontier, children...)
+			}
+		case <-done:
+			if err == nil {
+				err = ctx.Err()
+			}
+			// stop selecting on a closed channel while visits finish
+			done = nil
+		}
+	}
+
+	close(tasks)
+	wg.Wait()
+	if err != nil {
+		log.Debug(err.Error())
+	}
+	return err
+}
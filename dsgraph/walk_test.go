@@ -0,0 +1,180 @@
+package dsgraph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testGraph builds a graph from a map of parent paths to child paths. the
+// root node is "a"
+func testGraph(edges map[string][]string) *Node {
+	nodes := map[string]*Node{}
+	get := func(path string) *Node {
+		if nodes[path] == nil {
+			nodes[path] = &Node{Type: NtDataset, Path: path}
+		}
+		return nodes[path]
+	}
+	for from, tos := range edges {
+		n := get(from)
+		for _, to := range tos {
+			n.Links = append(n.Links, Link{From: n, To: get(to)})
+		}
+	}
+	return get("a")
+}
+
+// diamond is a DAG with a shared descendant "d", and a cycle from "e" back
+// to "a"
+var diamond = map[string][]string{
+	"a": {"b", "c"},
+	"b": {"d"},
+	"c": {"d", "e"},
+	"d": {"f"},
+	"e": {"a"},
+}
+
+// sibling is a DAG where "c" is both a child and a grandchild of the root
+var sibling = map[string][]string{
+	"a": {"b", "c"},
+	"b": {"c", "e"},
+}
+
+func TestParallelWalkOrder(t *testing.T) {
+	cases := []struct {
+		description string
+		graph       map[string][]string
+		cfg         func(cfg *WalkCfg)
+		expect      []string
+	}{
+		{"breadth first", diamond, func(cfg *WalkCfg) { cfg.Parallelism = 1 }, []string{"a:0", "b:1", "c:1", "d:2", "e:2", "f:3"}},
+		{"depth first", diamond, func(cfg *WalkCfg) { cfg.Parallelism = 1; cfg.Order = DepthFirst }, []string{"a:0", "b:1", "d:2", "f:3", "c:1", "e:2"}},
+		{"max depth", diamond, func(cfg *WalkCfg) { cfg.Parallelism = 1; cfg.MaxDepth = 1 }, []string{"a:0", "b:1", "c:1"}},
+		{"zero parallelism", diamond, func(cfg *WalkCfg) { cfg.Parallelism = 0; cfg.MaxDepth = 2 }, []string{"a:0", "b:1", "c:1", "d:2", "e:2"}},
+		{"breadth first shared child", sibling, func(cfg *WalkCfg) { cfg.Parallelism = 1 }, []string{"a:0", "b:1", "c:1", "e:2"}},
+		{"depth first preorder", sibling, func(cfg *WalkCfg) { cfg.Parallelism = 1; cfg.Order = DepthFirst }, []string{"a:0", "b:1", "c:2", "e:2"}},
+	}
+
+	for _, c := range cases {
+		var got []string
+		err := ParallelWalk(context.Background(), testGraph(c.graph), func(n *Node, depth int) error {
+			got = append(got, fmt.Sprintf("%s:%d", n.Path, depth))
+			return nil
+		}, c.cfg)
+		if err != nil {
+			t.Errorf("case '%s' unexpected error: %s", c.description, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case '%s' result mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestParallelWalkConcurrency(t *testing.T) {
+	// a wide graph, with every leaf shared by two parents
+	edges := map[string][]string{}
+	for i := 0; i < 20; i++ {
+		mid := fmt.Sprintf("m%d", i)
+		edges["a"] = append(edges["a"], mid)
+		edges[mid] = []string{fmt.Sprintf("l%d", i), fmt.Sprintf("l%d", (i+1)%20)}
+	}
+
+	var (
+		lk      sync.Mutex
+		visits  = map[string]int{}
+		active  int32
+		maxSeen int32
+	)
+	err := ParallelWalk(context.Background(), testGraph(edges), func(n *Node, depth int) error {
+		cur := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			prev := atomic.LoadInt32(&maxSeen)
+			if cur <= prev || atomic.CompareAndSwapInt32(&maxSeen, prev, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		lk.Lock()
+		visits[n.Path]++
+		lk.Unlock()
+		return nil
+	}, func(cfg *WalkCfg) { cfg.Parallelism = 3 })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(visits) != 41 {
+		t.Errorf("expected 41 nodes to be visited, got %d", len(visits))
+	}
+	for path, count := range visits {
+		if count != 1 {
+			t.Errorf("expected node '%s' to be visited once, got %d", path, count)
+		}
+	}
+	if maxSeen > 3 {
+		t.Errorf("expected at most 3 concurrent visits, got %d", maxSeen)
+	}
+}
+
+func TestParallelWalkErrors(t *testing.T) {
+	var visited int32
+	err := ParallelWalk(context.Background(), testGraph(diamond), func(n *Node, depth int) error {
+		atomic.AddInt32(&visited, 1)
+		if n.Path == "c" {
+			return fmt.Errorf("oh noes")
+		}
+		return nil
+	}, func(cfg *WalkCfg) { cfg.Parallelism = 1 })
+	if err == nil || err.Error() != "oh noes" {
+		t.Errorf("expected visit error, got: %v", err)
+	}
+	if visited != 3 {
+		t.Errorf("expected walk to stop after visiting 3 nodes, visited %d", visited)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	visited = 0
+	err = ParallelWalk(ctx, testGraph(diamond), func(n *Node, depth int) error {
+		if atomic.AddInt32(&visited, 1) == 2 {
+			cancel()
+		}
+		return nil
+	}, func(cfg *WalkCfg) { cfg.Parallelism = 1 })
+	if err != context.Canceled {
+		t.Errorf("expected context canceled error, got: %v", err)
+	}
+	if visited >= 6 {
+		t.Errorf("expected cancellation to stop the walk, visited %d nodes", visited)
+	}
+
+	if err := ParallelWalk(context.Background(), nil, func(n *Node, depth int) error {
+		t.Error("visit shouldn't be called for nil graphs")
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFilterNodeTypes(t *testing.T) {
+	graph := testGraph(diamond)
+	graph.Links[1].To.Type = NtTransform
+
+	got := FilterNodeTypes(graph, NtDataset)
+	paths := make([]string, len(got))
+	for i, n := range got {
+		paths[i] = n.Path
+	}
+	expect := []string{"a", "b", "d", "f", "e"}
+	if diff := cmp.Diff(expect, paths); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,112 @@
+package dsio
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/vals"
+)
+
+// Column is a named position within the entries of a dataset body. Columns
+// of tabular data are addressed by index, columns of arrays of objects by
+// property key
+type Column struct {
+	// Title is the column name, as given by the schema
+	Title string
+	// Index is the position of this column in array entries,
+	// -1 for columns of object entries
+	Index int
+	// Type is the declared type of this column, TypeUnknown if the schema
+	// doesn't specify one
+	Type vals.Type
+}
+
+// Columns lists the columns a structure's schema declares for each entry.
+// Tabular schemas list columns in order, object properties are sorted by key
+func Columns(st *dataset.Structure) ([]Column, error) {
+	if st == nil || st.Schema == nil {
+		return nil, fmt.Errorf("a schema object is required")
+	}
+
+	if titles, types, err := terribleHackToGetHeaderRowAndTypes(st); err == nil {
+		cols := make([]Column, len(titles))
+		for i, title := range titles {
+			cols[i] = Column{Title: title, Index: i, Type: vals.TypeFromString(types[i])}
+		}
+		return cols, nil
+	}
+
+	items, ok := st.Schema["items"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema doesn't describe entry columns")
+	}
+	props, ok := items["properties"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema doesn't describe entry columns")
+	}
+
+	cols := make([]Column, 0, len(props))
+	for key, p := range props {
+		col := Column{Title: key, Index: -1}
+		if pm, ok := p.(map[string]interface{}); ok {
+			col.Type = schemaType(pm["type"])
+		}
+		cols = append(cols, col)
+	}
+	sort.Slice(cols, func(i, j int) bool { return cols[i].Title < cols[j].Title })
+	return cols, nil
+}
+
+// FindColumns resolves a list of column names against a structure's schema
+func FindColumns(st *dataset.Structure, names ...string) ([]Column, error) {
+	cols, err := Columns(st)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]Column, len(names))
+NAMES:
+	for i, name := range names {
+		for _, col := range cols {
+			if col.Title == name {
+				found[i] = col
+				continue NAMES
+			}
+		}
+		return nil, fmt.Errorf("couldn't find column: %s", name)
+	}
+	return found, nil
+}
+
+// Value gives the value of this column within an entry value, returning nil
+// if the column isn't present
+func (c Column) Value(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []interface{}:
+		if c.Index >= 0 && c.Index < len(x) {
+			return x[c.Index]
+		}
+	case map[string]interface{}:
+		if c.Index < 0 {
+			return x[c.Title]
+		}
+	}
+	return nil
+}
+
+// schemaType reads a type from a schema "type" value, which may be a string
+// or a list of strings
+func schemaType(t interface{}) vals.Type {
+	switch x := t.(type) {
+	case string:
+		return vals.TypeFromString(x)
+	case []interface{}:
+		if len(x) > 0 {
+			if s, ok := x[0].(string); ok {
+				return vals.TypeFromString(s)
+			}
+		}
+	}
+	return vals.TypeUnknown
+}
@@ -0,0 +1,75 @@
+package dsio
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/vals"
+)
+
+func TestColumns(t *testing.T) {
+	cases := []struct {
+		st     *dataset.Structure
+		expect []Column
+		err    string
+	}{
+		{&dataset.Structure{}, nil, "a schema object is required"},
+		{&dataset.Structure{Schema: dataset.BaseSchemaArray}, nil, "schema doesn't describe entry columns"},
+		{&dataset.Structure{Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "a", "type": "string"},
+					map[string]interface{}{"title": "b", "type": []interface{}{"integer", "null"}},
+				},
+			},
+		}}, []Column{
+			{Title: "a", Index: 0, Type: vals.TypeString},
+			{Title: "b", Index: 1, Type: vals.TypeInteger},
+		}, ""},
+		{&dataset.Structure{Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"z": map[string]interface{}{"type": "number"},
+					"y": map[string]interface{}{},
+				},
+			},
+		}}, []Column{
+			{Title: "y", Index: -1},
+			{Title: "z", Index: -1, Type: vals.TypeNumber},
+		}, ""},
+	}
+
+	for i, c := range cases {
+		got, err := Columns(c.st)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestColumnValue(t *testing.T) {
+	arr := Column{Title: "b", Index: 1}
+	obj := Column{Title: "b", Index: -1}
+
+	if got := arr.Value([]interface{}{"a", "b"}); got != "b" {
+		t.Errorf("expected array column value 'b', got: %v", got)
+	}
+	if got := arr.Value([]interface{}{"a"}); got != nil {
+		t.Errorf("expected out of range value to be nil, got: %v", got)
+	}
+	if got := obj.Value(map[string]interface{}{"b": "c"}); got != "c" {
+		t.Errorf("expected object column value 'c', got: %v", got)
+	}
+	if got := obj.Value([]interface{}{"a", "b"}); got != nil {
+		t.Errorf("expected object column on array to be nil, got: %v", got)
+	}
+}
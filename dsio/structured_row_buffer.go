@@ -1,194 +1,589 @@
 package dsio
 
-// import (
-// 	"bytes"
-// 	"fmt"
-// 	"sort"
-
-// 	"github.com/qri-io/dataset"
-// )
-
-// // StructuredRowBuffer is the full-featured version of StructuredBuffer
-// // While incurring additional overhead & programmatic completexity, it brings
-// // the capactity to do things like sort data by fields, filter duplicate
-// // rows, etc.
-// type StructuredRowBuffer struct {
-// 	st     *dataset.Structure
-// 	rows   [][][]byte
-// 	less   *func(i, j int) bool
-// 	unique bool
-// 	err    error
-// }
-
-// // StructuredRowBufferCfg encapsulates configuration for StructuredRowBuffer
-// type StructuredRowBufferCfg struct {
-// 	// TODO - restore
-// 	// OrderBy gives a list of orders
-// 	// OrderBy []*dataset.Field
-// 	// OrderByDesc reverses the order given
-// 	// OrderByDesc bool
-
-// 	// Unique silently rejects writing rows
-// 	// already present in the buffer
-// 	Unique bool
-// 	// TODO - FilterFunc only allows rows that pass a given test function
-// 	// FilterFunc func(row [][]byte) bool
-// }
-
-// // NewStructuredRowBuffer allocates a StructuredRowBuffer from a statement
-// func NewStructuredRowBuffer(st *dataset.Structure, configs ...func(o *StructuredRowBufferCfg)) (*StructuredRowBuffer, error) {
-// 	cfg := &StructuredRowBufferCfg{}
-// 	for _, config := range configs {
-// 		config(cfg)
-// 	}
-
-// 	rb := &StructuredRowBuffer{
-// 		st:     st,
-// 		unique: cfg.Unique,
-// 	}
-// 	// rb.less, rb.err = rb.makeLessFunc(st, cfg)
-
-// 	return rb, nil
-// }
-
-// // Structure gives the underlying structure this buffer is using
-// func (rb *StructuredRowBuffer) Structure() *dataset.Structure {
-// 	return rb.st
-// }
-
-// // ReadRow reads one row from the buffer
-// func (rb *StructuredRowBuffer) ReadRow() ([][]byte, error) {
-// 	return nil, fmt.Errorf("cannot read rows from a *StructuredRowBuffer, call Close() then Bytes() instead")
-// }
-
-// // WriteRow writes one row to the buffer
-// func (rb *StructuredRowBuffer) WriteRow(row [][]byte) error {
-// 	if rb.unique && rb.HasRow(row) {
-// 		return nil
-// 	}
-// 	rb.rows = append(rb.rows, row)
-// 	return nil
-// }
-
-// // Close closes the writer portion of the buffer, which will affect
-// // underlying contents.
-// func (rb *StructuredRowBuffer) Close() error {
-// 	if rb.err != nil {
-// 		return rb.err
-// 	}
-// 	if rb.less != nil {
-// 		sort.Sort(rb)
-// 	}
-// 	return nil
-// }
-
-// // Bytes gives the raw contents of the underlying buffer
-// func (rb *StructuredRowBuffer) Bytes() []byte {
-// 	buf, err := NewValueBuffer(rb.Structure())
-// 	if err != nil {
-// 		// shouldn't be possible
-// 		panic(err)
-// 	}
-// 	for _, row := range rb.rows {
-// 		if err := buf.WriteRow(row); err != nil {
-// 			return nil
-// 		}
-// 	}
-// 	if err := buf.Close(); err != nil {
-// 		return nil
-// 	}
-// 	return buf.Bytes()
-// }
-
-// // HasRow checks if a row is in the buffer or not
-// func (rb *StructuredRowBuffer) HasRow(row [][]byte) bool {
-// ROWS:
-// 	for _, r := range rb.rows {
-// 		if len(r) != len(row) {
-// 			return false
-// 		}
-// 		for i, cell := range row {
-// 			if !bytes.Equal(r[i], cell) {
-// 				continue ROWS
-// 			}
-// 		}
-// 		return true
-// 	}
-// 	return false
-// }
-
-// // Len is the number of elements in the collection.
-// func (rb *StructuredRowBuffer) Len() int {
-// 	return len(rb.rows)
-// }
-
-// // Less reports whether the element with
-// // index i should sort before the element with index j.
-// func (rb *StructuredRowBuffer) Less(i, j int) bool {
-// 	less := *rb.less
-// 	return less(i, j)
-// }
-
-// // Swap swaps the elements with indexes i and j.
-// func (rb *StructuredRowBuffer) Swap(i, j int) {
-// 	rb.rows[i], rb.rows[j] = rb.rows[j], rb.rows[i]
-// }
-
-// // func (rb *StructuredRowBuffer) makeLessFunc(st *dataset.Structure, cfg *StructuredRowBufferCfg) (*func(i, j int) bool, error) {
-// // 	if len(cfg.OrderBy) == 0 {
-// // 		return nil, nil
-// // 	}
-
-// // 	type order struct {
-// // 		idx  int
-// // 		desc bool
-// // 		dt   datatypes.Type
-// // 	}
-
-// // 	if st.Schema == nil {
-// // 		return nil, fmt.Errorf("structure has no schema")
-// // 	}
-
-// // 	orders := []order{}
-// // 	for _, o := range cfg.OrderBy {
-// // 		idx := -1
-// // 		for i, f := range st.Schema.Fields {
-// // 			if f == o || f.Name == o.Name {
-// // 				idx = i
-// // 				break
-// // 			}
-// // 		}
-
-// // 		if idx < 0 {
-// // 			return nil, fmt.Errorf("couldn't find sort field: %s", o.Name)
-// // 		}
-
-// // 		orders = append(orders, order{
-// // 			idx:  idx,
-// // 			desc: cfg.OrderByDesc,
-// // 			dt:   st.Schema.Fields[idx].Type,
-// // 		})
-// // 	}
-
-// // 	less := func(i, j int) bool {
-// // 		for _, o := range orders {
-// // 			l, err := datatypes.CompareTypeBytes(rb.rows[i][o.idx], rb.rows[j][o.idx], o.dt)
-// // 			if err != nil {
-// // 				// TODO - wut
-// // 				continue
-// // 			}
-// // 			if l == 0 {
-// // 				continue
-// // 			}
-// // 			return l < 0
-// // 		}
-// // 		return false
-// // 	}
-
-// // 	if cfg.OrderByDesc {
-// // 		opposite := func(i, j int) bool { return !less(i, j) }
-// // 		return &opposite, nil
-// // 	}
-
-// // 	return &less, nil
-// // }
+import (
+	"bytes"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/vals"
+	"github.com/ugorji/go/codec"
+)
+
+// DefaultMaxMemoryBytes is the default amount of memory a StructuredRowBuffer
+// will use to hold entries before spilling sorted runs to disk
+const DefaultMaxMemoryBytes = 64 << 20
+
+// StructuredRowBuffer is the full-featured version of EntryBuffer
+// While incurring additional overhead & programmatic complexity, it brings
+// the capacity to do things like sort data by fields, filter duplicate
+// rows, etc. Entries are held in memory up to a configurable budget, beyond
+// which sorted runs are written to temporary files & merged on read, so
+// bodies larger than available memory can be sorted. Entries that compare
+// equal keep the order they were written in, and descending orders reverse
+// the complete ascending order, including that tiebreak. Sorted unique
+// buffers drop duplicates as sorted runs are merged, ordering entries with
+// equal order columns by their encoding so duplicates are adjacent.
+//
+// StructuredRowBuffer is write-then-read: all entries must be written & the
+// buffer closed before any entries can be read back
+type StructuredRowBuffer struct {
+	st      *dataset.Structure
+	orders  []Column
+	desc    bool
+	unique  bool
+	maxMem  int
+	tempDir string
+
+	entries []sortEntry
+	memSize int
+	runs    []string
+	seq     int
+	// seen holds digests of distinct entries for unique buffers without
+	// orders, which can't drop duplicates while merging
+	seen map[[sha256.Size]byte]bool
+
+	closed bool
+	merge  *mergeHeap
+	// last is the encoding of the last entry read from a sorted unique buffer
+	last  []byte
+	index int
+	data  []byte
+	err   error
+}
+
+var _ EntryReadWriter = (*StructuredRowBuffer)(nil)
+
+// StructuredRowBufferCfg encapsulates configuration for StructuredRowBuffer
+type StructuredRowBufferCfg struct {
+	// OrderBy gives a list of columns to sort by. For tabular data columns
+	// are referred to by schema title, for arrays of objects by property name.
+	// Without any columns entries keep the order they were written in
+	OrderBy []string
+	// OrderByDesc reverses the order given
+	OrderByDesc bool
+	// Unique silently drops entries that are exact duplicates of an
+	// entry already written to the buffer. With OrderBy columns duplicates
+	// are dropped while merging sorted runs, using memory within
+	// MaxMemoryBytes. Without OrderBy columns entries keep write order, and a
+	// digest of each distinct entry is held in memory for the lifetime of the
+	// buffer, so memory use grows with the number of distinct entries
+	Unique bool
+	// MaxMemoryBytes caps the approximate number of bytes of entry data held
+	// in memory before spilling to disk. defaults to DefaultMaxMemoryBytes
+	MaxMemoryBytes int
+	// TempDir is the directory spilled runs are written to, defaults to
+	// the system temp directory
+	TempDir string
+}
+
+// sortEntry pairs an entry with precomputed comparison values. seq is the
+// position the entry was written at, raw is the entry encoding sorted unique
+// buffers compare
+type sortEntry struct {
+	ent   Entry
+	cells [][]byte
+	raw   []byte
+	seq   int
+}
+
+// NewStructuredRowBuffer allocates a StructuredRowBuffer from a structure
+func NewStructuredRowBuffer(st *dataset.Structure, configs ...func(o *StructuredRowBufferCfg)) (*StructuredRowBuffer, error) {
+	cfg := &StructuredRowBufferCfg{
+		MaxMemoryBytes: DefaultMaxMemoryBytes,
+	}
+	for _, config := range configs {
+		config(cfg)
+	}
+
+	if _, err := GetTopLevelType(st); err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	orders, err := makeOrders(st, cfg.OrderBy)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	rb := &StructuredRowBuffer{
+		st:      st,
+		orders:  orders,
+		desc:    cfg.OrderByDesc,
+		unique:  cfg.Unique,
+		maxMem:  cfg.MaxMemoryBytes,
+		tempDir: cfg.TempDir,
+	}
+	if rb.unique && len(rb.orders) == 0 {
+		rb.seen = map[[sha256.Size]byte]bool{}
+	}
+	return rb, nil
+}
+
+// Structure gives the underlying structure this buffer is using
+func (rb *StructuredRowBuffer) Structure() *dataset.Structure {
+	return rb.st
+}
+
+// WriteEntry writes one entry to the buffer
+func (rb *StructuredRowBuffer) WriteEntry(ent Entry) error {
+	if rb.closed {
+		return fmt.Errorf("cannot write entries to a closed buffer")
+	}
+
+	if rb.seen != nil {
+		data, err := encodeEntry(ent)
+		if err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+		sum := sha256.Sum256(data)
+		if rb.seen[sum] {
+			return nil
+		}
+		rb.seen[sum] = true
+	}
+
+	se, err := rb.newSortEntry(ent, rb.seq)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+
+	rb.seq++
+	rb.entries = append(rb.entries, se)
+	rb.memSize += ApproxSize(ent.Value) + len(ent.Key) + len(se.raw)
+
+	if rb.maxMem > 0 && rb.memSize >= rb.maxMem {
+		return rb.spill()
+	}
+	return nil
+}
+
+// Close finalizes the writer portion of the buffer, sorting all written
+// entries. Calling Close a second time removes any temporary files
+func (rb *StructuredRowBuffer) Close() error {
+	if rb.closed {
+		return rb.cleanup()
+	}
+	rb.closed = true
+
+	rb.sortEntries()
+	sources := make([]entrySource, 0, len(rb.runs)+1)
+	for _, path := range rb.runs {
+		src, err := rb.openRun(path)
+		if err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+		sources = append(sources, src)
+	}
+	sources = append(sources, &sliceSource{entries: rb.entries})
+	rb.entries = nil
+
+	rb.merge = &mergeHeap{less: rb.less}
+	for i, src := range sources {
+		if err := rb.merge.push(src, i); err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadEntry reads one entry from the buffer in sorted order. Reading is only
+// possible once the buffer has been closed
+func (rb *StructuredRowBuffer) ReadEntry() (Entry, error) {
+	if !rb.closed {
+		return Entry{}, fmt.Errorf("cannot read entries from an open buffer, call Close() first")
+	}
+	if rb.err != nil {
+		return Entry{}, rb.err
+	}
+
+	se, err := rb.merge.pop()
+	// sorted duplicates are adjacent, skip entries equal to the last one read
+	for err == nil && se.raw != nil && bytes.Equal(se.raw, rb.last) {
+		se, err = rb.merge.pop()
+	}
+	if err != nil {
+		if err == io.EOF {
+			rb.cleanup()
+		}
+		rb.err = err
+		return Entry{}, err
+	}
+	rb.last = se.raw
+
+	ent := se.ent
+	ent.Index = rb.index
+	rb.index++
+	return ent, nil
+}
+
+// Bytes gives the sorted contents of the buffer encoded in the buffer's
+// structure format. Bytes consumes any entries that have not yet been read
+func (rb *StructuredRowBuffer) Bytes() []byte {
+	if rb.data != nil || !rb.closed {
+		return rb.data
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(rb.st, buf)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil
+	}
+	if err := Copy(rb, w); err != nil {
+		log.Debug(err.Error())
+		return nil
+	}
+	if err := w.Close(); err != nil {
+		log.Debug(err.Error())
+		return nil
+	}
+	rb.data = buf.Bytes()
+	return rb.data
+}
+
+// Len is the number of entries currently held in memory
+func (rb *StructuredRowBuffer) Len() int {
+	return len(rb.entries)
+}
+
+// Less reports whether the in-memory entry with index i should sort before
+// the entry with index j
+func (rb *StructuredRowBuffer) Less(i, j int) bool {
+	return rb.less(rb.entries[i], rb.entries[j])
+}
+
+// Swap swaps the in-memory entries with indexes i and j
+func (rb *StructuredRowBuffer) Swap(i, j int) {
+	rb.entries[i], rb.entries[j] = rb.entries[j], rb.entries[i]
+}
+
+func (rb *StructuredRowBuffer) sortEntries() {
+	sort.Stable(rb)
+}
+
+// spill sorts in-memory entries & writes them to a temporary file
+func (rb *StructuredRowBuffer) spill() error {
+	rb.sortEntries()
+
+	f, err := ioutil.TempFile(rb.tempDir, "dsio_sort_")
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("creating temp file: %s", err.Error())
+	}
+	defer f.Close()
+	rb.runs = append(rb.runs, f.Name())
+
+	enc := codec.NewEncoder(f, spillHandle())
+	for _, se := range rb.entries {
+		if err := enc.Encode([]interface{}{se.ent.Key, se.ent.Value, se.seq}); err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("writing temp file: %s", err.Error())
+		}
+	}
+
+	rb.entries = rb.entries[:0]
+	rb.memSize = 0
+	return nil
+}
+
+func (rb *StructuredRowBuffer) openRun(path string) (entrySource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &runSource{
+		f:   f,
+		dec: codec.NewDecoder(f, spillHandle()),
+		rb:  rb,
+	}, nil
+}
+
+func (rb *StructuredRowBuffer) cleanup() error {
+	var err error
+	if rb.merge != nil {
+		for _, src := range rb.merge.all {
+			if e := src.close(); e != nil {
+				err = e
+			}
+		}
+		rb.merge.all = nil
+	}
+	for _, path := range rb.runs {
+		if e := os.Remove(path); e != nil && !os.IsNotExist(e) {
+			err = e
+		}
+	}
+	rb.runs = nil
+	return err
+}
+
+func (rb *StructuredRowBuffer) newSortEntry(ent Entry, seq int) (sortEntry, error) {
+	se := sortEntry{ent: ent, seq: seq}
+	if len(rb.orders) > 0 {
+		se.cells = make([][]byte, len(rb.orders))
+		for i, o := range rb.orders {
+			se.cells[i] = ValueBytes(o.Value(ent.Value))
+		}
+	}
+	if rb.unique && len(rb.orders) > 0 {
+		data, err := encodeEntry(ent)
+		if err != nil {
+			return se, err
+		}
+		se.raw = data
+	}
+	return se, nil
+}
+
+// encodeEntry gives the encoding unique buffers compare entries by
+func encodeEntry(ent Entry) ([]byte, error) {
+	data, err := json.Marshal([]interface{}{ent.Key, ent.Value})
+	if err != nil {
+		return nil, fmt.Errorf("encoding entry: %s", err.Error())
+	}
+	return data, nil
+}
+
+// compare returns the relative order of two entries: -1, 0, or 1. entries
+// with equal order columns are ordered by encoding for sorted unique
+// buffers, then by write position
+func (rb *StructuredRowBuffer) compare(a, b sortEntry) (c int) {
+	for i, o := range rb.orders {
+		if c = compareValueBytes(a.cells[i], b.cells[i], o.Type); c != 0 {
+			break
+		}
+	}
+	if c == 0 && a.raw != nil && b.raw != nil {
+		c = bytes.Compare(a.raw, b.raw)
+	}
+	if c == 0 {
+		if a.seq < b.seq {
+			c = -1
+		} else if a.seq > b.seq {
+			c = 1
+		}
+	}
+	if rb.desc {
+		return -c
+	}
+	return c
+}
+
+func (rb *StructuredRowBuffer) less(a, b sortEntry) bool {
+	return rb.compare(a, b) < 0
+}
+
+// makeOrders resolves a list of column names to orders using the schema
+func makeOrders(st *dataset.Structure, names []string) ([]Column, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	orders := make([]Column, len(names))
+	for i, name := range names {
+		// schemas that don't describe columns have nothing to sort by
+		cols, err := FindColumns(st, name)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find sort column: %s", name)
+		}
+		orders[i] = cols[0]
+	}
+	return orders, nil
+}
+
+// ValueBytes converts a decoded entry value to the byte representation
+// expected by vals.CompareTypeBytes. nil values become empty slices, which
+// always sort first
+func ValueBytes(v interface{}) []byte {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []byte(x)
+	case int:
+		return []byte(strconv.Itoa(x))
+	case int64:
+		return []byte(strconv.FormatInt(x, 10))
+	case float64:
+		return []byte(strconv.FormatFloat(x, 'f', -1, 64))
+	case bool:
+		return []byte(strconv.FormatBool(x))
+	default:
+		data, _ := json.Marshal(x)
+		return data
+	}
+}
+
+// CompareValues orders two decoded entry values as values of type t,
+// returning -1, 0, or 1. Values that can't be compared as t are compared
+// by their byte representation. StructuredRowBuffer sorts columns with
+// this ordering
+func CompareValues(a, b interface{}, t vals.Type) int {
+	return compareValueBytes(ValueBytes(a), ValueBytes(b), t)
+}
+
+func compareValueBytes(a, b []byte, t vals.Type) int {
+	c, err := vals.CompareTypeBytes(a, b, t)
+	if err != nil {
+		return bytes.Compare(a, b)
+	}
+	return c
+}
+
+// ApproxSize estimates the in-memory size of a decoded entry value in bytes
+func ApproxSize(v interface{}) int {
+	switch x := v.(type) {
+	case string:
+		return len(x) + 16
+	case []interface{}:
+		size := 24
+		for _, el := range x {
+			size += ApproxSize(el)
+		}
+		return size
+	case map[string]interface{}:
+		size := 48
+		for key, el := range x {
+			size += len(key) + 16 + ApproxSize(el)
+		}
+		return size
+	default:
+		return 16
+	}
+}
+
+func spillHandle() *codec.CborHandle {
+	h := &codec.CborHandle{}
+	h.SignedInteger = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.SliceType = reflect.TypeOf([]interface{}(nil))
+	return h
+}
+
+// entrySource is a sorted sequence of entries
+type entrySource interface {
+	next() (sortEntry, error)
+	close() error
+}
+
+type sliceSource struct {
+	entries []sortEntry
+}
+
+func (s *sliceSource) next() (sortEntry, error) {
+	if len(s.entries) == 0 {
+		return sortEntry{}, io.EOF
+	}
+	se := s.entries[0]
+	s.entries = s.entries[1:]
+	return se, nil
+}
+
+func (s *sliceSource) close() error { return nil }
+
+// runSource reads a sorted run back from a temporary file
+type runSource struct {
+	f   *os.File
+	dec *codec.Decoder
+	rb  *StructuredRowBuffer
+}
+
+func (s *runSource) next() (sortEntry, error) {
+	rec := []interface{}{}
+	if err := s.dec.Decode(&rec); err != nil {
+		return sortEntry{}, err
+	}
+	if len(rec) != 3 {
+		return sortEntry{}, fmt.Errorf("invalid temp file record")
+	}
+	key, _ := rec[0].(string)
+	var seq int
+	switch x := rec[2].(type) {
+	case int64:
+		seq = int(x)
+	case uint64:
+		seq = int(x)
+	default:
+		return sortEntry{}, fmt.Errorf("invalid temp file record")
+	}
+	return s.rb.newSortEntry(Entry{Key: key, Value: rec[1]}, seq)
+}
+
+func (s *runSource) close() error {
+	return s.f.Close()
+}
+
+// mergeHeap performs a k-way merge of sorted entry sources. ties are broken
+// by source position, keeping the merge stable
+type mergeHeap struct {
+	less  func(a, b sortEntry) bool
+	heads []mergeHead
+	all   []entrySource
+}
+
+type mergeHead struct {
+	se  sortEntry
+	pos int
+	src entrySource
+}
+
+func (h *mergeHeap) Len() int { return len(h.heads) }
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.heads[i], h.heads[j]
+	if h.less(a.se, b.se) {
+		return true
+	} else if h.less(b.se, a.se) {
+		return false
+	}
+	return a.pos < b.pos
+}
+func (h *mergeHeap) Swap(i, j int)      { h.heads[i], h.heads[j] = h.heads[j], h.heads[i] }
+func (h *mergeHeap) Push(x interface{}) { h.heads = append(h.heads, x.(mergeHead)) }
+func (h *mergeHeap) Pop() interface{} {
+	last := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return last
+}
+
+func (h *mergeHeap) push(src entrySource, pos int) error {
+	h.all = append(h.all, src)
+	se, err := src.next()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	heap.Push(h, mergeHead{se: se, pos: pos, src: src})
+	return nil
+}
+
+func (h *mergeHeap) pop() (sortEntry, error) {
+	if h.Len() == 0 {
+		return sortEntry{}, io.EOF
+	}
+	head := h.heads[0]
+	se, err := head.src.next()
+	if err == io.EOF {
+		heap.Pop(h)
+	} else if err != nil {
+		return sortEntry{}, err
+	} else {
+		h.heads[0].se = se
+		heap.Fix(h, 0)
+	}
+	return head.se, nil
+}
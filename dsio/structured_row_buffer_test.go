@@ -1,138 +1,229 @@
 package dsio
 
-// import (
-// 	"bytes"
-// 	"fmt"
-// 	"io/ioutil"
-// 	"os"
-// 	"testing"
-
-// 	"github.com/qri-io/dataset"
-// 	dmp "github.com/sergi/go-diff/diffmatchpatch"
-// )
-
-// func TestStructuredRowBuffer(t *testing.T) {
-// 	datasets, err := makeTestData()
-// 	if err != nil {
-// 		t.Errorf("error creating filestore", err.Error())
-// 		return
-// 	}
-
-// 	cases := []struct {
-// 		dsName     string
-// 		Cfg        func(cfg *StructuredRowBufferCfg)
-// 		resultPath string
-// 		// newErr     string
-// 	}{
-// 		{"movies", func(cfg *StructuredRowBufferCfg) {}, "testdata/movies.csv"},
-// 		{"movies", func(cfg *StructuredRowBufferCfg) {
-// 			cfg.OrderBy = []*dataset.Field{
-// 				&dataset.Field{Name: "movie_title"},
-// 			}
-// 		}, "testdata/movies_sorted_movie_title.csv"},
-// 		{"movies", func(cfg *StructuredRowBufferCfg) {
-// 			cfg.OrderBy = []*dataset.Field{
-// 				&dataset.Field{Name: "movie_title"},
-// 			}
-// 			cfg.OrderByDesc = true
-// 		}, "testdata/movies_sorted_movie_title_desc.csv"},
-// 		{"movies", func(cfg *StructuredRowBufferCfg) {
-// 			cfg.OrderBy = []*dataset.Field{
-// 				&dataset.Field{Name: "duration"},
-// 				&dataset.Field{Name: "movie_title"},
-// 			}
-// 		}, "testdata/movies_sorted_duration_movie_title.csv"},
-// 		{"movies", func(cfg *StructuredRowBufferCfg) {
-// 			cfg.OrderBy = []*dataset.Field{
-// 				&dataset.Field{Name: "duration"},
-// 			}
-// 			cfg.OrderByDesc = true
-// 		}, "testdata/movies_sorted_duration_desc.csv"},
-// 		{"cities", func(cfg *StructuredRowBufferCfg) {
-// 			cfg.Unique = true
-// 		}, "testdata/cities_unique.csv"},
-// 	}
-
-// 	for i, c := range cases {
-// 		ds := datasets[c.dsName].ds
-// 		// if err != nil {
-// 		// 	t.Errorf("error creating dataset: %s", err.Error())
-// 		// 	return
-// 		// }
-
-// 		// outst := &dataset.Structure{
-// 		// 	Format: dataset.JSONDataFormat,
-// 		// 	FormatConfig: &dataset.JSONOptions{
-// 		// 		ArrayEntries: false,
-// 		// 	},
-// 		// 	Schema: ds.Structure.Schema,
-// 		// }
-
-// 		srbuf, err := NewStructuredRowBuffer(ds.Structure, c.Cfg)
-// 		if err != nil {
-// 			t.Errorf("case %d error allocating StructuredRowBuffer: %s", i, err.Error())
-// 			continue
-// 		}
-
-// 		rr, err := NewRowReader(ds.Structure, bytes.NewBuffer(datasets[c.dsName].data))
-// 		if err != nil {
-// 			t.Errorf("case %d error allocating RowReader: %s", i, err.Error())
-// 			continue
-// 		}
-
-// 		if err = EachRow(rr, func(i int, row [][]byte, err error) error {
-// 			if err != nil {
-// 				return err
-// 			}
-// 			return srbuf.WriteRow(row)
-// 		}); err != nil {
-// 			t.Errorf("error writing rows: %s", err.Error())
-// 			continue
-// 		}
-
-// 		srbufs := srbuf.Structure()
-// 		if err := dataset.CompareStructures(ds.Structure, srbufs); err != nil {
-// 			t.Errorf("case %d buffer structure mismatch: %s", i, err.Error())
-// 			continue
-// 		}
-
-// 		if err := srbuf.Close(); err != nil {
-// 			t.Errorf("case %d error closing buffer: %s", i, err.Error())
-// 			continue
-// 		}
-
-// 		if c.resultPath != "" {
-// 			expectBytes, err := ioutil.ReadFile(c.resultPath)
-// 			if err != nil {
-// 				t.Errorf("case %d error reading result data file: %s", i, err.Error())
-// 				continue
-// 			}
-// 			if !bytes.Equal(expectBytes, srbuf.Bytes()) {
-// 				dmp := dmp.New()
-// 				diffs := dmp.DiffMain(string(expectBytes), string(srbuf.Bytes()), true)
-// 				if len(diffs) == 0 {
-// 					t.Logf("case %d bytes were unequal but computed no difference between results")
-// 					continue
-// 				}
-
-// 				t.Errorf("case %d mismatch:\n%s", i, dmp.DiffPrettyText(diffs))
-// 				path := fmt.Sprintf("%sTestStructuredRowBuffer_case_%d_data.%s", os.TempDir(), i, ds.Structure.Format.String())
-// 				if err := ioutil.WriteFile(path, srbuf.Bytes(), os.ModePerm); err == nil {
-// 					t.Logf("result bytes written to: %s", path)
-// 				}
-// 			}
-// 		}
-
-// 	}
-
-// 	// out := []interface{}{}
-// 	// if err := json.Unmarshal(rbuf.Bytes(), &out); err != nil {
-// 	// 	t.Errorf("error unmarshaling encoded bytes: %s", err.Error())
-// 	// 	return
-// 	// }
-
-// 	// if _, err = json.Marshal(out); err != nil {
-// 	// 	t.Errorf("error marshaling json data: %s", err.Error())
-// 	// 	return
-// 	// }
-// }
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dstest"
+)
+
+func TestStructuredRowBuffer(t *testing.T) {
+	cases := []struct {
+		dsName     string
+		Cfg        func(cfg *StructuredRowBufferCfg)
+		resultPath string
+	}{
+		{"movies", func(cfg *StructuredRowBufferCfg) {}, "testdata/csv/movies/body.csv"},
+		{"movies", func(cfg *StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"movie_title"}
+		}, "testdata/csv/movies_sorted_movie_title/body.csv"},
+		{"movies", func(cfg *StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"movie_title"}
+			cfg.OrderByDesc = true
+		}, "testdata/csv/movies_sorted_movie_title_desc/body.csv"},
+		{"movies", func(cfg *StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"duration", "movie_title"}
+		}, "testdata/csv/movies_sorted_duration_movie_title/body.csv"},
+		{"movies", func(cfg *StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"duration"}
+			cfg.OrderByDesc = true
+		}, "testdata/csv/movies_sorted_duration_desc/body.csv"},
+		{"cities", func(cfg *StructuredRowBufferCfg) {
+			cfg.Unique = true
+		}, "testdata/csv/cities_unique/cities_unique.csv"},
+		// tiny memory budgets force spilling sorted runs to disk
+		{"movies", func(cfg *StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"duration", "movie_title"}
+			cfg.MaxMemoryBytes = 512
+		}, "testdata/csv/movies_sorted_duration_movie_title/body.csv"},
+		{"movies", func(cfg *StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"duration"}
+			cfg.OrderByDesc = true
+			cfg.MaxMemoryBytes = 512
+		}, "testdata/csv/movies_sorted_duration_desc/body.csv"},
+		{"cities", func(cfg *StructuredRowBufferCfg) {
+			cfg.Unique = true
+			cfg.MaxMemoryBytes = 1
+		}, "testdata/csv/cities_unique/cities_unique.csv"},
+	}
+
+	for i, c := range cases {
+		tc, err := dstest.NewTestCaseFromDir("testdata/csv/" + c.dsName)
+		if err != nil {
+			t.Fatalf("case %d error loading test case: %s", i, err.Error())
+		}
+		st := tc.Input.Structure
+
+		srbuf, err := NewStructuredRowBuffer(st, c.Cfg)
+		if err != nil {
+			t.Errorf("case %d error allocating StructuredRowBuffer: %s", i, err.Error())
+			continue
+		}
+
+		rr, err := NewEntryReader(st, tc.BodyFile())
+		if err != nil {
+			t.Errorf("case %d error allocating EntryReader: %s", i, err.Error())
+			continue
+		}
+
+		if err = Copy(rr, srbuf); err != nil {
+			t.Errorf("case %d error writing entries: %s", i, err.Error())
+			continue
+		}
+
+		if err := srbuf.Close(); err != nil {
+			t.Errorf("case %d error closing buffer: %s", i, err.Error())
+			continue
+		}
+
+		expect, err := ioutil.ReadFile(c.resultPath)
+		if err != nil {
+			t.Errorf("case %d error reading result data file: %s", i, err.Error())
+			continue
+		}
+		if got := srbuf.Bytes(); !bytes.Equal(expect, got) {
+			t.Errorf("case %d result mismatch. expected:\n%s\ngot:\n%s", i, string(expect), string(got))
+		}
+
+		if err := srbuf.Close(); err != nil {
+			t.Errorf("case %d error cleaning up buffer: %s", i, err.Error())
+		}
+	}
+}
+
+func TestStructuredRowBufferErrors(t *testing.T) {
+	tc, err := dstest.NewTestCaseFromDir("testdata/csv/movies")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewStructuredRowBuffer(tc.Input.Structure, func(cfg *StructuredRowBufferCfg) {
+		cfg.OrderBy = []string{"not_a_column"}
+	}); err == nil || err.Error() != "couldn't find sort column: not_a_column" {
+		t.Errorf("expected missing column error, got: %v", err)
+	}
+
+	srbuf, err := NewStructuredRowBuffer(tc.Input.Structure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srbuf.ReadEntry(); err == nil {
+		t.Errorf("expected reading from an open buffer to error")
+	}
+	if err := srbuf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := srbuf.WriteEntry(Entry{Value: []interface{}{"a", 1}}); err == nil {
+		t.Errorf("expected writing to a closed buffer to error")
+	}
+}
+
+func TestStructuredRowBufferSpillCleanup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestStructuredRowBufferSpillCleanup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tc, err := dstest.NewTestCaseFromDir("testdata/csv/movies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srbuf, err := NewStructuredRowBuffer(tc.Input.Structure, func(cfg *StructuredRowBufferCfg) {
+		cfg.OrderBy = []string{"movie_title"}
+		cfg.MaxMemoryBytes = 1024
+		cfg.TempDir = dir
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := NewEntryReader(tc.Input.Structure, tc.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Copy(rr, srbuf); err != nil {
+		t.Fatal(err)
+	}
+	if err := srbuf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fis, _ := ioutil.ReadDir(dir); len(fis) == 0 {
+		t.Errorf("expected sorted runs to be spilled to disk")
+	}
+
+	count := 0
+	if err := EachEntry(srbuf, func(i int, ent Entry, err error) error {
+		if ent.Index != i {
+			t.Errorf("entry index mismatch. expected: %d, got: %d", i, ent.Index)
+		}
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Errorf("expected entries to be read")
+	}
+
+	if fis, _ := ioutil.ReadDir(dir); len(fis) != 0 {
+		t.Errorf("expected temp files to be removed after reading, found %d", len(fis))
+	}
+}
+
+func TestStructuredRowBufferUniqueOrdered(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "json",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "name", "type": "string"},
+					map[string]interface{}{"title": "n", "type": "integer"},
+				},
+			},
+		},
+	}
+	// duplicates that aren't adjacent in write order, across spilled runs
+	rows := []interface{}{
+		[]interface{}{"b", 1}, []interface{}{"a", 2}, []interface{}{"a", 1},
+		[]interface{}{"b", 1}, []interface{}{"a", 2}, []interface{}{"c", 1},
+	}
+
+	cases := []struct {
+		desc   bool
+		maxMem int
+		expect string
+	}{
+		{false, DefaultMaxMemoryBytes, `[["a",1],["b",1],["c",1],["a",2]]`},
+		{false, 1, `[["a",1],["b",1],["c",1],["a",2]]`},
+		{true, 1, `[["a",2],["c",1],["b",1],["a",1]]`},
+	}
+	for i, c := range cases {
+		srbuf, err := NewStructuredRowBuffer(st, func(cfg *StructuredRowBufferCfg) {
+			cfg.OrderBy = []string{"n"}
+			cfg.OrderByDesc = c.desc
+			cfg.Unique = true
+			cfg.MaxMemoryBytes = c.maxMem
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			if err := srbuf.WriteEntry(Entry{Value: row}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := srbuf.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(srbuf.Bytes()); got != c.expect {
+			t.Errorf("case %d result mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+		srbuf.Close()
+	}
+}
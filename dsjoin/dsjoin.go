@@ -0,0 +1,292 @@
+// Package dsjoin combines the bodies of two datasets by matching values of
+// key columns. Joins read from two dsio.EntryReaders & produce a tabular
+// EntryReader whose schema merges the columns of both inputs:
+//
+//	r, err := dsjoin.Join(codes, labels, func(cfg *dsjoin.Config) {
+//		cfg.LeftOn = []string{"code"}
+//		cfg.Type = dsjoin.Left
+//	})
+//
+// Key columns refer to schema titles of tabular data, or property keys of
+// arrays of objects. Rows with null key values never match
+package dsjoin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	logger "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+)
+
+var log = logger.Logger("dsjoin")
+
+// Type enumerates the kinds of join
+type Type string
+
+const (
+	// Inner joins only emit rows with matching keys on both sides
+	Inner Type = "inner"
+	// Left joins emit every left row, with empty right columns when no right
+	// row matches
+	Left Type = "left"
+	// FullOuter joins emit every row from both sides, matched where possible
+	FullOuter Type = "full"
+)
+
+// Algorithm is a strategy for matching rows
+type Algorithm string
+
+const (
+	// Hash joins load the right side into an in-memory lookup table & stream
+	// the left side, preserving left row order. Hash joins are best suited
+	// to joining against a small lookup dataset
+	Hash Algorithm = "hash"
+	// SortMerge joins sort both sides by key, spilling to disk when needed,
+	// then merge. Output is ordered by key. Sort-merge joins suit inputs
+	// that don't fit in memory
+	SortMerge Algorithm = "sort-merge"
+)
+
+// Config configures a join
+type Config struct {
+	// Type is the kind of join to perform, defaults to Inner
+	Type Type
+	// Algorithm is the join strategy, defaults to Hash
+	Algorithm Algorithm
+	// LeftOn lists key columns of the left input
+	LeftOn []string
+	// RightOn lists key columns of the right input, defaults to LeftOn
+	RightOn []string
+	// RightSuffix is appended to titles of right columns that conflict with
+	// a left column title. defaults to "_right"
+	RightSuffix string
+	// MaxMemoryBytes caps memory used by each side of a sort-merge join
+	// before spilling to disk. defaults to dsio.DefaultMaxMemoryBytes
+	MaxMemoryBytes int
+	// TempDir is the directory sort-merge joins spill to, defaults to the
+	// system temp directory
+	TempDir string
+}
+
+// Join combines entries of left & right readers into a tabular reader. Output
+// rows consist of all left columns followed by right columns that aren't keys.
+// For full outer joins key columns are filled from the right side when no
+// left row matched
+func Join(left, right dsio.EntryReader, configs ...func(cfg *Config)) (dsio.EntryReader, error) {
+	cfg := &Config{
+		Type:           Inner,
+		Algorithm:      Hash,
+		RightSuffix:    "_right",
+		MaxMemoryBytes: dsio.DefaultMaxMemoryBytes,
+	}
+	for _, config := range configs {
+		config(cfg)
+	}
+	if len(cfg.RightOn) == 0 {
+		cfg.RightOn = cfg.LeftOn
+	}
+
+	switch cfg.Type {
+	case Inner, Left, FullOuter:
+	default:
+		return nil, fmt.Errorf("invalid join type: '%s'", cfg.Type)
+	}
+
+	l, err := newLayout(left.Structure(), right.Structure(), cfg)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	switch cfg.Algorithm {
+	case Hash:
+		return newHashJoin(l, left, right, cfg)
+	case SortMerge:
+		return newMergeJoin(l, left, right, cfg)
+	default:
+		return nil, fmt.Errorf("invalid join algorithm: '%s'", cfg.Algorithm)
+	}
+}
+
+// layout describes how input columns map to output rows
+type layout struct {
+	left      []dsio.Column
+	leftKeys  []dsio.Column
+	rightKeys []dsio.Column
+	rightOut  []dsio.Column
+	keyTypes  []vals.Type
+	// keyPos maps positions in left to key indexes, -1 for non-key columns
+	keyPos []int
+	st     *dataset.Structure
+}
+
+func newLayout(lst, rst *dataset.Structure, cfg *Config) (*layout, error) {
+	if len(cfg.LeftOn) == 0 {
+		return nil, fmt.Errorf("at least one key column is required")
+	}
+	if len(cfg.LeftOn) != len(cfg.RightOn) {
+		return nil, fmt.Errorf("left and right key column counts must match")
+	}
+
+	l := &layout{}
+	var err error
+	if l.left, err = dsio.Columns(lst); err != nil {
+		return nil, fmt.Errorf("left: %s", err.Error())
+	}
+	if l.leftKeys, err = dsio.FindColumns(lst, cfg.LeftOn...); err != nil {
+		return nil, fmt.Errorf("left: %s", err.Error())
+	}
+	right, err := dsio.Columns(rst)
+	if err != nil {
+		return nil, fmt.Errorf("right: %s", err.Error())
+	}
+	if l.rightKeys, err = dsio.FindColumns(rst, cfg.RightOn...); err != nil {
+		return nil, fmt.Errorf("right: %s", err.Error())
+	}
+
+	l.keyTypes = make([]vals.Type, len(l.leftKeys))
+	for i := range l.leftKeys {
+		l.keyTypes[i] = keyType(l.leftKeys[i].Type, l.rightKeys[i].Type)
+	}
+
+	titles := map[string]bool{}
+	items := make([]interface{}, 0, len(l.left)+len(right))
+	l.keyPos = make([]int, len(l.left))
+	for i, col := range l.left {
+		l.keyPos[i] = -1
+		for k, key := range l.leftKeys {
+			if key.Title == col.Title {
+				l.keyPos[i] = k
+			}
+		}
+		titles[col.Title] = true
+		items = append(items, schemaColumn(col.Title, col.Type))
+	}
+
+RIGHT:
+	for _, col := range right {
+		for _, key := range l.rightKeys {
+			if key.Title == col.Title {
+				continue RIGHT
+			}
+		}
+		title := col.Title
+		for titles[title] {
+			title += cfg.RightSuffix
+			if cfg.RightSuffix == "" {
+				return nil, fmt.Errorf("duplicate column title: %s", col.Title)
+			}
+		}
+		titles[title] = true
+		l.rightOut = append(l.rightOut, col)
+		items = append(items, schemaColumn(title, col.Type))
+	}
+
+	l.st = &dataset.Structure{
+		Format: dataset.JSONDataFormat.String(),
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": items,
+			},
+		},
+	}
+	return l, nil
+}
+
+// keyType picks a type that orders key values of both sides consistently
+func keyType(l, r vals.Type) vals.Type {
+	if l == r && (l == vals.TypeString || l == vals.TypeInteger || l == vals.TypeNumber) {
+		return l
+	}
+	if (l == vals.TypeInteger || l == vals.TypeNumber) && (r == vals.TypeInteger || r == vals.TypeNumber) {
+		return vals.TypeNumber
+	}
+	return vals.TypeString
+}
+
+func schemaColumn(title string, t vals.Type) map[string]interface{} {
+	col := map[string]interface{}{"title": title}
+	if t != vals.TypeUnknown {
+		col["type"] = t.String()
+	}
+	return col
+}
+
+// row constructs an output row. hasLeft & hasRight indicate which side of
+// the join is present
+func (l *layout) row(lv, rv interface{}, hasLeft, hasRight bool) []interface{} {
+	row := make([]interface{}, 0, len(l.left)+len(l.rightOut))
+	for i, col := range l.left {
+		switch {
+		case hasLeft:
+			row = append(row, col.Value(lv))
+		case hasRight && l.keyPos[i] >= 0:
+			row = append(row, l.rightKeys[l.keyPos[i]].Value(rv))
+		default:
+			row = append(row, nil)
+		}
+	}
+	for _, col := range l.rightOut {
+		if hasRight {
+			row = append(row, col.Value(rv))
+		} else {
+			row = append(row, nil)
+		}
+	}
+	return row
+}
+
+// keys extracts key values from an entry value, returning false if any
+// key is null
+func keys(cols []dsio.Column, v interface{}) ([]interface{}, bool) {
+	ks := make([]interface{}, len(cols))
+	for i, col := range cols {
+		if ks[i] = col.Value(v); ks[i] == nil {
+			return ks, false
+		}
+	}
+	return ks, true
+}
+
+// hashKey creates a lookup key for a set of key values. Values are
+// normalized for their key type, so key values that compareKeys finds equal
+// share a hash key
+func (l *layout) hashKey(ks []interface{}) string {
+	strs := make([]string, len(ks))
+	for i, k := range ks {
+		data := dsio.ValueBytes(k)
+		switch l.keyTypes[i] {
+		case vals.TypeInteger:
+			if n, err := vals.ParseInteger(data); err == nil {
+				data = []byte(strconv.FormatInt(n, 10))
+			}
+		case vals.TypeNumber:
+			if f, err := vals.ParseNumber(data); err == nil {
+				if f == 0 {
+					// -0 and 0 are equal
+					f = 0
+				}
+				data = []byte(strconv.FormatFloat(f, 'g', -1, 64))
+			}
+		}
+		strs[i] = string(data)
+	}
+	return strings.Join(strs, "\x00")
+}
+
+// compareKeys orders two sets of key values. Both join algorithms match
+// rows with this comparison
+func (l *layout) compareKeys(a, b []interface{}) int {
+	for i := range a {
+		if c := dsio.CompareValues(a[i], b[i], l.keyTypes[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
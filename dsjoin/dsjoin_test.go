@@ -0,0 +1,217 @@
+package dsjoin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func tabular(cols ...string) *dataset.Structure {
+	items := make([]interface{}, len(cols))
+	for i, col := range cols {
+		parts := strings.Split(col, ":")
+		items[i] = map[string]interface{}{"title": parts[0], "type": parts[1]}
+	}
+	return &dataset.Structure{
+		Format: "json",
+		Schema: map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "array", "items": items},
+		},
+	}
+}
+
+var (
+	ordersSt   = tabular("id:integer", "code:string", "qty:integer")
+	ordersBody = `[[1,"a",10],[2,"b",20],[3,"z",30],[4,"a",40],[5,null,50]]`
+
+	labelsSt   = tabular("code:string", "label:string", "qty:integer")
+	labelsBody = `[["a","apple",1],["b","banana",2],["c","cherry",3],["b","blueberry",4],[null,"nothing",5]]`
+
+	labelObjectsSt = &dataset.Structure{
+		Format: "json",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"code":  map[string]interface{}{"type": "string"},
+					"label": map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+	}
+	labelObjectsBody = `[{"code":"a","label":"apple"},{"code":"c","label":"cherry"}]`
+)
+
+func TestJoin(t *testing.T) {
+	cases := []struct {
+		description string
+		rightSt     *dataset.Structure
+		rightBody   string
+		typ         Type
+		titles      []string
+		expect      string
+		// sortedExpect is the expected output of sort-merge joins, if it
+		// differs from hash join output order
+		sortedExpect string
+	}{
+		{"inner", labelsSt, labelsBody, Inner,
+			[]string{"id", "code", "qty", "label", "qty_right"},
+			`[[1,"a",10,"apple",1],[2,"b",20,"banana",2],[2,"b",20,"blueberry",4],[4,"a",40,"apple",1]]`,
+			`[[1,"a",10,"apple",1],[4,"a",40,"apple",1],[2,"b",20,"banana",2],[2,"b",20,"blueberry",4]]`,
+		},
+		{"left", labelsSt, labelsBody, Left,
+			[]string{"id", "code", "qty", "label", "qty_right"},
+			`[[1,"a",10,"apple",1],[2,"b",20,"banana",2],[2,"b",20,"blueberry",4],[3,"z",30,null,null],[4,"a",40,"apple",1],[5,null,50,null,null]]`,
+			`[[5,null,50,null,null],[1,"a",10,"apple",1],[4,"a",40,"apple",1],[2,"b",20,"banana",2],[2,"b",20,"blueberry",4],[3,"z",30,null,null]]`,
+		},
+		{"full outer", labelsSt, labelsBody, FullOuter,
+			[]string{"id", "code", "qty", "label", "qty_right"},
+			`[[1,"a",10,"apple",1],[2,"b",20,"banana",2],[2,"b",20,"blueberry",4],[3,"z",30,null,null],[4,"a",40,"apple",1],[5,null,50,null,null],[null,"c",null,"cherry",3],[null,null,null,"nothing",5]]`,
+			`[[5,null,50,null,null],[null,null,null,"nothing",5],[1,"a",10,"apple",1],[4,"a",40,"apple",1],[2,"b",20,"banana",2],[2,"b",20,"blueberry",4],[null,"c",null,"cherry",3],[3,"z",30,null,null]]`,
+		},
+		{"object keys", labelObjectsSt, labelObjectsBody, Left,
+			[]string{"id", "code", "qty", "label"},
+			`[[1,"a",10,"apple"],[2,"b",20,null],[3,"z",30,null],[4,"a",40,"apple"],[5,null,50,null]]`,
+			`[[5,null,50,null],[1,"a",10,"apple"],[4,"a",40,"apple"],[2,"b",20,null],[3,"z",30,null]]`,
+		},
+	}
+
+	for _, c := range cases {
+		for _, alg := range []Algorithm{Hash, SortMerge} {
+			t.Run(c.description+" "+string(alg), func(t *testing.T) {
+				left, err := dsio.NewJSONReader(ordersSt, strings.NewReader(ordersBody))
+				if err != nil {
+					t.Fatal(err)
+				}
+				right, err := dsio.NewJSONReader(c.rightSt, strings.NewReader(c.rightBody))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				r, err := Join(left, right, func(cfg *Config) {
+					cfg.LeftOn = []string{"code"}
+					cfg.Type = c.typ
+					cfg.Algorithm = alg
+					// force sort-merge joins to spill
+					cfg.MaxMemoryBytes = 128
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer r.Close()
+
+				cols, err := dsio.Columns(r.Structure())
+				if err != nil {
+					t.Fatal(err)
+				}
+				titles := make([]string, len(cols))
+				for i, col := range cols {
+					titles[i] = col.Title
+				}
+				if diff := cmp.Diff(c.titles, titles); diff != "" {
+					t.Errorf("output column mismatch (-want +got):\n%s", diff)
+				}
+
+				rows := []interface{}{}
+				if err := dsio.EachEntry(r, func(i int, ent dsio.Entry, err error) error {
+					if ent.Index != i {
+						t.Errorf("index mismatch. expected: %d, got: %d", i, ent.Index)
+					}
+					rows = append(rows, ent.Value)
+					return nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+				got, err := json.Marshal(rows)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				expect := c.expect
+				if alg == SortMerge {
+					expect = c.sortedExpect
+				}
+				if expect != string(got) {
+					t.Errorf("result mismatch.\nwant: %s\ngot:  %s", expect, string(got))
+				}
+			})
+		}
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	cases := []struct {
+		cfg func(cfg *Config)
+		err string
+	}{
+		{func(cfg *Config) {}, "at least one key column is required"},
+		{func(cfg *Config) { cfg.LeftOn = []string{"code"}; cfg.Type = "outer" }, "invalid join type: 'outer'"},
+		{func(cfg *Config) { cfg.LeftOn = []string{"code"}; cfg.Algorithm = "nested" }, "invalid join algorithm: 'nested'"},
+		{func(cfg *Config) { cfg.LeftOn = []string{"missing"} }, "left: couldn't find column: missing"},
+		{func(cfg *Config) { cfg.LeftOn = []string{"id"} }, "right: couldn't find column: id"},
+		{func(cfg *Config) {
+			cfg.LeftOn = []string{"code"}
+			cfg.RightOn = []string{"code", "label"}
+		}, "left and right key column counts must match"},
+		{func(cfg *Config) { cfg.LeftOn = []string{"code"}; cfg.RightSuffix = "" }, "duplicate column title: qty"},
+	}
+
+	for i, c := range cases {
+		left, _ := dsio.NewJSONReader(ordersSt, strings.NewReader(ordersBody))
+		right, _ := dsio.NewJSONReader(labelsSt, strings.NewReader(labelsBody))
+		_, err := Join(left, right, c.cfg)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+		}
+	}
+}
+
+func TestJoinKeyEquality(t *testing.T) {
+	// key values with different encodings of the same integer match with
+	// either algorithm
+	leftSt := tabular("code:integer", "name:string")
+	leftBody := `[["007","a"],[8,"b"],[9,"c"]]`
+	rightSt := tabular("code:integer", "label:string")
+	rightBody := `[[7,"seven"],["08","eight"]]`
+	expect := `[["007","a","seven"],[8,"b","eight"]]`
+
+	for _, alg := range []Algorithm{Hash, SortMerge} {
+		left, err := dsio.NewJSONReader(leftSt, strings.NewReader(leftBody))
+		if err != nil {
+			t.Fatal(err)
+		}
+		right, err := dsio.NewJSONReader(rightSt, strings.NewReader(rightBody))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := Join(left, right, func(cfg *Config) {
+			cfg.LeftOn = []string{"code"}
+			cfg.Algorithm = alg
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows := []interface{}{}
+		if err := dsio.EachEntry(r, func(i int, ent dsio.Entry, err error) error {
+			rows = append(rows, ent.Value)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+		got, err := json.Marshal(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect != string(got) {
+			t.Errorf("%s result mismatch.\nwant: %s\ngot:  %s", alg, expect, string(got))
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package dsjoin
+
+import (
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// hashJoin streams left entries, matching against a lookup table built from
+// all right entries
+type hashJoin struct {
+	l       *layout
+	typ     Type
+	left    dsio.EntryReader
+	table   map[string][]int
+	rights  []interface{}
+	rkeys   [][]interface{}
+	matched []bool
+
+	pending  [][]interface{}
+	leftDone bool
+	pos      int
+	index    int
+}
+
+var _ dsio.EntryReader = (*hashJoin)(nil)
+
+func newHashJoin(l *layout, left, right dsio.EntryReader, cfg *Config) (*hashJoin, error) {
+	j := &hashJoin{
+		l:     l,
+		typ:   cfg.Type,
+		left:  left,
+		table: map[string][]int{},
+	}
+
+	err := dsio.EachEntry(right, func(_ int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		i := len(j.rights)
+		ks, ok := keys(l.rightKeys, ent.Value)
+		j.rights = append(j.rights, ent.Value)
+		j.rkeys = append(j.rkeys, ks)
+		if ok {
+			key := l.hashKey(ks)
+			j.table[key] = append(j.table[key], i)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Debug(err.Error())
+		right.Close()
+		return nil, err
+	}
+	j.matched = make([]bool, len(j.rights))
+	return j, right.Close()
+}
+
+// Structure gives the structure of joined rows
+func (j *hashJoin) Structure() *dataset.Structure {
+	return j.l.st
+}
+
+// ReadEntry reads one joined row
+func (j *hashJoin) ReadEntry() (dsio.Entry, error) {
+	for len(j.pending) == 0 {
+		if j.leftDone {
+			return j.readUnmatchedRight()
+		}
+
+		ent, err := j.left.ReadEntry()
+		if err == io.EOF {
+			j.leftDone = true
+			continue
+		} else if err != nil {
+			return dsio.Entry{}, err
+		}
+
+		matched := false
+		if ks, ok := keys(j.l.leftKeys, ent.Value); ok {
+			for _, i := range j.table[j.l.hashKey(ks)] {
+				if j.l.compareKeys(ks, j.rkeys[i]) != 0 {
+					continue
+				}
+				matched = true
+				j.matched[i] = true
+				j.pending = append(j.pending, j.l.row(ent.Value, j.rights[i], true, true))
+			}
+		}
+		if !matched && j.typ != Inner {
+			j.pending = append(j.pending, j.l.row(ent.Value, nil, true, false))
+		}
+	}
+
+	row := j.pending[0]
+	j.pending = j.pending[1:]
+	return j.entry(row), nil
+}
+
+// readUnmatchedRight emits right rows that never matched, which only full
+// outer joins include
+func (j *hashJoin) readUnmatchedRight() (dsio.Entry, error) {
+	if j.typ != FullOuter {
+		return dsio.Entry{}, io.EOF
+	}
+	for ; j.pos < len(j.rights); j.pos++ {
+		if !j.matched[j.pos] {
+			row := j.l.row(nil, j.rights[j.pos], false, true)
+			j.pos++
+			return j.entry(row), nil
+		}
+	}
+	return dsio.Entry{}, io.EOF
+}
+
+func (j *hashJoin) entry(row []interface{}) dsio.Entry {
+	ent := dsio.Entry{Index: j.index, Value: row}
+	j.index++
+	return ent
+}
+
+// Close finalizes the join, closing the left reader
+func (j *hashJoin) Close() error {
+	return j.left.Close()
+}
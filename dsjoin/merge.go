@@ -0,0 +1,230 @@
+package dsjoin
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+)
+
+// mergeJoin sorts both inputs by key & walks them in step
+type mergeJoin struct {
+	l     *layout
+	typ   Type
+	left  *dsio.StructuredRowBuffer
+	right *dsio.StructuredRowBuffer
+
+	lnext   *sideRow
+	rnext   *sideRow
+	pending [][]interface{}
+	index   int
+}
+
+var _ dsio.EntryReader = (*mergeJoin)(nil)
+
+// sideRow is one input entry with extracted key values
+type sideRow struct {
+	keys  []interface{}
+	valid bool
+	value interface{}
+}
+
+func newMergeJoin(l *layout, left, right dsio.EntryReader, cfg *Config) (_ *mergeJoin, err error) {
+	j := &mergeJoin{l: l, typ: cfg.Type}
+	defer func() {
+		if err != nil {
+			log.Debug(err.Error())
+			j.Close()
+		}
+	}()
+
+	if j.left, err = sortSide(l, l.leftKeys, left, cfg); err != nil {
+		right.Close()
+		return nil, fmt.Errorf("sorting left: %s", err.Error())
+	}
+	if j.right, err = sortSide(l, l.rightKeys, right, cfg); err != nil {
+		return nil, fmt.Errorf("sorting right: %s", err.Error())
+	}
+
+	if j.lnext, err = readSide(j.left); err != nil {
+		return nil, err
+	}
+	if j.rnext, err = readSide(j.right); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// sortSide writes all entries of r to a buffer sorted by key. Buffered rows
+// are prefixed with key values, which are sorted using the key types both
+// sides share. r is closed once all entries are read
+func sortSide(l *layout, cols []dsio.Column, r dsio.EntryReader, cfg *Config) (buf *dsio.StructuredRowBuffer, err error) {
+	defer func() {
+		if e := r.Close(); e != nil && err == nil {
+			err = e
+		}
+		if err != nil && buf != nil {
+			// first close finalizes the buffer, second removes temp files
+			buf.Close()
+			buf.Close()
+			buf = nil
+		}
+	}()
+
+	items := make([]interface{}, 0, len(cols)+1)
+	orderBy := make([]string, len(cols))
+	for i, t := range l.keyTypes {
+		orderBy[i] = fmt.Sprintf("key_%d", i)
+		items = append(items, schemaColumn(orderBy[i], t))
+	}
+	items = append(items, schemaColumn("value", vals.TypeUnknown))
+	st := &dataset.Structure{
+		Format: dataset.JSONDataFormat.String(),
+		Schema: map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "array", "items": items},
+		},
+	}
+
+	buf, err = dsio.NewStructuredRowBuffer(st, func(o *dsio.StructuredRowBufferCfg) {
+		o.OrderBy = orderBy
+		o.MaxMemoryBytes = cfg.MaxMemoryBytes
+		o.TempDir = cfg.TempDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = dsio.EachEntry(r, func(_ int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		ks, _ := keys(cols, ent.Value)
+		return buf.WriteEntry(dsio.Entry{Value: append(ks, ent.Value)})
+	})
+	if err != nil {
+		return buf, err
+	}
+	return buf, buf.Close()
+}
+
+// readSide reads the next row from a sorted side, returning nil at the end
+func readSide(buf *dsio.StructuredRowBuffer) (*sideRow, error) {
+	ent, err := buf.ReadEntry()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	arr := ent.Value.([]interface{})
+	row := &sideRow{keys: arr[:len(arr)-1], value: arr[len(arr)-1], valid: true}
+	for _, k := range row.keys {
+		if k == nil {
+			row.valid = false
+		}
+	}
+	return row, nil
+}
+
+// Structure gives the structure of joined rows
+func (j *mergeJoin) Structure() *dataset.Structure {
+	return j.l.st
+}
+
+// ReadEntry reads one joined row
+func (j *mergeJoin) ReadEntry() (dsio.Entry, error) {
+	for len(j.pending) == 0 {
+		if j.lnext == nil && j.rnext == nil {
+			return dsio.Entry{}, io.EOF
+		}
+		if err := j.step(); err != nil {
+			return dsio.Entry{}, err
+		}
+	}
+
+	ent := dsio.Entry{Index: j.index, Value: j.pending[0]}
+	j.pending = j.pending[1:]
+	j.index++
+	return ent, nil
+}
+
+// step advances at least one side of the join, adding any output rows
+// to pending
+func (j *mergeJoin) step() (err error) {
+	switch {
+	case j.lnext != nil && !j.lnext.valid:
+		return j.unmatchedLeft()
+	case j.rnext != nil && !j.rnext.valid:
+		return j.unmatchedRight()
+	case j.rnext == nil:
+		return j.unmatchedLeft()
+	case j.lnext == nil:
+		return j.unmatchedRight()
+	}
+
+	c := j.l.compareKeys(j.lnext.keys, j.rnext.keys)
+	if c < 0 {
+		return j.unmatchedLeft()
+	} else if c > 0 {
+		return j.unmatchedRight()
+	}
+
+	// collect all right rows sharing this key
+	key := j.rnext.keys
+	var group []*sideRow
+	for j.rnext != nil && j.l.compareKeys(key, j.rnext.keys) == 0 {
+		if j.rnext.valid {
+			group = append(group, j.rnext)
+		} else if j.typ == FullOuter {
+			j.pending = append(j.pending, j.l.row(nil, j.rnext.value, false, true))
+		}
+		if j.rnext, err = readSide(j.right); err != nil {
+			return err
+		}
+	}
+
+	for j.lnext != nil && j.l.compareKeys(key, j.lnext.keys) == 0 {
+		if j.lnext.valid {
+			for _, r := range group {
+				j.pending = append(j.pending, j.l.row(j.lnext.value, r.value, true, true))
+			}
+		} else if j.typ != Inner {
+			j.pending = append(j.pending, j.l.row(j.lnext.value, nil, true, false))
+		}
+		if j.lnext, err = readSide(j.left); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *mergeJoin) unmatchedLeft() (err error) {
+	if j.typ != Inner {
+		j.pending = append(j.pending, j.l.row(j.lnext.value, nil, true, false))
+	}
+	j.lnext, err = readSide(j.left)
+	return err
+}
+
+func (j *mergeJoin) unmatchedRight() (err error) {
+	if j.typ == FullOuter {
+		j.pending = append(j.pending, j.l.row(nil, j.rnext.value, false, true))
+	}
+	j.rnext, err = readSide(j.right)
+	return err
+}
+
+// Close finalizes the join, removing any temporary files
+func (j *mergeJoin) Close() (err error) {
+	for _, buf := range []*dsio.StructuredRowBuffer{j.left, j.right} {
+		if buf == nil {
+			continue
+		}
+		if e := buf.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+/*
+Package dsreadme renders the readme component of a dataset, returning a
+qfs.File of sanitised HTML
+
+Readme scripts are markdown documents that are first executed as go
+text/template templates, then converted to HTML. Readme templates share the
+dsviz template function set, making it possible to embed live data previews
+in a readme:
+
+	# {{ ds.meta.title }}
+
+	{{ bodyEntries 0 10 }}
+
+	{{ structureSummary }}
+
+	functions (in addition to those provided by dsviz):
+		{{ bodyEntries offset limit }}
+			get body entries within an offset/limit range. entries print as a
+			markdown table, range over .Rows to work with values directly
+		{{ allBodyEntries }}
+			load the full dataset body, printing as a markdown table
+		{{ structureSummary }}
+			markdown summary of the dataset structure: format, entry count, size,
+			and a table of fields for tabular data
+
+Generate creates a starter readme for datasets that don't have one, writing
+meta details and a structure summary as markdown, followed by a live preview
+of the first few body entries
+
+The resulting HTML is sanitised to remove scripts, event handlers and other
+unsafe content, so rendered readmes are safe to embed in other pages
+*/
+package dsreadme
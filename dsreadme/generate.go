@@ -0,0 +1,125 @@
+package dsreadme
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+// ScriptFilename is the name given to generated readme script files
+const ScriptFilename = "readme.md"
+
+// PreviewEntries is the number of body entries generated readmes preview
+const PreviewEntries = 10
+
+// Generate creates a starter markdown readme from a dataset's meta and
+// structure components. Meta values and the structure summary are written
+// into the readme as text, followed by a live preview of the first
+// PreviewEntries body entries that updates each time the readme is rendered
+func Generate(ds *dataset.Dataset) (*dataset.Readme, error) {
+	if ds.Meta == nil && ds.Structure == nil {
+		return nil, fmt.Errorf("dataset has no meta or structure component")
+	}
+
+	b := &strings.Builder{}
+	md := ds.Meta
+	if md == nil {
+		md = &dataset.Meta{}
+	}
+
+	title := md.Title
+	if title == "" {
+		title = fmt.Sprintf("%s/%s", ds.Peername, ds.Name)
+	}
+	fmt.Fprintf(b, "# %s\n", escapeTemplate(title))
+	if md.Description != "" {
+		fmt.Fprintf(b, "\n%s\n", escapeTemplate(md.Description))
+	}
+
+	details := &strings.Builder{}
+	if md.HomeURL != "" {
+		fmt.Fprintf(details, "- **homepage:** <%s>\n", escapeTemplate(md.HomeURL))
+	}
+	if md.License != nil && (md.License.Type != "" || md.License.URL != "") {
+		fmt.Fprintf(details, "- **license:** %s\n", link(md.License.Type, md.License.URL))
+	}
+	if len(md.Keywords) > 0 {
+		fmt.Fprintf(details, "- **keywords:** %s\n", escapeTemplate(strings.Join(md.Keywords, ", ")))
+	}
+	if len(md.Theme) > 0 {
+		fmt.Fprintf(details, "- **themes:** %s\n", escapeTemplate(strings.Join(md.Theme, ", ")))
+	}
+	if md.Version != "" {
+		fmt.Fprintf(details, "- **version:** %s\n", escapeTemplate(md.Version))
+	}
+	if md.AccrualPeriodicity != "" {
+		fmt.Fprintf(details, "- **update frequency:** %s\n", escapeTemplate(md.AccrualPeriodicity))
+	}
+	if details.Len() > 0 {
+		fmt.Fprintf(b, "\n%s", details.String())
+	}
+
+	if ds.Structure != nil {
+		summary, err := structureSummary(ds.Structure)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(b, "\n## Structure\n\n%s", escapeTemplate(summary))
+		fmt.Fprintf(b, "\n## Preview\n\n{{ bodyEntries 0 %d }}\n", PreviewEntries)
+	}
+
+	if len(md.Citations) > 0 {
+		b.WriteString("\n## Sources\n\n")
+		for _, c := range md.Citations {
+			if c != nil {
+				fmt.Fprintf(b, "- %s\n", link(c.Name, c.URL))
+			}
+		}
+	}
+	if len(md.Contributors) > 0 {
+		b.WriteString("\n## Contributors\n\n")
+		for _, u := range md.Contributors {
+			if u == nil {
+				continue
+			}
+			name := u.Fullname
+			if name == "" {
+				name = u.ID
+			}
+			if u.Email != "" {
+				fmt.Fprintf(b, "- %s <%s>\n", escapeTemplate(name), escapeTemplate(u.Email))
+			} else {
+				fmt.Fprintf(b, "- %s\n", escapeTemplate(name))
+			}
+		}
+	}
+
+	rm := &dataset.Readme{
+		Qri:         dataset.KindReadme.String(),
+		Format:      "md",
+		ScriptBytes: []byte(b.String()),
+	}
+	rm.SetScriptFile(qfs.NewMemfileBytes(ScriptFilename, rm.ScriptBytes))
+	return rm, nil
+}
+
+// link writes a markdown link, falling back to plain text when either the
+// text or url is missing
+func link(text, url string) string {
+	text, url = escapeTemplate(text), escapeTemplate(url)
+	switch {
+	case url == "":
+		return text
+	case text == "":
+		return fmt.Sprintf("<%s>", url)
+	}
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// escapeTemplate quotes template delimiters in text so generated readmes
+// render it literally
+func escapeTemplate(s string) string {
+	return strings.Replace(s, "{{", `{{ "{{" }}`, -1)
+}
@@ -0,0 +1,99 @@
+package dsreadme
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestGenerate(t *testing.T) {
+	if _, err := Generate(&dataset.Dataset{}); err == nil {
+		t.Error("expected dataset with no meta or structure to error")
+	}
+
+	ds := &dataset.Dataset{
+		Peername: "steve",
+		Name:     "world_pop",
+		Meta: &dataset.Meta{
+			Title:       "World Population",
+			Description: "population of the world, by `{{ year }}`",
+			HomeURL:     "https://example.com/pop",
+			License:     &dataset.License{Type: "CC0-1.0", URL: "https://creativecommons.org/publicdomain/zero/1.0/"},
+			Keywords:    []string{"population", "world"},
+			Citations:   []*dataset.Citation{{Name: "UN", URL: "https://un.org"}, {URL: "https://example.com/raw.csv"}},
+			Contributors: []*dataset.User{
+				{Fullname: "Steve", Email: "steve@example.com"},
+				{ID: "QmContributor"},
+			},
+		},
+		Structure: &dataset.Structure{
+			Format:  "json",
+			Entries: 3,
+			Length:  64,
+			Schema: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": []interface{}{
+						map[string]interface{}{"title": "year", "type": "integer", "description": "calendar year"},
+						map[string]interface{}{"title": "population", "type": "integer"},
+					},
+				},
+			},
+		},
+	}
+
+	rm, err := Generate(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rm.Format != "md" {
+		t.Errorf("expected format 'md', got: '%s'", rm.Format)
+	}
+
+	expect := "# World Population\n\n" +
+		"population of the world, by `{{ \"{{\" }} year }}`\n\n" +
+		"- **homepage:** <https://example.com/pop>\n" +
+		"- **license:** [CC0-1.0](https://creativecommons.org/publicdomain/zero/1.0/)\n" +
+		"- **keywords:** population, world\n" +
+		"\n## Structure\n\n" +
+		"- **format:** json\n" +
+		"- **entries:** 3\n" +
+		"- **size:** 64 bytes\n" +
+		"\n" +
+		"| field | type | description |\n" +
+		"| --- | --- | --- |\n" +
+		"| year | integer | calendar year |\n" +
+		"| population | integer |  |\n" +
+		"\n## Preview\n\n{{ bodyEntries 0 10 }}\n" +
+		"\n## Sources\n\n" +
+		"- [UN](https://un.org)\n" +
+		"- <https://example.com/raw.csv>\n" +
+		"\n## Contributors\n\n" +
+		"- Steve <steve@example.com>\n" +
+		"- QmContributor\n"
+	if string(rm.ScriptBytes) != expect {
+		t.Errorf("result mismatch. expected:\n%s\ngot:\n%s", expect, string(rm.ScriptBytes))
+	}
+
+	// generated readmes must render
+	ds.Readme = rm
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[[2000,6.1],[2010,6.9],[2020,7.8]]`)))
+	f, err := Render(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(html, []byte("<code>{{ year }}</code>")) {
+		t.Errorf("expected template delimiters in meta text to render literally. got:\n%s", string(html))
+	}
+	if !bytes.Contains(html, []byte("<td>2020</td>")) {
+		t.Errorf("expected body preview in rendered readme. got:\n%s", string(html))
+	}
+}
@@ -0,0 +1,295 @@
+package dsreadme
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	logger "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsviz"
+	"github.com/qri-io/qfs"
+)
+
+var log = logger.Logger("dsreadme")
+
+// RenderedFilename is the name given to rendered readme files
+const RenderedFilename = "readme.html"
+
+// Render executes the readme component of a dataset, returning sanitised HTML
+// and setting it as the readme's rendered file. The provided dataset must be
+// fully deserialized, with all files Opened. Render replaces any file readers
+// it consumes, making the dataset safe for reuse after calling render. Body
+// access is limited the same way as dsviz.Render
+func Render(ds *dataset.Dataset, configs ...func(cfg *dsviz.RenderCfg)) (qfs.File, error) {
+	if ds.Readme == nil {
+		return nil, fmt.Errorf("no readme component")
+	}
+	if ds.Readme.Format != "md" && ds.Readme.Format != "html" {
+		return nil, fmt.Errorf("render format must be 'md' or 'html'")
+	}
+	if ds.Readme.ScriptFile() == nil {
+		return nil, fmt.Errorf("readme has no script file")
+	}
+
+	data, err := renderTemplate(ds, configs)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+
+	if ds.Readme.Format == "md" {
+		if data, err = dsviz.Markdown(data); err != nil {
+			log.Debug(err.Error())
+			return nil, err
+		}
+	} else {
+		data = dsviz.Sanitize(data)
+	}
+
+	ds.Readme.SetRenderedFile(qfs.NewMemfileBytes(RenderedFilename, data))
+	return qfs.NewMemfileBytes(RenderedFilename, data), nil
+}
+
+func renderTemplate(ds *dataset.Dataset, configs []func(cfg *dsviz.RenderCfg)) ([]byte, error) {
+	script := ds.Readme.ScriptFile()
+	// tee the readme file to avoid losing script data
+	scriptBuf := &bytes.Buffer{}
+	tr := io.TeeReader(script, scriptBuf)
+
+	tmplBytes, err := ioutil.ReadAll(tr)
+	if err != nil {
+		return nil, fmt.Errorf("reading template data: %s", err.Error())
+	}
+
+	// restore consumed script file
+	ds.Readme.SetScriptFile(qfs.NewMemfileReader(script.FileName(), scriptBuf))
+
+	funcs, err := dsviz.NewTemplateFuncs(ds, configs...)
+	if err != nil {
+		return nil, err
+	}
+	defer funcs.Close()
+
+	tmpl, err := template.New(script.FileName()).Funcs(readmeFuncs(ds, funcs)).Parse(string(tmplBytes))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %s", err.Error())
+	}
+
+	buf := &bytes.Buffer{}
+	if err := funcs.Execute(tmpl, buf, ds); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readmeFuncs extends the dsviz template function set with body entry
+// functions that print as markdown tables and a structureSummary function
+func readmeFuncs(ds *dataset.Dataset, vizFuncs *dsviz.TemplateFuncs) template.FuncMap {
+	// bodyEntries is a stub when excluded by an allow-list, leave it in place
+	if bodyEntries, ok := vizFuncs.FuncMap["bodyEntries"].(func(int, int) (interface{}, error)); ok {
+		vizFuncs.Set("bodyEntries", func(offset, limit int) (interface{}, error) {
+			entries, err := bodyEntries(offset, limit)
+			if err != nil {
+				return nil, err
+			}
+			return newTable(ds.Structure, entries), nil
+		})
+		vizFuncs.Set("allBodyEntries", func() (interface{}, error) {
+			entries, err := bodyEntries(0, -1)
+			if err != nil {
+				return nil, err
+			}
+			return newTable(ds.Structure, entries), nil
+		})
+	}
+	vizFuncs.Set("structureSummary", func() (string, error) {
+		return structureSummary(ds.Structure)
+	})
+
+	return template.FuncMap(vizFuncs.FuncMap)
+}
+
+// Table is a set of body entries that prints as a markdown table. Templates
+// can range over Rows to work with entry values directly
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// newTable arranges body entries into rows. Array entries use column titles
+// from a tabular schema, object entries become key-value rows
+func newTable(st *dataset.Structure, entries interface{}) *Table {
+	t := &Table{}
+	switch e := entries.(type) {
+	case []interface{}:
+		t.Columns = columnTitles(st)
+		for _, ent := range e {
+			switch v := ent.(type) {
+			case []interface{}:
+				t.Rows = append(t.Rows, v)
+			default:
+				t.Rows = append(t.Rows, []interface{}{v})
+			}
+		}
+	case map[string]interface{}:
+		t.Columns = []string{"key", "value"}
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			t.Rows = append(t.Rows, []interface{}{key, e[key]})
+		}
+	}
+	return t
+}
+
+// String renders the table as markdown
+func (t *Table) String() string {
+	width := len(t.Columns)
+	for _, row := range t.Rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	if width == 0 {
+		return ""
+	}
+
+	header := make([]string, width)
+	for i := range header {
+		if i < len(t.Columns) && t.Columns[i] != "" {
+			header[i] = escapeCell(t.Columns[i])
+		} else {
+			header[i] = fmt.Sprintf("field_%d", i+1)
+		}
+	}
+
+	b := &strings.Builder{}
+	writeRow(b, header)
+	sep := make([]string, width)
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeRow(b, sep)
+	for _, row := range t.Rows {
+		cells := make([]string, width)
+		for i, v := range row {
+			cells[i] = escapeCell(cellString(v))
+		}
+		writeRow(b, cells)
+	}
+	return b.String()
+}
+
+func writeRow(b *strings.Builder, cells []string) {
+	b.WriteString("| ")
+	b.WriteString(strings.Join(cells, " | "))
+	b.WriteString(" |\n")
+}
+
+func cellString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(x)
+		if err != nil {
+			return fmt.Sprintf("%v", x)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+// escapeCell keeps a value on a single table cell
+func escapeCell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	s = strings.Replace(s, "\r\n", " ", -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+// columnTitles gets column titles from a tabular schema, returning nil for
+// non-tabular schemas
+func columnTitles(st *dataset.Structure) []string {
+	cols := tabularColumns(st)
+	titles := make([]string, len(cols))
+	for i, col := range cols {
+		titles[i], _ = col["title"].(string)
+	}
+	return titles
+}
+
+func tabularColumns(st *dataset.Structure) (cols []map[string]interface{}) {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	items, ok := st.Schema["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	list, ok := items["items"].([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, item := range list {
+		if col, ok := item.(map[string]interface{}); ok {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// structureSummary describes a structure as markdown
+func structureSummary(st *dataset.Structure) (string, error) {
+	if st == nil {
+		return "", fmt.Errorf("dataset has no structure component")
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "- **format:** %s\n", st.Format)
+	fmt.Fprintf(b, "- **entries:** %d\n", st.Entries)
+	fmt.Fprintf(b, "- **size:** %d bytes\n", st.Length)
+	if st.ErrCount > 0 {
+		fmt.Fprintf(b, "- **errors:** %d\n", st.ErrCount)
+	}
+
+	cols := tabularColumns(st)
+	if len(cols) == 0 {
+		return b.String(), nil
+	}
+
+	t := &Table{Columns: []string{"field", "type", "description"}}
+	for _, col := range cols {
+		t.Rows = append(t.Rows, []interface{}{col["title"], typeString(col["type"]), col["description"]})
+	}
+	b.WriteString("\n")
+	b.WriteString(t.String())
+	return b.String(), nil
+}
+
+// typeString prints a schema type, which can be a string or a list of strings
+func typeString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []interface{}:
+		types := make([]string, len(t))
+		for i, s := range t {
+			types[i] = fmt.Sprintf("%v", s)
+		}
+		return strings.Join(types, ", ")
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package dsreadme
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/dataset/dsviz"
+	"github.com/qri-io/qfs"
+)
+
+func TestRender(t *testing.T) {
+	if _, err := Render(&dataset.Dataset{}); err == nil {
+		t.Error("expected ds with no readme to error")
+	}
+	if _, err := Render(&dataset.Dataset{Readme: &dataset.Readme{Format: "rst"}}); err == nil {
+		t.Error("expected unsupported readme format to error")
+	}
+	if _, err := Render(&dataset.Dataset{Readme: &dataset.Readme{Format: "md"}}); err == nil {
+		t.Error("expected readme without a script file to error")
+	}
+
+	tc, err := dstest.NewTestCaseFromDir("testdata/world_pop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := ioutil.ReadFile(filepath.Join(tc.Path, "readme.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := tc.Input
+	ds.Readme.SetScriptFile(qfs.NewMemfileBytes("readme.md", script))
+
+	rendered, err := Render(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rf, err := tc.RenderedFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := ioutil.ReadAll(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expect, got) {
+		t.Errorf("result mismatch. expected:\n%s\ngot:\n%s", string(expect), string(got))
+	}
+
+	set, err := ioutil.ReadAll(ds.Readme.RenderedFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expect, set) {
+		t.Errorf("rendered file mismatch. expected:\n%s\ngot:\n%s", string(expect), string(set))
+	}
+
+	// script & body files must be restored for reuse
+	if _, err := Render(ds); err != nil {
+		t.Errorf("rendering a second time: %s", err)
+	}
+}
+
+func TestRenderHTMLFormat(t *testing.T) {
+	ds := &dataset.Dataset{
+		Meta:   &dataset.Meta{Title: "hello"},
+		Readme: &dataset.Readme{Format: "html"},
+	}
+	ds.Readme.SetScriptFile(qfs.NewMemfileBytes("readme.html", []byte(`<h1>{{ title }}</h1><img src="x.png" onerror="steal()">`)))
+
+	rendered, err := Render(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `<h1>hello</h1><img src="x.png">`
+	if string(got) != expect {
+		t.Errorf("result mismatch. expected: %q, got: %q", expect, string(got))
+	}
+}
+
+func TestRenderLimits(t *testing.T) {
+	cases := []struct {
+		description string
+		allowed     []string
+		err         string
+	}{
+		{"allowed", []string{"title", "structureSummary"}, ""},
+		{"readme function not allowed", []string{"title"}, "template function not allowed: 'structureSummary'"},
+		{"viz function not allowed", []string{"structureSummary"}, "template function not allowed: 'title'"},
+	}
+
+	for _, c := range cases {
+		ds := &dataset.Dataset{
+			Meta:      &dataset.Meta{Title: "hello"},
+			Structure: &dataset.Structure{Format: "json"},
+			Readme:    &dataset.Readme{Format: "md"},
+		}
+		ds.Readme.SetScriptFile(qfs.NewMemfileBytes("readme.md", []byte("# {{ title }}\n\n{{ structureSummary }}")))
+
+		_, err := Render(ds, func(cfg *dsviz.RenderCfg) { cfg.AllowedFuncs = c.allowed })
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case '%s' error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+			continue
+		}
+		if _, ok := err.(*dsviz.LimitError); err != nil && !ok {
+			t.Errorf("case '%s' expected a *dsviz.LimitError, got: %T", c.description, err)
+		}
+	}
+}
+
+func TestTableString(t *testing.T) {
+	st := &dataset.Structure{
+		Format: "json",
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "a", "type": "string"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		description string
+		st          *dataset.Structure
+		entries     interface{}
+		expect      string
+	}{
+		{"empty", nil, []interface{}{}, ""},
+		{"tabular", st, []interface{}{[]interface{}{"x|y", 1.5, true}}, "| a | field_2 | field_3 |\n| --- | --- | --- |\n| x\\|y | 1.5 | true |\n"},
+		{"nested values", nil, []interface{}{map[string]interface{}{"b": []interface{}{1.0}}}, "| field_1 |\n| --- |\n| {\"b\":[1]} |\n"},
+		{"object", nil, map[string]interface{}{"b": 2.0, "a": "multi\nline"}, "| key | value |\n| --- | --- |\n| a | multi line |\n| b | 2 |\n"},
+	}
+
+	for _, c := range cases {
+		got := newTable(c.st, c.entries).String()
+		if got != c.expect {
+			t.Errorf("case '%s' result mismatch. expected:\n%s\ngot:\n%s", c.description, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package dsviz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+)
+
+const (
+	// DefaultMaxEntries is the default number of body entries a single render
+	// can decode
+	DefaultMaxEntries = 100000
+	// DefaultMaxBodyBytes is the default number of body bytes a single render
+	// can read
+	DefaultMaxBodyBytes = 64 << 20
+	// DefaultMaxOutputBytes is the default number of bytes a single render can
+	// write
+	DefaultMaxOutputBytes = 64 << 20
+)
+
+// RenderCfg configures limits on the time, output and body data templates can
+// use during a single render, and the body preview page shown by the default
+// template. Renders that hit a limit abort with a *LimitError
+type RenderCfg struct {
+	// Context bounds the render. Renders return a *LimitError as soon as the
+	// context is done, including templates busy looping without output
+	Context context.Context
+	// MaxOutputBytes caps the size of rendered output. zero or less means no
+	// limit
+	MaxOutputBytes int64
+	// AllowedFuncs restricts the functions templates can call to the named
+	// set. calling any other function aborts the render. nil allows all
+	// functions. builtin go template functions are always allowed
+	AllowedFuncs []string
+	// MaxEntries caps the number of body entries a render can decode. zero or
+	// less means no limit
+	MaxEntries int
+	// MaxBodyBytes caps the number of body bytes a render can read. zero or
+	// less means no limit
+	MaxBodyBytes int64
+	// PreviewPage is the 1-indexed page of body entries the default template
+	// shows
+	PreviewPage int
+	// PreviewPageSize is the number of body entries on each page of the default
+	// template body preview
+	PreviewPageSize int
+	// MaxMemoryBytes caps the number of body bytes kept in memory to restore
+	// the dataset body file after a render, spilling to disk past the cap.
+	// spilled bytes are removed when the restored body file is closed. decoded
+	// entries are cached up to the same cap, entries past it are decoded again
+	// from recorded bytes when asked for a second time.
+	// defaults to dsio.DefaultMaxMemoryBytes, zero or less never spills
+	MaxMemoryBytes int
+	// TempDir is the directory body bytes spill to, defaults to the system
+	// temp directory
+	TempDir string
+	// VegaLiteScripts are the script URLs vega-lite pages load to draw charts.
+	// defaults to none, keeping pages self-contained. set to VegaLiteScripts to
+	// load the vega runtime from a CDN, or to self-hosted copies of it
+	VegaLiteScripts []string
+	// VegaLiteInlineScripts are javascript sources included in vega-lite pages
+	// as inline scripts, after any VegaLiteScripts. inlining the vega, vega-lite
+	// and vega-embed runtimes with no VegaLiteScripts produces pages that draw
+	// charts offline. sources are trusted & included as-is
+	VegaLiteInlineScripts []string
+}
+
+// DefaultRenderCfg returns a RenderCfg with default limits
+func DefaultRenderCfg() *RenderCfg {
+	return &RenderCfg{
+		Context:         context.Background(),
+		MaxOutputBytes:  DefaultMaxOutputBytes,
+		MaxEntries:      DefaultMaxEntries,
+		MaxBodyBytes:    DefaultMaxBodyBytes,
+		PreviewPage:     1,
+		PreviewPageSize: DefaultPreviewPageSize,
+		MaxMemoryBytes:  dsio.DefaultMaxMemoryBytes,
+	}
+}
+
+// body gives templates limited access to a dataset body. Entries are decoded
+// lazily in order, and only as far as templates ask for them. Decoded entries
+// are cached from the start of the body until the cache holds
+// RenderCfg.MaxMemoryBytes, asking for an uncached entry before the last one
+// decoded re-reads recorded body bytes from the start. Body bytes are read
+// from the dataset body file once per render & recorded so the file can be
+// restored on close
+type body struct {
+	ds  *dataset.Dataset
+	cfg *RenderCfg
+	lim *limiter
+
+	lk     sync.Mutex
+	file   qfs.File
+	rec    *recording
+	src    *limitReader
+	reader dsio.EntryReader
+	tlt    string
+	// pos is the index of the next entry reader returns
+	pos int
+	// count is the number of entries in the body, set once the end is reached
+	count int
+	// cache holds decoded entries from the start of the body, cacheSize is the
+	// approximate size of cached entries in bytes
+	cache     []dsio.Entry
+	cacheSize int
+	cacheFull bool
+	eof       bool
+	// first error encountered by an iterator
+	iterErr error
+	stop    chan struct{}
+	closed  bool
+}
+
+func newBody(ds *dataset.Dataset, cfg *RenderCfg) *body {
+	return &body{ds: ds, cfg: cfg, lim: newLimiter(cfg.Context), stop: make(chan struct{})}
+}
+
+// open sets up the entry reader on first use
+func (b *body) open() error {
+	if b.reader != nil {
+		return nil
+	}
+	if b.closed {
+		return fmt.Errorf("body is closed")
+	}
+	if b.ds.Structure == nil {
+		return fmt.Errorf("can't get_body. dataset has no structure component")
+	}
+	if b.ds.BodyFile() == nil {
+		return fmt.Errorf("can't get_body. dataset has no body file")
+	}
+
+	tlt, err := dsio.GetTopLevelType(b.ds.Structure)
+	if err != nil {
+		return err
+	}
+
+	b.file = b.ds.BodyFile()
+	b.rec = &recording{maxMem: b.cfg.MaxMemoryBytes, tempDir: b.cfg.TempDir}
+	b.src = &limitReader{r: b.file, max: b.cfg.MaxBodyBytes, w: b.rec}
+	rr, err := dsio.NewEntryReader(b.ds.Structure, b.src)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err)
+	}
+	b.reader = rr
+	b.tlt = tlt
+	return nil
+}
+
+// rewind starts decoding from the first entry again, reading recorded bytes
+// before continuing with the unread remainder of the body file
+func (b *body) rewind() error {
+	r := io.MultiReader(b.rec.reader(), b.src)
+	rr, err := dsio.NewEntryReader(b.ds.Structure, r)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err)
+	}
+	b.reader.Close()
+	b.reader = rr
+	b.pos = 0
+	return nil
+}
+
+// entry returns the entry at index i, decoding entries up to i as needed.
+// ok is false when the body has fewer than i+1 entries
+func (b *body) entry(i int) (ent dsio.Entry, ok bool, err error) {
+	if b.closed {
+		// renders abandoned at a deadline can outlive the body
+		return ent, false, fmt.Errorf("body is closed")
+	}
+	if err = b.open(); err != nil {
+		return ent, false, err
+	}
+	if i < len(b.cache) {
+		return b.cache[i], true, nil
+	}
+	if b.eof && i >= b.count {
+		return ent, false, nil
+	}
+	if i < b.pos {
+		if err = b.rewind(); err != nil {
+			return ent, false, err
+		}
+	}
+
+	for b.pos <= i {
+		if err := b.lim.check(); err != nil {
+			return ent, false, err
+		}
+		next, err := b.reader.ReadEntry()
+		if err != nil {
+			if b.src.exceeded {
+				return ent, false, b.lim.exceed(&LimitError{Limit: LimitBodyBytes, Max: b.cfg.MaxBodyBytes})
+			}
+			if err == io.EOF || err.Error() == "EOF" {
+				b.eof = true
+				b.count = b.pos
+				return ent, false, nil
+			}
+			return ent, false, err
+		}
+		if b.cfg.MaxEntries > 0 && b.pos >= b.cfg.MaxEntries {
+			return ent, false, b.lim.exceed(&LimitError{Limit: LimitEntries, Max: int64(b.cfg.MaxEntries)})
+		}
+		ent = next
+		b.cacheEntry(next)
+		b.pos++
+	}
+	return ent, true, nil
+}
+
+// cacheEntry adds the entry at pos to the cache if it follows the cached
+// entries & fits within the memory cap. the cache stops growing at the cap
+func (b *body) cacheEntry(ent dsio.Entry) {
+	if b.cacheFull || b.pos != len(b.cache) {
+		return
+	}
+	size := dsio.ApproxSize(ent.Value) + len(ent.Key)
+	if b.cfg.MaxMemoryBytes > 0 && b.cacheSize+size > b.cfg.MaxMemoryBytes {
+		b.cacheFull = true
+		return
+	}
+	b.cache = append(b.cache, ent)
+	b.cacheSize += size
+}
+
+// entryAt is a locking version of entry, returning the top level type of the
+// body alongside the entry
+func (b *body) entryAt(i int) (ent dsio.Entry, tlt string, ok bool, err error) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	ent, ok, err = b.entry(i)
+	return ent, b.tlt, ok, err
+}
+
+// page returns body entries as a native go array or map. passing a negative
+// offset or limit returns the entire body
+func (b *body) page(offset, limit int) (interface{}, error) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	if offset < 0 || limit < 0 {
+		offset = 0
+		limit = -1
+	}
+
+	obj := make(map[string]interface{})
+	array := make([]interface{}, 0)
+	for i := offset; limit < 0 || i < offset+limit; i++ {
+		ent, ok, err := b.entry(i)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if b.tlt == "object" {
+			obj[ent.Key] = ent.Value
+		} else {
+			array = append(array, ent.Value)
+		}
+	}
+
+	if b.tlt == "object" {
+		return obj, nil
+	}
+	return array, nil
+}
+
+// iterate sends entries within an offset/limit range on the returned channel,
+// decoding one entry at a time. Errors are recorded and the channel is
+// closed, callers should check err after consuming the channel
+func (b *body) iterate(offset, limit int) <-chan dsio.Entry {
+	if offset < 0 || limit < 0 {
+		offset = 0
+		limit = -1
+	}
+
+	ch := make(chan dsio.Entry)
+	go func() {
+		defer close(ch)
+		for i := offset; limit < 0 || i < offset+limit; i++ {
+			b.lk.Lock()
+			ent, ok, err := b.entry(i)
+			if err != nil && b.iterErr == nil {
+				b.iterErr = err
+			}
+			b.lk.Unlock()
+			if err != nil || !ok {
+				return
+			}
+
+			select {
+			case ch <- ent:
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// err returns the first error encountered while iterating
+func (b *body) err() error {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	return b.iterErr
+}
+
+// close stops any running iterators, and restores the dataset body file if it
+// has been read from. Recorded bytes are placed in front of any unread
+// remainder of the original file. Closing the restored file removes spilled
+// bytes & closes the original file
+func (b *body) close() {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.stop)
+
+	if b.reader != nil {
+		b.reader.Close()
+	}
+	b.cache = nil
+	if b.file != nil {
+		r := &restoredBody{
+			Reader: io.MultiReader(b.rec.reader(), b.file),
+			rec:    b.rec,
+			file:   b.file,
+		}
+		b.ds.SetBodyFile(qfs.NewMemfileReader(b.file.FileName(), r))
+	}
+}
+
+// restoredBody reads recorded bytes followed by the rest of the original body
+// file, cleaning up both on close
+type restoredBody struct {
+	io.Reader
+	rec  *recording
+	file io.Closer
+}
+
+func (r *restoredBody) Close() error {
+	err := r.rec.Close()
+	if e := r.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// recording holds bytes read from the body file in memory, spilling to a temp
+// file once more than maxMem bytes are written
+type recording struct {
+	maxMem  int
+	tempDir string
+	mem     []byte
+	f       *os.File
+	size    int64
+}
+
+func (r *recording) Write(p []byte) (int, error) {
+	if r.f == nil && r.maxMem > 0 && len(r.mem)+len(p) > r.maxMem {
+		f, err := ioutil.TempFile(r.tempDir, "dsviz_body_")
+		if err != nil {
+			return 0, err
+		}
+		r.f = f
+		if _, err := f.Write(r.mem); err != nil {
+			return 0, err
+		}
+		r.mem = nil
+	}
+	if r.f != nil {
+		n, err := r.f.Write(p)
+		r.size += int64(n)
+		return n, err
+	}
+	r.mem = append(r.mem, p...)
+	r.size += int64(len(p))
+	return len(p), nil
+}
+
+// ReadAt implements io.ReaderAt
+func (r *recording) ReadAt(p []byte, off int64) (int, error) {
+	if r.f != nil {
+		return r.f.ReadAt(p, off)
+	}
+	if off >= int64(len(r.mem)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.mem[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// reader reads bytes recorded so far. later writes aren't included
+func (r *recording) reader() io.Reader {
+	return io.NewSectionReader(r, 0, r.size)
+}
+
+// Close removes any spilled bytes
+func (r *recording) Close() error {
+	r.mem = nil
+	if r.f == nil {
+		return nil
+	}
+	r.f.Close()
+	err := os.Remove(r.f.Name())
+	r.f = nil
+	return err
+}
+
+// limitReader writes all bytes read from r to w, erroring once more than max
+// bytes have been read
+type limitReader struct {
+	r        io.Reader
+	max      int64
+	read     int64
+	w        io.Writer
+	exceeded bool
+}
+
+// errBodyLimit is returned by limitReader once the limit is passed. readers
+// may wrap this error, so body checks limitReader.exceeded instead
+var errBodyLimit = fmt.Errorf("body size limit exceeded")
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errBodyLimit
+	}
+	n, err := l.r.Read(p)
+	// record every byte read so the body can be restored, but only pass bytes
+	// within the limit on to the reader
+	if _, werr := l.w.Write(p[:n]); werr != nil {
+		return 0, werr
+	}
+	if l.max > 0 && l.read+int64(n) > l.max {
+		allowed := l.max - l.read
+		l.read = l.max
+		l.exceeded = true
+		return int(allowed), errBodyLimit
+	}
+	l.read += int64(n)
+	return n, err
+}
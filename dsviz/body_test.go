@@ -0,0 +1,257 @@
+package dsviz
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+const testBody = `[[1,"a"],[2,"b"],[3,"c"],[4,"d"]]`
+
+// countingFile counts the number of bytes read from it
+type countingFile struct {
+	qfs.File
+	read int
+}
+
+func (f *countingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.read += n
+	return n, err
+}
+
+func newBodyDataset(data string) *dataset.Dataset {
+	ds := &dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format: "json",
+			Schema: dataset.BaseSchemaArray,
+		},
+		Viz: &dataset.Viz{Format: "html"},
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(data)))
+	return ds
+}
+
+func renderString(ds *dataset.Dataset, tmpl string, configs ...func(cfg *RenderCfg)) (string, error) {
+	ds.Viz.SetScriptFile(qfs.NewMemfileBytes("template.html", []byte(tmpl)))
+	f, err := Render(ds, configs...)
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadAll(f)
+	return string(data), err
+}
+
+func TestRenderBodyFuncs(t *testing.T) {
+	cases := []struct {
+		description string
+		body        string
+		tmpl        string
+		cfg         func(cfg *RenderCfg)
+		expect      string
+		err         string
+	}{
+		{"page", testBody, `{{ range bodyEntries 1 2 }}{{ index . 1 }}{{ end }}`, nil, "bc", ""},
+		{"page past end", testBody, `{{ len (bodyEntries 3 10) }}`, nil, "1", ""},
+		{"all entries", testBody, `{{ len allBodyEntries }}`, nil, "4", ""},
+		{"repeated calls", testBody, `{{ len (bodyEntries 2 1) }}{{ len (bodyEntries 0 4) }}{{ len allBodyEntries }}`, nil, "144", ""},
+		{"iterate", testBody, `{{ range eachBodyEntry 0 -1 }}{{ .Index }}{{ index .Value 1 }}{{ end }}`, nil, "0a1b2c3d", ""},
+		{"iterate range", testBody, `{{ range eachBodyEntry 1 2 }}{{ index .Value 1 }}{{ end }}`, nil, "bc", ""},
+		{"object body", `{"a":1,"b":2}`, `{{ range eachBodyEntry 0 -1 }}{{ .Key }}{{ end }}{{ len allBodyEntries }}`, nil, "ab2", ""},
+		{"entry limit at body size", testBody, `{{ len allBodyEntries }}`, func(cfg *RenderCfg) { cfg.MaxEntries = 4 }, "4", ""},
+		{"entry limit within page", testBody, `{{ len (bodyEntries 0 2) }}`, func(cfg *RenderCfg) { cfg.MaxEntries = 2 }, "2", ""},
+
+		{"entry limit", testBody, `{{ allBodyEntries }}`, func(cfg *RenderCfg) { cfg.MaxEntries = 2 }, "",
+			`body entry limit exceeded: templates can read at most 2 body entries`},
+		{"iterator entry limit", testBody, `{{ range eachBodyEntry 0 -1 }}{{ end }}`, func(cfg *RenderCfg) { cfg.MaxEntries = 3 }, "",
+			`body entry limit exceeded: templates can read at most 3 body entries`},
+		{"byte limit", testBody, `{{ allBodyEntries }}`, func(cfg *RenderCfg) { cfg.MaxBodyBytes = 10 }, "",
+			`body size limit exceeded: templates can read at most 10 bytes of body data`},
+	}
+
+	for _, c := range cases {
+		ds := newBodyDataset(c.body)
+		if strings.HasPrefix(c.body, "{") {
+			ds.Structure.Schema = dataset.BaseSchemaObject
+		} else {
+			ds.Structure.Schema = dataset.BaseSchemaArray
+		}
+
+		var configs []func(cfg *RenderCfg)
+		if c.cfg != nil {
+			configs = append(configs, c.cfg)
+		}
+		got, err := renderString(ds, c.tmpl, configs...)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case '%s' error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case '%s' result mismatch. expected: '%s', got: '%s'", c.description, c.expect, got)
+		}
+
+		// body file must be restored in full, even after errors
+		body, err := ioutil.ReadAll(ds.BodyFile())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != c.body {
+			t.Errorf("case '%s' body not restored. expected: '%s', got: '%s'", c.description, c.body, string(body))
+		}
+	}
+}
+
+func TestRenderBodyReadOnce(t *testing.T) {
+	ds := newBodyDataset(testBody)
+	f := &countingFile{File: ds.BodyFile()}
+	ds.SetBodyFile(f)
+
+	got, err := renderString(ds, `{{ len allBodyEntries }}{{ len allBodyEntries }}{{ range eachBodyEntry 0 -1 }}{{ end }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "44" {
+		t.Errorf("result mismatch. expected: '44', got: '%s'", got)
+	}
+
+	if _, err := renderString(ds, `{{ len allBodyEntries }}`); err != nil {
+		t.Fatal(err)
+	}
+	if f.read != len(testBody) {
+		t.Errorf("expected body file to be read once. read %d bytes of a %d byte body", f.read, len(testBody))
+	}
+}
+
+func TestRenderBodyPartialRead(t *testing.T) {
+	ds := newBodyDataset(testBody)
+	if _, err := renderString(ds, `{{ bodyEntries 0 1 }}`); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testBody {
+		t.Errorf("body not restored after partial read. got: %s", string(data))
+	}
+}
+
+func TestRenderBodySpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsviz_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ds := newBodyDataset(testBody)
+	f := &countingFile{File: ds.BodyFile()}
+	ds.SetBodyFile(f)
+
+	// entries before the last one decoded are read again from recorded bytes
+	tmpl := `{{ range eachBodyEntry 0 -1 }}{{ index .Value 1 }}{{ bodyEntries 0 1 }}{{ end }}{{ len allBodyEntries }}`
+	got, err := renderString(ds, tmpl, func(cfg *RenderCfg) {
+		cfg.MaxMemoryBytes = 8
+		cfg.TempDir = dir
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "a[[1 a]]b[[1 a]]c[[1 a]]d[[1 a]]4"; got != expect {
+		t.Errorf("result mismatch. expected: '%s', got: '%s'", expect, got)
+	}
+	if f.read != len(testBody) {
+		t.Errorf("expected body file to be read once. read %d bytes of a %d byte body", f.read, len(testBody))
+	}
+
+	spilled, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spilled) != 1 {
+		t.Fatalf("expected body bytes to spill to one file, got %d files", len(spilled))
+	}
+
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testBody {
+		t.Errorf("body not restored. got: %s", string(data))
+	}
+	if err := ds.BodyFile().Close(); err != nil {
+		t.Fatal(err)
+	}
+	if spilled, _ = ioutil.ReadDir(dir); len(spilled) != 0 {
+		t.Errorf("expected spilled bytes to be removed on close, found %d files", len(spilled))
+	}
+}
+
+func TestBodyEntryCache(t *testing.T) {
+	cases := []struct {
+		description string
+		maxMem      int
+		cached      int
+		rewinds     bool
+	}{
+		{"unbounded", 0, 4, false},
+		{"within cap", 1 << 10, 4, false},
+		// each entry is approximately 57 bytes
+		{"spill past cap", 120, 2, true},
+	}
+
+	for _, c := range cases {
+		cfg := DefaultRenderCfg()
+		cfg.MaxMemoryBytes = c.maxMem
+		b := newBody(newBodyDataset(testBody), cfg)
+
+		if _, ok, err := b.entry(3); err != nil || !ok {
+			t.Fatalf("case '%s' reading last entry: ok: %t, err: %v", c.description, ok, err)
+		}
+		if len(b.cache) != c.cached {
+			t.Errorf("case '%s' expected %d cached entries, got %d", c.description, c.cached, len(b.cache))
+		}
+
+		// cached entries are served without decoding the body again
+		ent, _, err := b.entry(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.pos != 4 {
+			t.Errorf("case '%s' expected cached entry to be read without rewinding", c.description)
+		}
+		if got := ent.Value.([]interface{})[1]; got != "a" {
+			t.Errorf("case '%s' first entry mismatch. got: %v", c.description, got)
+		}
+
+		ent, _, err = b.entry(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rewound := b.pos != 4; rewound != c.rewinds {
+			t.Errorf("case '%s' expected rewind: %t, got: %t", c.description, c.rewinds, rewound)
+		}
+		if got := ent.Value.([]interface{})[1]; got != "c" {
+			t.Errorf("case '%s' third entry mismatch. got: %v", c.description, got)
+		}
+		b.close()
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	l := &limitReader{r: strings.NewReader("0123456789"), max: 4, w: &bytes.Buffer{}}
+	if _, err := ioutil.ReadAll(io.LimitReader(l, 3)); err != nil {
+		t.Errorf("unexpected error reading under the limit: %s", err)
+	}
+	if _, err := ioutil.ReadAll(l); err != errBodyLimit {
+		t.Errorf("expected body limit error, got: %v", err)
+	}
+	if !l.exceeded {
+		t.Error("expected exceeded to be true")
+	}
+}
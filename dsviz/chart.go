@@ -0,0 +1,532 @@
+package dsviz
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chartFuncs are template functions that render body columns as SVG charts
+func chartFuncs(titles []string) template.FuncMap {
+	return template.FuncMap{
+		"barChart": func(x, y string, entries interface{}, opts ...interface{}) (template.HTML, error) {
+			return renderChart(titles, chartBar, x, y, entries, opts)
+		},
+		"lineChart": func(x, y string, entries interface{}, opts ...interface{}) (template.HTML, error) {
+			return renderChart(titles, chartLine, x, y, entries, opts)
+		},
+		"scatterChart": func(x, y string, entries interface{}, opts ...interface{}) (template.HTML, error) {
+			return renderChart(titles, chartScatter, x, y, entries, opts)
+		},
+		"histogram": func(x string, entries interface{}, opts ...interface{}) (template.HTML, error) {
+			return renderChart(titles, chartHistogram, x, "", entries, opts)
+		},
+	}
+}
+
+// chart kinds
+const (
+	chartBar       = "bar"
+	chartLine      = "line"
+	chartScatter   = "scatter"
+	chartHistogram = "histogram"
+)
+
+// chartCfg configures the size and labelling of a chart. Templates set these
+// values with trailing key-value option pairs:
+//
+//	{{ barChart "region" "rainfall" allBodyEntries "width" 400 "title" "Rain" }}
+type chartCfg struct {
+	Width  int
+	Height int
+	Title  string
+	XLabel string
+	YLabel string
+	Color  string
+	// Bins is the number of buckets in a histogram
+	Bins int
+}
+
+// chart margins, leaving space for a title and axis labels
+const (
+	marginTop    = 32
+	marginRight  = 16
+	marginBottom = 48
+	marginLeft   = 64
+)
+
+// colorPattern matches colors that are safe to write into an attribute
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+)$`)
+
+func newChartCfg(kind, x, y string, opts []interface{}) (*chartCfg, error) {
+	cfg := &chartCfg{
+		Width:  600,
+		Height: 400,
+		XLabel: x,
+		YLabel: y,
+		Color:  "#4c78a8",
+		Bins:   10,
+	}
+	if kind == chartHistogram {
+		cfg.YLabel = "count"
+	}
+
+	if len(opts)%2 != 0 {
+		return nil, fmt.Errorf("%s chart: options must be key-value pairs", kind)
+	}
+	for i := 0; i < len(opts); i += 2 {
+		key, ok := opts[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s chart: option keys must be strings, got %T", kind, opts[i])
+		}
+		val := opts[i+1]
+
+		var err error
+		switch key {
+		case "width":
+			cfg.Width, err = intOption(val)
+		case "height":
+			cfg.Height, err = intOption(val)
+		case "bins":
+			cfg.Bins, err = intOption(val)
+		case "title":
+			cfg.Title = fmt.Sprintf("%v", val)
+		case "xLabel":
+			cfg.XLabel = fmt.Sprintf("%v", val)
+		case "yLabel":
+			cfg.YLabel = fmt.Sprintf("%v", val)
+		case "color":
+			cfg.Color = fmt.Sprintf("%v", val)
+			if !colorPattern.MatchString(cfg.Color) {
+				err = fmt.Errorf("invalid color '%s'", cfg.Color)
+			}
+		default:
+			err = fmt.Errorf("unknown option '%s'", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s chart: %s", kind, err)
+		}
+	}
+
+	if cfg.Width <= marginLeft+marginRight || cfg.Height <= marginTop+marginBottom {
+		return nil, fmt.Errorf("%s chart: size %dx%d is too small", kind, cfg.Width, cfg.Height)
+	}
+	if cfg.Bins < 1 {
+		return nil, fmt.Errorf("%s chart: bins must be greater than zero", kind)
+	}
+	return cfg, nil
+}
+
+func intOption(v interface{}) (int, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return 0, err
+	}
+	return int(f), nil
+}
+
+// renderChart extracts columns from entries and draws them as an SVG chart
+func renderChart(titles []string, kind, x, y string, entries interface{}, opts []interface{}) (template.HTML, error) {
+	cfg, err := newChartCfg(kind, x, y, opts)
+	if err != nil {
+		return "", err
+	}
+
+	xs, err := column(titles, x, entries)
+	if err != nil {
+		return "", fmt.Errorf("%s chart: %s", kind, err)
+	}
+	var ys []interface{}
+	if kind != chartHistogram {
+		if ys, err = column(titles, y, entries); err != nil {
+			return "", fmt.Errorf("%s chart: %s", kind, err)
+		}
+	}
+
+	var svg []byte
+	switch kind {
+	case chartBar:
+		svg, err = barChart(cfg, xs, ys)
+	case chartLine, chartScatter:
+		svg, err = xyChart(cfg, kind, xs, ys)
+	case chartHistogram:
+		svg, err = histogram(cfg, xs)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s chart: %s", kind, err)
+	}
+	return template.HTML(svg), nil
+}
+
+// linearScale maps a numeric domain onto a pixel range
+type linearScale struct {
+	d0, d1 float64
+	r0, r1 float64
+}
+
+func (s linearScale) at(v float64) float64 {
+	if s.d1 == s.d0 {
+		return (s.r0 + s.r1) / 2
+	}
+	return s.r0 + (v-s.d0)/(s.d1-s.d0)*(s.r1-s.r0)
+}
+
+// niceTicks picks around n evenly spaced, round tick values covering
+// [min, max], returning the ticks. the first and last ticks extend the domain
+// to round numbers
+func niceTicks(min, max float64, n int) []float64 {
+	if min == max {
+		if min == 0 {
+			max = 1
+		} else {
+			d := math.Abs(min) * 0.1
+			min, max = min-d, max+d
+		}
+	}
+	step := niceStep((max - min) / float64(n))
+	start := math.Floor(min/step) * step
+	end := math.Ceil(max/step) * step
+
+	// round ticks to the precision of the step to avoid values like
+	// 0.6000000000000001
+	decimals := int(math.Max(0, -math.Floor(math.Log10(step))))
+	var ticks []float64
+	for k := math.Round(start / step); k*step <= end+step/2; k++ {
+		t, _ := strconv.ParseFloat(strconv.FormatFloat(k*step, 'f', decimals, 64), 64)
+		ticks = append(ticks, t)
+	}
+	return ticks
+}
+
+// niceStep rounds a raw step size to 1, 2 or 5 times a power of ten
+func niceStep(raw float64) float64 {
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch norm := raw / mag; {
+	case norm <= 1:
+		return mag
+	case norm <= 2:
+		return 2 * mag
+	case norm <= 5:
+		return 5 * mag
+	}
+	return 10 * mag
+}
+
+// svgWriter accumulates SVG markup
+type svgWriter struct {
+	bytes.Buffer
+	cfg *chartCfg
+}
+
+func newSVG(cfg *chartCfg, kind string) *svgWriter {
+	w := &svgWriter{cfg: cfg}
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" class="chart %s-chart" width="%d" height="%d" viewBox="0 0 %d %d" role="img" font-family="sans-serif" font-size="11">`, kind, cfg.Width, cfg.Height, cfg.Width, cfg.Height)
+	if cfg.Title != "" {
+		fmt.Fprintf(w, `<title>%s</title>`, template.HTMLEscapeString(cfg.Title))
+		fmt.Fprintf(w, `<text class="title" x="%s" y="20" text-anchor="middle" font-size="14">%s</text>`, num(float64(cfg.Width)/2), template.HTMLEscapeString(cfg.Title))
+	}
+	return w
+}
+
+func (w *svgWriter) close() []byte {
+	w.WriteString(`</svg>`)
+	return w.Bytes()
+}
+
+// plot area bounds
+func (w *svgWriter) left() float64   { return marginLeft }
+func (w *svgWriter) right() float64  { return float64(w.cfg.Width - marginRight) }
+func (w *svgWriter) top() float64    { return marginTop }
+func (w *svgWriter) bottom() float64 { return float64(w.cfg.Height - marginBottom) }
+
+// yAxis draws a vertical axis with gridlines and the y axis label
+func (w *svgWriter) yAxis(s linearScale, ticks []float64) {
+	w.WriteString(`<g class="y-axis">`)
+	for _, t := range ticks {
+		y := num(s.at(t))
+		fmt.Fprintf(w, `<line x1="%s" x2="%s" y1="%s" y2="%s" stroke="#e0e0e0"/>`, num(w.left()), num(w.right()), y, y)
+		fmt.Fprintf(w, `<text x="%s" y="%s" text-anchor="end" dominant-baseline="middle">%s</text>`, num(w.left()-6), y, template.HTMLEscapeString(tickLabel(t)))
+	}
+	fmt.Fprintf(w, `<line x1="%s" x2="%s" y1="%s" y2="%s" stroke="#333"/>`, num(w.left()), num(w.left()), num(w.top()), num(w.bottom()))
+	if w.cfg.YLabel != "" {
+		cy := num((w.top() + w.bottom()) / 2)
+		fmt.Fprintf(w, `<text class="y-label" x="16" y="%s" text-anchor="middle" transform="rotate(-90 16 %s)">%s</text>`, cy, cy, template.HTMLEscapeString(w.cfg.YLabel))
+	}
+	w.WriteString(`</g>`)
+}
+
+// xAxis draws a horizontal axis with labelled ticks at the given positions
+func (w *svgWriter) xAxis(positions []float64, labels []string) {
+	w.WriteString(`<g class="x-axis">`)
+	fmt.Fprintf(w, `<line x1="%s" x2="%s" y1="%s" y2="%s" stroke="#333"/>`, num(w.left()), num(w.right()), num(w.bottom()), num(w.bottom()))
+	for i, x := range positions {
+		fmt.Fprintf(w, `<line x1="%s" x2="%s" y1="%s" y2="%s" stroke="#333"/>`, num(x), num(x), num(w.bottom()), num(w.bottom()+4))
+		fmt.Fprintf(w, `<text x="%s" y="%s" text-anchor="middle">%s</text>`, num(x), num(w.bottom()+16), template.HTMLEscapeString(labels[i]))
+	}
+	if w.cfg.XLabel != "" {
+		fmt.Fprintf(w, `<text class="x-label" x="%s" y="%d" text-anchor="middle">%s</text>`, num((w.left()+w.right())/2), w.cfg.Height-8, template.HTMLEscapeString(w.cfg.XLabel))
+	}
+	w.WriteString(`</g>`)
+}
+
+// yScale builds a vertical scale and ticks covering values, optionally
+// including zero so bars have a baseline
+func (w *svgWriter) yScale(vals []float64, zero bool) (linearScale, []float64) {
+	min, max := extent(vals)
+	if zero {
+		min, max = math.Min(min, 0), math.Max(max, 0)
+	}
+	ticks := niceTicks(min, max, 5)
+	return linearScale{d0: ticks[0], d1: ticks[len(ticks)-1], r0: w.bottom(), r1: w.top()}, ticks
+}
+
+func extent(vals []float64) (min, max float64) {
+	if len(vals) == 0 {
+		return 0, 0
+	}
+	min, max = vals[0], vals[0]
+	for _, v := range vals[1:] {
+		min, max = math.Min(min, v), math.Max(max, v)
+	}
+	return min, max
+}
+
+// barChart draws one bar per category. values of repeated categories are
+// summed, categories are kept in order of first appearance
+func barChart(cfg *chartCfg, xs, ys []interface{}) ([]byte, error) {
+	var cats []string
+	totals := map[string]float64{}
+	for i, x := range xs {
+		if ys[i] == nil {
+			continue
+		}
+		v, err := toFloat(ys[i])
+		if err != nil {
+			return nil, err
+		}
+		cat := ""
+		if x != nil {
+			cat = fmt.Sprintf("%v", x)
+		}
+		if _, ok := totals[cat]; !ok {
+			cats = append(cats, cat)
+		}
+		totals[cat] += v
+	}
+	if len(cats) == 0 {
+		return nil, fmt.Errorf("no values to plot")
+	}
+
+	vals := make([]float64, len(cats))
+	for i, cat := range cats {
+		vals[i] = totals[cat]
+	}
+
+	w := newSVG(cfg, chartBar)
+	ys0, yTicks := w.yScale(vals, true)
+	w.yAxis(ys0, yTicks)
+
+	band := (w.right() - w.left()) / float64(len(cats))
+	pad := band * 0.1
+	positions := make([]float64, len(cats))
+	fmt.Fprintf(w, `<g class="marks" fill="%s">`, cfg.Color)
+	for i, v := range vals {
+		x := w.left() + band*float64(i)
+		positions[i] = x + band/2
+		y0, y1 := ys0.at(0), ys0.at(v)
+		top, height := math.Min(y0, y1), math.Abs(y1-y0)
+		fmt.Fprintf(w, `<rect x="%s" y="%s" width="%s" height="%s"><title>%s: %s</title></rect>`, num(x+pad), num(top), num(band-2*pad), num(height), template.HTMLEscapeString(cats[i]), tickLabel(v))
+	}
+	w.WriteString(`</g>`)
+	w.xAxis(positions, cats)
+	return w.close(), nil
+}
+
+// xyPoint is a single point in a line or scatter chart
+type xyPoint struct {
+	x, y float64
+}
+
+// xyChart draws a line or scatter chart. x values must be numbers or
+// timestamps, timestamps are plotted on a time axis
+func xyChart(cfg *chartCfg, kind string, xs, ys []interface{}) ([]byte, error) {
+	xVals, isTime, err := xValues(xs)
+	if err != nil {
+		return nil, err
+	}
+
+	var pts []xyPoint
+	for i, x := range xVals {
+		if x == nil || ys[i] == nil {
+			continue
+		}
+		y, err := toFloat(ys[i])
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, xyPoint{x: *x, y: y})
+	}
+	if len(pts) == 0 {
+		return nil, fmt.Errorf("no values to plot")
+	}
+	if kind == chartLine {
+		sort.SliceStable(pts, func(i, j int) bool { return pts[i].x < pts[j].x })
+	}
+
+	xv := make([]float64, len(pts))
+	yv := make([]float64, len(pts))
+	for i, p := range pts {
+		xv[i], yv[i] = p.x, p.y
+	}
+
+	w := newSVG(cfg, kind)
+	yScale, yTicks := w.yScale(yv, false)
+	w.yAxis(yScale, yTicks)
+
+	xMin, xMax := extent(xv)
+	xTicks := niceTicks(xMin, xMax, 5)
+	layout := ""
+	if isTime {
+		layout = timeTickLayout(xMin, xMax)
+	}
+	xScale := linearScale{d0: xTicks[0], d1: xTicks[len(xTicks)-1], r0: w.left(), r1: w.right()}
+
+	positions := make([]float64, len(xTicks))
+	labels := make([]string, len(xTicks))
+	for i, t := range xTicks {
+		positions[i] = xScale.at(t)
+		if isTime {
+			labels[i] = time.Unix(int64(t), 0).UTC().Format(layout)
+		} else {
+			labels[i] = tickLabel(t)
+		}
+	}
+
+	if kind == chartLine {
+		coords := make([]string, len(pts))
+		for i, p := range pts {
+			coords[i] = num(xScale.at(p.x)) + "," + num(yScale.at(p.y))
+		}
+		fmt.Fprintf(w, `<g class="marks"><polyline fill="none" stroke="%s" stroke-width="2" points="%s"/></g>`, cfg.Color, strings.Join(coords, " "))
+	} else {
+		fmt.Fprintf(w, `<g class="marks" fill="%s" fill-opacity="0.7">`, cfg.Color)
+		for _, p := range pts {
+			fmt.Fprintf(w, `<circle cx="%s" cy="%s" r="3"/>`, num(xScale.at(p.x)), num(yScale.at(p.y)))
+		}
+		w.WriteString(`</g>`)
+	}
+	w.xAxis(positions, labels)
+	return w.close(), nil
+}
+
+// xValues converts x values to numbers, parsing strings as timestamps.
+// timestamps become seconds since the unix epoch
+func xValues(xs []interface{}) (vals []*float64, isTime bool, err error) {
+	vals = make([]*float64, len(xs))
+	for i, x := range xs {
+		if x == nil {
+			continue
+		}
+		var v float64
+		if s, ok := x.(string); ok {
+			t, err := timeParse(s)
+			if err != nil {
+				return nil, false, fmt.Errorf("x values must be numbers or timestamps: %s", err)
+			}
+			v = float64(t.Unix())
+			isTime = true
+		} else if v, err = toFloat(x); err != nil {
+			return nil, false, fmt.Errorf("x values must be numbers or timestamps: %s", err)
+		}
+		vals[i] = &v
+	}
+	return vals, isTime, nil
+}
+
+// timeTickLayout picks a label format suited to a time span in seconds
+func timeTickLayout(min, max float64) string {
+	const day = 24 * 60 * 60
+	switch span := max - min; {
+	case span > 3*365*day:
+		return "2006"
+	case span > 60*day:
+		return "Jan 2006"
+	case span > 2*day:
+		return "Jan 2"
+	}
+	return "Jan 2 15:04"
+}
+
+// histogram counts values into equal-width bins
+func histogram(cfg *chartCfg, xs []interface{}) ([]byte, error) {
+	vals, err := numbers("histogram", xs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("no values to plot")
+	}
+
+	min, max := extent(vals)
+	if min == max {
+		min, max = min-0.5, max+0.5
+	}
+	width := (max - min) / float64(cfg.Bins)
+	counts := make([]float64, cfg.Bins)
+	for _, v := range vals {
+		i := int((v - min) / width)
+		if i >= cfg.Bins {
+			// the maximum value belongs to the last bin
+			i = cfg.Bins - 1
+		}
+		counts[i]++
+	}
+
+	w := newSVG(cfg, chartHistogram)
+	// counts are whole numbers, keep ticks from going below a step of one
+	_, maxCount := extent(counts)
+	yTicks := niceTicks(0, maxCount, int(math.Min(5, maxCount)))
+	yScale := linearScale{d0: 0, d1: yTicks[len(yTicks)-1], r0: w.bottom(), r1: w.top()}
+	w.yAxis(yScale, yTicks)
+
+	xScale := linearScale{d0: min, d1: max, r0: w.left(), r1: w.right()}
+	fmt.Fprintf(w, `<g class="marks" fill="%s">`, cfg.Color)
+	for i, c := range counts {
+		x0, x1 := xScale.at(min+width*float64(i)), xScale.at(min+width*float64(i+1))
+		y := yScale.at(c)
+		fmt.Fprintf(w, `<rect x="%s" y="%s" width="%s" height="%s"><title>%s to %s: %s</title></rect>`, num(x0+0.5), num(y), num(math.Max(x1-x0-1, 0)), num(yScale.at(0)-y), tickLabel(min+width*float64(i)), tickLabel(min+width*float64(i+1)), tickLabel(c))
+	}
+	w.WriteString(`</g>`)
+
+	var positions []float64
+	var labels []string
+	for _, t := range niceTicks(min, max, 5) {
+		if t < min || t > max {
+			continue
+		}
+		positions = append(positions, xScale.at(t))
+		labels = append(labels, tickLabel(t))
+	}
+	w.xAxis(positions, labels)
+	return w.close(), nil
+}
+
+// tickLabel formats an axis value compactly
+func tickLabel(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		s, _ := formatNumber(0, v)
+		return s
+	}
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// num formats a pixel coordinate
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,116 @@
+package dsviz
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNiceTicks(t *testing.T) {
+	cases := []struct {
+		min, max float64
+		n        int
+		expect   []float64
+	}{
+		{0, 10, 5, []float64{0, 2, 4, 6, 8, 10}},
+		{0, 268, 5, []float64{0, 100, 200, 300}},
+		{-4.3, 16.4, 5, []float64{-5, 0, 5, 10, 15, 20}},
+		{0.01, 0.04, 3, []float64{0.01, 0.02, 0.03, 0.04}},
+		{0, 0, 5, []float64{0, 0.2, 0.4, 0.6, 0.8, 1}},
+		{5, 5, 2, []float64{4.5, 5, 5.5}},
+	}
+
+	for i, c := range cases {
+		got := niceTicks(c.min, c.max, c.n)
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestChartOptions(t *testing.T) {
+	cases := []struct {
+		opts []interface{}
+		err  string
+	}{
+		{[]interface{}{"width", 300, "height", 200.0, "title", "t", "xLabel", "x", "yLabel", "y", "color", "#fff", "bins", 3}, ""},
+		{[]interface{}{"color", "steelblue"}, ""},
+		{[]interface{}{"width"}, "bar chart: options must be key-value pairs"},
+		{[]interface{}{1, 2}, "bar chart: option keys must be strings, got int"},
+		{[]interface{}{"depth", 3}, "bar chart: unknown option 'depth'"},
+		{[]interface{}{"width", "wide"}, "bar chart: 'wide' is not a number"},
+		{[]interface{}{"color", `red" onload="alert(1)`}, `bar chart: invalid color 'red" onload="alert(1)'`},
+		{[]interface{}{"width", 50}, "bar chart: size 50x400 is too small"},
+		{[]interface{}{"bins", 0}, "bar chart: bins must be greater than zero"},
+	}
+
+	for i, c := range cases {
+		_, err := newChartCfg(chartBar, "x", "y", c.opts)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+		}
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	titles := []string{"label", "value"}
+	entries := []interface{}{
+		[]interface{}{"<b>a</b>", 1.0},
+		[]interface{}{"b", nil},
+		[]interface{}{"a", 3.0},
+	}
+
+	cases := []struct {
+		description string
+		kind        string
+		x, y        string
+		entries     interface{}
+		contains    []string
+		err         string
+	}{
+		{"bar chart escapes labels", chartBar, "label", "value", entries, []string{`class="chart bar-chart"`, `&lt;b&gt;a&lt;/b&gt;`}, ""},
+		{"bar chart sums categories", chartBar, "label", "value", append(entries, []interface{}{"a", 2.0}), []string{`<title>a: 5</title>`}, ""},
+		{"line chart numeric x", chartLine, "value", "value", entries, []string{`<polyline`}, ""},
+		{"scatter chart", chartScatter, "value", "value", entries, []string{`<circle`}, ""},
+		{"histogram", chartHistogram, "value", "", entries, []string{`class="chart histogram-chart"`, `>count</text>`}, ""},
+		{"single value histogram", chartHistogram, "value", "", []interface{}{[]interface{}{"a", 2.0}}, []string{`<title>2 to 2.1: 1</title>`, `>1</text>`}, ""},
+
+		{"missing column", chartBar, "label", "nope", entries, nil, "bar chart: column: column not found: 'nope'"},
+		{"non numeric y", chartBar, "value", "label", entries, nil, "bar chart: '<b>a</b>' is not a number"},
+		{"no values", chartBar, "label", "value", []interface{}{[]interface{}{"a", nil}}, nil, "bar chart: no values to plot"},
+		{"invalid x", chartLine, "label", "value", entries, nil, "line chart: x values must be numbers or timestamps: timeParse: invalid time '<b>a</b>'"},
+		{"empty histogram", chartHistogram, "value", "", []interface{}{}, nil, "histogram chart: no values to plot"},
+	}
+
+	for _, c := range cases {
+		got, err := renderChart(titles, c.kind, c.x, c.y, c.entries, nil)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case '%s' error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+			continue
+		}
+		for _, s := range c.contains {
+			if !strings.Contains(string(got), s) {
+				t.Errorf("case '%s' expected output to contain '%s'. got:\n%s", c.description, s, got)
+			}
+		}
+	}
+}
+
+func TestTimeTickLayout(t *testing.T) {
+	const day = 24 * 60 * 60
+	cases := []struct {
+		span   float64
+		expect string
+	}{
+		{5 * 365 * day, "2006"},
+		{90 * day, "Jan 2006"},
+		{7 * day, "Jan 2"},
+		{60 * 60, "Jan 2 15:04"},
+	}
+	for i, c := range cases {
+		if got := timeTickLayout(0, c.span); got != c.expect {
+			t.Errorf("case %d expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package dsviz
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+)
+
+// DefaultTemplateName is the name the default template is registered under in
+// PredefinedHTMLTemplates. Custom templates can include the default page with:
+//
+//	{{ template "default" . }}
+const DefaultTemplateName = "default"
+
+// DefaultPreviewPageSize is the default number of body entries shown on each
+// page of a body preview
+const DefaultPreviewPageSize = 50
+
+// DefaultTemplate renders a landing page for any dataset: title, description,
+// commit info, license, a structure summary, a schema table and a paginated
+// body preview. Render uses it for datasets without a viz script
+const DefaultTemplate = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{ title }}</title>
+  <style>
+    body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; margin: 0; }
+    #wrap { max-width: 960px; margin: 0 auto; padding: 0 1em 3em; }
+    header { padding: 2em 0 1em; border-bottom: 1px solid #e1e4e8; }
+    section { padding: 1em 0; }
+    table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+    th, td { border: 1px solid #e1e4e8; padding: 0.3em 0.6em; text-align: left; }
+    th { background: #f6f8fa; }
+    .meta, .pages { color: #586069; font-size: 0.9em; }
+  </style>
+</head>
+<body>
+  <div id="wrap">
+    <header>
+      <h1>{{ title }}</h1>
+      <p class="meta">{{ ds.peername }}/{{ ds.name }}</p>
+      {{- with ds.meta }}{{ with .description }}
+      <div class="description">{{ markdown . }}</div>
+      {{- end }}{{ end }}
+    </header>
+    {{- with ds.commit }}
+    <section class="commit">
+      <h2>Commit</h2>
+      {{- with .title }}
+      <p><strong>{{ . }}</strong></p>
+      {{- end }}
+      {{- with .message }}
+      <p>{{ . }}</p>
+      {{- end }}
+      <p class="meta">
+        {{- with .author }}{{ with .id }}{{ . }} · {{ end }}{{ end -}}
+        {{ timeFormat "2006-01-02 15:04 MST" .timestamp }}
+        {{- with ds.path }} · {{ . }}{{ end -}}
+      </p>
+    </section>
+    {{- end }}
+    {{- with ds.meta }}{{ with .license }}
+    <section class="license">
+      <h2>License</h2>
+      <p>{{ if .url }}<a href="{{ safeURL .url }}">{{ or .type .url }}</a>{{ else }}{{ .type }}{{ end }}</p>
+    </section>
+    {{- end }}{{ end }}
+    {{- with ds.structure }}
+    <section class="structure">
+      <h2>Structure</h2>
+      <p class="meta">{{ .format }} format{{ with .entries }} · {{ . }} entries{{ end }}{{ with .length }} · {{ filesize . }}{{ end }}{{ with .errCount }} · {{ . }} errors{{ end }}</p>
+      {{- with schemaFields }}
+      <table class="schema">
+        <thead><tr><th>field</th><th>type</th><th>description</th></tr></thead>
+        <tbody>
+          {{- range . }}
+          <tr><td>{{ .Title }}</td><td>{{ .Type }}</td><td>{{ .Description }}</td></tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- end }}
+    </section>
+    {{- end }}
+    {{- with bodyPreview }}
+    <section class="body">
+      <h2>Body</h2>
+      {{- if .Rows }}
+      <table class="preview">
+        {{- with .Columns }}
+        <thead><tr><th>#</th>{{ range . }}<th>{{ . }}</th>{{ end }}</tr></thead>
+        {{- end }}
+        <tbody>
+          {{- range $i, $row := .Rows }}
+          <tr><td>{{ $row.Index }}</td>{{ range $row.Cells }}<td>{{ . }}</td>{{ end }}</tr>
+          {{- end }}
+        </tbody>
+      </table>
+      {{- else }}
+      <p class="meta">no entries on this page</p>
+      {{- end }}
+      <p class="pages">
+        {{- if .PrevPage }}<a href="?page={{ .PrevPage }}">previous</a> · {{ end -}}
+        page {{ .Page }}
+        {{- if .NextPage }} · <a href="?page={{ .NextPage }}">next</a>{{ end -}}
+      </p>
+    </section>
+    {{- end }}
+  </div>
+</body>
+</html>
+`
+
+// SchemaField describes one field of a dataset schema
+type SchemaField struct {
+	Title       string
+	Type        string
+	Description string
+}
+
+// schemaFields lists the fields of a schema. Tabular schemas list columns in
+// order, schemas for arrays or maps of objects list properties by name
+func schemaFields(st *dataset.Structure) []SchemaField {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	var fields []SchemaField
+	items, _ := st.Schema["items"].(map[string]interface{})
+	if list, ok := items["items"].([]interface{}); ok {
+		for i, item := range list {
+			col, _ := item.(map[string]interface{})
+			f := schemaField(col)
+			if f.Title == "" {
+				f.Title = fmt.Sprintf("field_%d", i+1)
+			}
+			fields = append(fields, f)
+		}
+		return fields
+	}
+
+	props, _ := items["properties"].(map[string]interface{})
+	if props == nil {
+		props, _ = st.Schema["properties"].(map[string]interface{})
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, _ := props[name].(map[string]interface{})
+		f := schemaField(prop)
+		f.Title = name
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func schemaField(sch map[string]interface{}) SchemaField {
+	f := SchemaField{}
+	f.Title, _ = sch["title"].(string)
+	f.Description, _ = sch["description"].(string)
+	switch t := sch["type"].(type) {
+	case string:
+		f.Type = t
+	case []interface{}:
+		for i, s := range t {
+			if i > 0 {
+				f.Type += ", "
+			}
+			f.Type += fmt.Sprintf("%v", s)
+		}
+	}
+	return f
+}
+
+// Preview is one page of body entries, arranged as rows of printable cells
+type Preview struct {
+	Columns []string
+	Rows    []PreviewRow
+	// Page is the 1-indexed page number
+	Page     int
+	PageSize int
+	// PrevPage and NextPage are adjacent page numbers, zero if there's no page
+	PrevPage int
+	NextPage int
+}
+
+// PreviewRow is a single body entry in a preview
+type PreviewRow struct {
+	// Index is the position of the entry in the body
+	Index int
+	Cells []string
+}
+
+// bodyPreview reads a page of entries from the body. Array entries use column
+// titles from a tabular schema, object bodies are shown as key-value rows
+func bodyPreview(st *dataset.Structure, b *body, page, size int) (*Preview, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = DefaultPreviewPageSize
+	}
+	p := &Preview{Page: page, PageSize: size}
+	if page > 1 {
+		p.PrevPage = page - 1
+	}
+
+	for _, f := range schemaFields(st) {
+		p.Columns = append(p.Columns, f.Title)
+	}
+
+	offset := (page - 1) * size
+	tlt := ""
+	for i := offset; i <= offset+size; i++ {
+		ent, t, ok, err := b.entryAt(i)
+		if err != nil {
+			return nil, err
+		}
+		if tlt = t; !ok {
+			break
+		}
+		if i == offset+size {
+			p.NextPage = page + 1
+			break
+		}
+
+		row := PreviewRow{Index: i}
+		if tlt == "object" {
+			row.Cells = []string{ent.Key, previewCell(ent.Value)}
+		} else {
+			row.Cells = previewCells(p.Columns, ent.Value)
+		}
+		p.Rows = append(p.Rows, row)
+	}
+	if tlt == "object" {
+		p.Columns = []string{"key", "value"}
+	}
+	return p, nil
+}
+
+// previewCells splits an array body entry into cells. Object entries are split
+// by schema property when columns are known
+func previewCells(columns []string, v interface{}) (cells []string) {
+	switch e := v.(type) {
+	case []interface{}:
+		for _, val := range e {
+			cells = append(cells, previewCell(val))
+		}
+	case map[string]interface{}:
+		if len(columns) == 0 {
+			return []string{previewCell(e)}
+		}
+		for _, col := range columns {
+			cells = append(cells, previewCell(e[col]))
+		}
+	default:
+		cells = []string{previewCell(e)}
+	}
+	return cells
+}
+
+// previewCell prints a body value. Objects and arrays print as JSON
+func previewCell(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(x)
+		if err != nil {
+			return fmt.Sprintf("%v", x)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// defaultFuncs are template functions used by the default template
+func defaultFuncs(ds *dataset.Dataset, b *body, cfg *RenderCfg) template.FuncMap {
+	return template.FuncMap{
+		"schemaFields": func() []SchemaField {
+			return schemaFields(ds.Structure)
+		},
+		"bodyPreview": func() (*Preview, error) {
+			if ds.BodyFile() == nil {
+				return nil, nil
+			}
+			return bodyPreview(ds.Structure, b, cfg.PreviewPage, cfg.PreviewPageSize)
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package dsviz
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestRenderDefault(t *testing.T) {
+	ds := newBodyDataset(testBody)
+	ds.Viz = nil
+	got, err := renderString(&dataset.Dataset{Viz: &dataset.Viz{Format: "html"}}, `{{ template "default" . }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "<title>/</title>") {
+		t.Errorf("expected default template to be available to custom templates. got:\n%s", got)
+	}
+
+	prev := PredefinedHTMLTemplates
+	PredefinedHTMLTemplates = nil
+	_, err = Render(ds)
+	PredefinedHTMLTemplates = prev
+	if err == nil || err.Error() != "no viz component" {
+		t.Errorf("expected missing default template to error, got: %v", err)
+	}
+}
+
+func TestBodyPreview(t *testing.T) {
+	cases := []struct {
+		description string
+		body        string
+		schema      map[string]interface{}
+		page, size  int
+		expect      *Preview
+	}{
+		{"first page", testBody, dataset.BaseSchemaArray, 1, 3, &Preview{
+			Page: 1, PageSize: 3, NextPage: 2,
+			Rows: []PreviewRow{{0, []string{"1", "a"}}, {1, []string{"2", "b"}}, {2, []string{"3", "c"}}},
+		}},
+		{"last page", testBody, dataset.BaseSchemaArray, 2, 3, &Preview{
+			Page: 2, PageSize: 3, PrevPage: 1,
+			Rows: []PreviewRow{{3, []string{"4", "d"}}},
+		}},
+		{"exact page", testBody, dataset.BaseSchemaArray, 1, 4, &Preview{
+			Page: 1, PageSize: 4,
+			Rows: []PreviewRow{{0, []string{"1", "a"}}, {1, []string{"2", "b"}}, {2, []string{"3", "c"}}, {3, []string{"4", "d"}}},
+		}},
+		{"past end", testBody, dataset.BaseSchemaArray, 5, 3, &Preview{Page: 5, PageSize: 3, PrevPage: 4}},
+		{"defaults", `[1]`, dataset.BaseSchemaArray, 0, 0, &Preview{
+			Page: 1, PageSize: DefaultPreviewPageSize,
+			Rows: []PreviewRow{{0, []string{"1"}}},
+		}},
+		{"tabular", `[[1,"a"]]`, map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{"type": "array", "items": []interface{}{
+				map[string]interface{}{"title": "id", "type": "integer"},
+				map[string]interface{}{"type": "string"},
+			}},
+		}, 1, 10, &Preview{
+			Columns: []string{"id", "field_2"}, Page: 1, PageSize: 10,
+			Rows: []PreviewRow{{0, []string{"1", "a"}}},
+		}},
+		{"array of objects", `[{"b":[1],"a":"x"},{"a":null}]`, map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+				"a": map[string]interface{}{"type": "string"},
+				"b": map[string]interface{}{"type": "array"},
+			}},
+		}, 1, 10, &Preview{
+			Columns: []string{"a", "b"}, Page: 1, PageSize: 10,
+			Rows: []PreviewRow{{0, []string{"x", "[1]"}}, {1, []string{"", ""}}},
+		}},
+		{"object body", `{"a":{"b":true},"c":2.5}`, dataset.BaseSchemaObject, 1, 10, &Preview{
+			Columns: []string{"key", "value"}, Page: 1, PageSize: 10,
+			Rows: []PreviewRow{{0, []string{"a", `{"b":true}`}}, {1, []string{"c", "2.5"}}},
+		}},
+	}
+
+	for _, c := range cases {
+		ds := newBodyDataset(c.body)
+		ds.Structure.Schema = c.schema
+		b := newBody(ds, DefaultRenderCfg())
+		got, err := bodyPreview(ds.Structure, b, c.page, c.size)
+		b.close()
+		if err != nil {
+			t.Errorf("case '%s' unexpected error: %s", c.description, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case '%s' result mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestRenderDefaultPage(t *testing.T) {
+	ds := newBodyDataset(testBody)
+	ds.Viz = nil
+	f, err := Render(ds, func(cfg *RenderCfg) {
+		cfg.PreviewPage = 2
+		cfg.PreviewPageSize = 1
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, expect := range []string{
+		`<tr><td>1</td><td>2</td><td>b</td></tr>`,
+		`<a href="?page=1">previous</a> · page 2 · <a href="?page=3">next</a>`,
+	} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("expected rendered page to contain %q. got:\n%s", expect, got)
+		}
+	}
+}
@@ -24,12 +24,131 @@ UpperCamelCase naming conventions
 			{{ bodyEntries offset limit }}
 				get body entries within an offset/limit range. passing offset: 0,
 				limit: -1 returns the entire body
+			{{ eachBodyEntry offset limit }}
+				iterate body entries within an offset/limit range without building
+				a list of entries first. use with range:
+				{{ range eachBodyEntry 0 -1 }}{{ .Index }} {{ .Key }} {{ .Value }}{{ end }}
 			{{ filesize }}
 				convert byte count to kb/mb/etc string
 			{{ title }}
 				give the title of a dataset
 			{{ isType $val "type" }}
 				return true or false if the type of $val matches the given type string
-				possible type values are "string", "object", "array", "boolean", "number"
+				possible type values are "string", "object", "array", "boolean",
+				"number", "integer", "null"
+
+		helpers:
+			helpers that accept entries work with the results of bodyEntries,
+			allBodyEntries, and other helpers. fields are referenced by column
+			title or position for tabular data, and by key for object entries
+			{{ timeParse "2019-01-05" }}
+				parse a timestamp string, returning a time value
+			{{ timeFormat "Jan 2, 2006" $val }}
+				format a time, timestamp string or unix seconds value using a go
+				reference time layout
+			{{ formatNumber 2 $val }}
+				format a number with fixed decimal places and comma-separated
+				thousands: 1,234.50
+			{{ column "title" $entries }}
+				list the values of a single field from each entry
+			{{ sortBy "title" $entries }}
+				sort entries by a field in ascending order. an empty field sorts
+				entries by their own value
+			{{ groupBy "title" $entries }}
+				map entries by the value of a field
+			{{ reverse $list }}
+				reverse a list
+			{{ sum $list }} {{ mean $list }} {{ min $list }} {{ max $list }}
+				aggregate a list of numbers, skipping null values
+			{{ toJSON $val }}
+				encode a value as JSON, safe for use in script elements
+			{{ markdown $text }}
+				render markdown text to sanitised HTML
+			{{ safeURL $url }}
+				allow a relative, http(s) or mailto URL in href and src attributes.
+				other URLs are replaced with "#ZgotmplZ"
+			{{ urlJoin $base "path" "elements" }}
+				append escaped path elements to a URL
+
+		charts:
+			chart functions render entries as static SVG, with axes, gridlines and
+			labels. fields are referenced the same way as helpers. trailing
+			key-value pairs set chart options: "width", "height", "title",
+			"xLabel", "yLabel", "color", and "bins" for histograms
+			{{ barChart "x" "y" $entries }}
+				one bar per x category, summing y values of repeated categories
+			{{ lineChart "x" "y" $entries }}
+				a line through points ordered by x. x values can be numbers or
+				timestamps
+			{{ scatterChart "x" "y" $entries }}
+				a point for each entry. x values can be numbers or timestamps
+			{{ histogram "x" $entries "bins" 20 }}
+				count x values into equal-width bins
+
+	default template:
+		datasets without a viz script are rendered with DefaultTemplate, a landing
+		page showing title, description, commit info, license, a structure
+		summary, a schema table and a paginated body preview. The default
+		template is registered in PredefinedHTMLTemplates, custom templates can
+		include it with {{ template "default" . }}. RenderCfg.PreviewPage and
+		RenderCfg.PreviewPageSize choose the page of body entries shown
+		{{ schemaFields }}
+			list schema fields with .Title, .Type and .Description
+		{{ bodyPreview }}
+			a page of body entries as .Columns and .Rows of printable .Cells, with
+			.Page, .PrevPage and .NextPage numbers
+
+	body limits:
+		body entries are decoded in order as templates ask for them, and cached
+		for the rest of the render up to RenderCfg.MaxMemoryBytes. asking for an
+		earlier entry past the cache decodes the body again from bytes recorded
+		during the render, the body file is only read once.
+		Renders that read more than RenderCfg.MaxEntries entries or
+		RenderCfg.MaxBodyBytes bytes of body data fail with an error
+
+	sandboxing:
+		untrusted templates can be rendered with resource limits. RenderCfg sets
+		a Context to bound render time, MaxOutputBytes to cap output size, and
+		AllowedFuncs to restrict the template functions available. Renders that
+		hit any limit abort with a *LimitError, whose Limit field names the limit:
+			render, err := Render(ds, func(cfg *RenderCfg) {
+				cfg.Context = ctx
+				cfg.MaxOutputBytes = 1 << 20
+				cfg.MaxEntries = 1000
+				cfg.AllowedFuncs = []string{"title", "bodyEntries", "barChart"}
+			})
+			if lerr, ok := err.(*LimitError); ok {
+				// lerr.Limit is one of LimitDeadline, LimitOutputBytes,
+				// LimitEntries, LimitBodyBytes or LimitFunc
+			}
+
+Vega-Lite rendering treats the viz script as a Vega-Lite specification. The
+spec is checked against VegaLiteSchema, the dataset body is inlined as the
+spec's data values, and the result is a self-contained HTML page holding the
+spec. The vega runtime isn't bundled with this package, so by default pages
+only draw specs with a single bar, line, point or circle mark, as a static SVG
+rendering of the chart. Other specs need the runtime to draw: set
+RenderCfg.VegaLiteScripts to VegaLiteScripts to load it from a CDN, or to
+self-hosted copies, and pages draw the spec with vega-embed.
+RenderCfg.VegaLiteInlineScripts includes runtime sources in the page instead,
+for interactive pages that work offline
+
+	outline: vega-lite viz specs
+		body entries are converted to Vega-Lite data objects. array entries are
+		keyed by column title, object bodies become {"key", "value"} rows, and
+		other values are wrapped as {"data": value}. Any data url or name in the
+		spec is replaced by the inlined values, and url data in layered,
+		concatenated, faceted or repeated views is removed. body inlining can
+		be configured with a "qri" property in the spec's usermeta:
+			"usermeta": {
+				"qri": {
+					"columns": ["year", "population"],
+					"limit": 100
+				}
+			}
+		columns:
+			include only the listed fields in each data object
+		limit:
+			include at most limit body entries
 */
 package dsviz
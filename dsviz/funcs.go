@@ -0,0 +1,512 @@
+package dsviz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/qri-io/dataset"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// helperFuncs is the library of general-purpose template helpers. Helpers that
+// reference fields by name resolve tabular column titles using the dataset
+// structure
+func helperFuncs(ds *dataset.Dataset) template.FuncMap {
+	titles := columnTitles(ds.Structure)
+	return template.FuncMap{
+		"timeParse":    timeParse,
+		"timeFormat":   timeFormat,
+		"formatNumber": formatNumber,
+		"column": func(key string, entries interface{}) ([]interface{}, error) {
+			return column(titles, key, entries)
+		},
+		"sortBy": func(key string, entries interface{}) ([]interface{}, error) {
+			return sortBy(titles, key, entries)
+		},
+		"groupBy": func(key string, entries interface{}) (map[string][]interface{}, error) {
+			return groupBy(titles, key, entries)
+		},
+		"reverse":  reverse,
+		"sum":      sum,
+		"mean":     mean,
+		"min":      minValue,
+		"max":      maxValue,
+		"toJSON":   toJSON,
+		"markdown": markdown,
+		"safeURL":  safeURL,
+		"urlJoin":  urlJoin,
+	}
+}
+
+// timeLayouts are the formats timeParse accepts, in order of preference
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"01/02/2006",
+}
+
+// timeParse parses a timestamp string
+func timeParse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("timeParse: invalid time '%s'", s)
+}
+
+// timeFormat formats a time using a go reference time layout. v can be a
+// time, a timestamp string, or a number of seconds since the unix epoch
+func timeFormat(layout string, v interface{}) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case string:
+		parsed, err := timeParse(t)
+		if err != nil {
+			return "", err
+		}
+		return parsed.Format(layout), nil
+	case nil:
+		return "", nil
+	}
+
+	secs, err := toFloat(v)
+	if err != nil {
+		return "", fmt.Errorf("timeFormat: can't format value of type %T", v)
+	}
+	sec, frac := math.Modf(secs)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC().Format(layout), nil
+}
+
+// formatNumber formats a number with a fixed number of decimal places and
+// comma-separated thousands
+func formatNumber(decimals int, v interface{}) (string, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return "", fmt.Errorf("formatNumber: %s", err)
+	}
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i:]
+	}
+
+	buf := &bytes.Buffer{}
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		buf.WriteByte('-')
+	}
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteRune(r)
+	}
+	buf.WriteString(frac)
+	return buf.String(), nil
+}
+
+// toFloat converts template values to float64. Body values decode as float64,
+// while numbers written in templates are ints
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a number", n)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("value of type %T is not a number", v)
+}
+
+// columnTitles gets column titles from a tabular schema, returning nil for
+// non-tabular schemas
+func columnTitles(st *dataset.Structure) []string {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	items, ok := st.Schema["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	cols, ok := items["items"].([]interface{})
+	if !ok {
+		return nil
+	}
+	titles := make([]string, len(cols))
+	for i, col := range cols {
+		if c, ok := col.(map[string]interface{}); ok {
+			titles[i], _ = c["title"].(string)
+		}
+	}
+	return titles
+}
+
+// entryList converts the result of body entry functions to a list
+func entryList(entries interface{}) ([]interface{}, error) {
+	switch e := entries.(type) {
+	case []interface{}:
+		return e, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		list := make([]interface{}, len(keys))
+		for i, key := range keys {
+			list[i] = e[key]
+		}
+		return list, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("expected a list of entries, got %T", entries)
+}
+
+// field gets a value from an entry by key. array entries are indexed by column
+// title or numeric position, object entries by key
+func field(titles []string, key string, entry interface{}) (interface{}, error) {
+	switch e := entry.(type) {
+	case []interface{}:
+		for i, title := range titles {
+			if title == key {
+				if i < len(e) {
+					return e[i], nil
+				}
+				return nil, nil
+			}
+		}
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 {
+			if i < len(e) {
+				return e[i], nil
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("column not found: '%s'", key)
+	case map[string]interface{}:
+		return e[key], nil
+	}
+	return nil, fmt.Errorf("can't get field '%s' from value of type %T", key, entry)
+}
+
+// column extracts a single field from each entry
+func column(titles []string, key string, entries interface{}) ([]interface{}, error) {
+	list, err := entryList(entries)
+	if err != nil {
+		return nil, fmt.Errorf("column: %s", err)
+	}
+	col := make([]interface{}, len(list))
+	for i, ent := range list {
+		if col[i], err = field(titles, key, ent); err != nil {
+			return nil, fmt.Errorf("column: %s", err)
+		}
+	}
+	return col, nil
+}
+
+// sortBy returns a copy of entries sorted by a field in ascending order. an
+// empty key sorts entries by their own value
+func sortBy(titles []string, key string, entries interface{}) ([]interface{}, error) {
+	list, err := entryList(entries)
+	if err != nil {
+		return nil, fmt.Errorf("sortBy: %s", err)
+	}
+
+	vals := list
+	if key != "" {
+		vals = make([]interface{}, len(list))
+		for i, ent := range list {
+			if vals[i], err = field(titles, key, ent); err != nil {
+				return nil, fmt.Errorf("sortBy: %s", err)
+			}
+		}
+	}
+
+	idx := make([]int, len(list))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return compareValues(vals[idx[i]], vals[idx[j]]) < 0
+	})
+
+	sorted := make([]interface{}, len(list))
+	for i, j := range idx {
+		sorted[i] = list[j]
+	}
+	return sorted, nil
+}
+
+// compareValues orders nulls first, then numbers, then everything else by
+// string representation
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	af, aErr := toFloat(a)
+	bf, bErr := toFloat(b)
+	_, aStr := a.(string)
+	_, bStr := b.(string)
+	aNum := aErr == nil && !aStr
+	bNum := bErr == nil && !bStr
+	switch {
+	case aNum && bNum:
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+}
+
+// groupBy groups entries by the string value of a field
+func groupBy(titles []string, key string, entries interface{}) (map[string][]interface{}, error) {
+	list, err := entryList(entries)
+	if err != nil {
+		return nil, fmt.Errorf("groupBy: %s", err)
+	}
+
+	groups := map[string][]interface{}{}
+	for _, ent := range list {
+		v, err := field(titles, key, ent)
+		if err != nil {
+			return nil, fmt.Errorf("groupBy: %s", err)
+		}
+		k := ""
+		if v != nil {
+			k = fmt.Sprintf("%v", v)
+		}
+		groups[k] = append(groups[k], ent)
+	}
+	return groups, nil
+}
+
+// reverse returns a copy of a list in reverse order
+func reverse(entries interface{}) ([]interface{}, error) {
+	list, err := entryList(entries)
+	if err != nil {
+		return nil, fmt.Errorf("reverse: %s", err)
+	}
+	rev := make([]interface{}, len(list))
+	for i, v := range list {
+		rev[len(list)-1-i] = v
+	}
+	return rev, nil
+}
+
+// numbers converts a list of values to floats, skipping nulls
+func numbers(name string, vals interface{}) ([]float64, error) {
+	list, err := entryList(vals)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	nums := make([]float64, 0, len(list))
+	for _, v := range list {
+		if v == nil {
+			continue
+		}
+		f, err := toFloat(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		nums = append(nums, f)
+	}
+	return nums, nil
+}
+
+// sum adds a list of numbers
+func sum(vals interface{}) (float64, error) {
+	nums, err := numbers("sum", vals)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, n := range nums {
+		total += n
+	}
+	return total, nil
+}
+
+// mean gives the average of a list of numbers
+func mean(vals interface{}) (float64, error) {
+	nums, err := numbers("mean", vals)
+	if err != nil {
+		return 0, err
+	}
+	if len(nums) == 0 {
+		return 0, fmt.Errorf("mean: no values")
+	}
+	total := 0.0
+	for _, n := range nums {
+		total += n
+	}
+	return total / float64(len(nums)), nil
+}
+
+// minValue gives the smallest of a list of numbers
+func minValue(vals interface{}) (float64, error) {
+	nums, err := numbers("min", vals)
+	if err != nil {
+		return 0, err
+	}
+	if len(nums) == 0 {
+		return 0, fmt.Errorf("min: no values")
+	}
+	m := nums[0]
+	for _, n := range nums[1:] {
+		m = math.Min(m, n)
+	}
+	return m, nil
+}
+
+// maxValue gives the largest of a list of numbers
+func maxValue(vals interface{}) (float64, error) {
+	nums, err := numbers("max", vals)
+	if err != nil {
+		return 0, err
+	}
+	if len(nums) == 0 {
+		return 0, fmt.Errorf("max: no values")
+	}
+	m := nums[0]
+	for _, n := range nums[1:] {
+		m = math.Max(m, n)
+	}
+	return m, nil
+}
+
+// toJSON encodes a value as JSON. The result is marked safe for use as a
+// value in script elements. encoding/json escapes HTML characters, so the
+// output can't close a script element
+func toJSON(v interface{}) (template.JS, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("toJSON: %s", err)
+	}
+	return template.JS(data), nil
+}
+
+// markdown renders markdown text to sanitised HTML
+func markdown(s string) (template.HTML, error) {
+	data, err := Markdown([]byte(s))
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(data), nil
+}
+
+// Markdown converts markdown to sanitised HTML. GitHub flavoured extensions
+// like tables are supported
+func Markdown(src []byte) ([]byte, error) {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		// raw HTML is allowed through here, and removed during sanitisation
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+	buf := &bytes.Buffer{}
+	if err := md.Convert(src, buf); err != nil {
+		return nil, fmt.Errorf("converting markdown: %s", err.Error())
+	}
+	return Sanitize(buf.Bytes()), nil
+}
+
+// Sanitize strips unsafe content like scripts, styles and event handlers from
+// HTML, keeping common formatting elements
+func Sanitize(data []byte) []byte {
+	return bluemonday.UGCPolicy().SanitizeBytes(data)
+}
+
+// unsafeURL replaces rejected URLs, matching the value html/template uses
+const unsafeURL = "#ZgotmplZ"
+
+// safeURLSchemes are the schemes safeURL allows
+var safeURLSchemes = map[string]bool{
+	"":       true,
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// safeURL marks a URL as safe for use in href and src attributes if it's a
+// relative, http(s) or mailto URL. other URLs are replaced with "#ZgotmplZ"
+func safeURL(s string) template.URL {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || !safeURLSchemes[strings.ToLower(u.Scheme)] {
+		return unsafeURL
+	}
+	return template.URL(u.String())
+}
+
+// urlJoin appends path elements to a base URL, escaping special characters
+func urlJoin(base string, elems ...string) (template.URL, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("urlJoin: invalid url '%s'", base)
+	}
+	if !safeURLSchemes[strings.ToLower(u.Scheme)] {
+		return unsafeURL, nil
+	}
+	parts := append([]string{u.Path}, elems...)
+	u.Path = path.Join(parts...)
+	if strings.HasSuffix(base, "/") && len(elems) == 0 {
+		u.Path += "/"
+	}
+	return template.URL(u.String()), nil
+}
@@ -0,0 +1,347 @@
+package dsviz
+
+import (
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset/dstest"
+)
+
+// helpersTestCase loads the dstest viz case used to test helper functions
+func helpersTestCase(t *testing.T) ([]string, []interface{}) {
+	tcs, err := dstest.LoadTestCases("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := tcs["helpers"]
+	funcs, err := NewTemplateFuncs(tc.Input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer funcs.Close()
+
+	entries, err := funcs.body.page(0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return columnTitles(tc.Input.Structure), entries.([]interface{})
+}
+
+func TestTimeParse(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect time.Time
+		err    string
+	}{
+		{"2019-01-05", time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC), ""},
+		{"2019-01-05T10:30:00Z", time.Date(2019, 1, 5, 10, 30, 0, 0, time.UTC), ""},
+		{" 2019-01-05 10:30:00 ", time.Date(2019, 1, 5, 10, 30, 0, 0, time.UTC), ""},
+		{"01/05/2019", time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC), ""},
+		{"yesterday", time.Time{}, "timeParse: invalid time 'yesterday'"},
+	}
+
+	for i, c := range cases {
+		got, err := timeParse(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if !got.Equal(c.expect) {
+			t.Errorf("case %d result mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	ts := time.Date(2019, 1, 5, 10, 30, 0, 0, time.UTC)
+	cases := []struct {
+		layout string
+		in     interface{}
+		expect string
+		err    string
+	}{
+		{"Jan 2, 2006", ts, "Jan 5, 2019", ""},
+		{"2006", &ts, "2019", ""},
+		{"Jan 2, 2006", "2019-01-05", "Jan 5, 2019", ""},
+		{"2006-01-02 15:04", float64(ts.Unix()), "2019-01-05 10:30", ""},
+		{"2006-01-02", nil, "", ""},
+		{"2006", "nope", "", "timeParse: invalid time 'nope'"},
+		{"2006", true, "", "timeFormat: can't format value of type bool"},
+	}
+
+	for i, c := range cases {
+		got, err := timeFormat(c.layout, c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d result mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	cases := []struct {
+		decimals int
+		in       interface{}
+		expect   string
+		err      string
+	}{
+		{0, 0, "0", ""},
+		{2, 1234567.891, "1,234,567.89", ""},
+		{0, 999.5, "1,000", ""},
+		{1, -1234.56, "-1,234.6", ""},
+		{2, -0.001, "0.00", ""},
+		{-1, 12, "12", ""},
+		{0, "4500", "4,500", ""},
+		{0, "many", "", "formatNumber: 'many' is not a number"},
+		{0, nil, "", "formatNumber: value of type <nil> is not a number"},
+	}
+
+	for i, c := range cases {
+		got, err := formatNumber(c.decimals, c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d result mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
+
+func TestColumn(t *testing.T) {
+	titles, entries := helpersTestCase(t)
+	cases := []struct {
+		key     string
+		entries interface{}
+		expect  []interface{}
+		err     string
+	}{
+		{"region", entries, []interface{}{"north", "south", "north", "east"}, ""},
+		{"2", entries, []interface{}{1200.5, int64(300), 45000.25, nil}, ""},
+		{"a", []interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{}}, []interface{}{1, nil}, ""},
+		{"a", map[string]interface{}{"y": map[string]interface{}{"a": 2}, "x": map[string]interface{}{"a": 1}}, []interface{}{1, 2}, ""},
+		{"missing", entries, nil, "column: column not found: 'missing'"},
+		{"a", []interface{}{"scalar"}, nil, "column: can't get field 'a' from value of type string"},
+		{"a", "nope", nil, "column: expected a list of entries, got string"},
+	}
+
+	for i, c := range cases {
+		got, err := column(titles, c.key, c.entries)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	titles, entries := helpersTestCase(t)
+
+	got, err := sortBy(titles, "rainfall", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, _ := column(titles, "date", got)
+	expect := []interface{}{"2019-03-01", "2019-02-10", "2019-01-05", "2019-01-20"}
+	if diff := cmp.Diff(expect, order); diff != "" {
+		t.Errorf("sort by rainfall mismatch (-want +got):\n%s", diff)
+	}
+
+	// sorting must not modify input
+	if first, _ := field(titles, "date", entries[0]); first != "2019-01-05" {
+		t.Errorf("expected input to be unchanged, first date is: %v", first)
+	}
+
+	got, err = sortBy(titles, "", []interface{}{"b", 2.0, nil, "a", 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{nil, 1, 2.0, "a", "b"}, got); diff != "" {
+		t.Errorf("sort values mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := sortBy(titles, "missing", entries); err == nil || err.Error() != "sortBy: column not found: 'missing'" {
+		t.Errorf("expected missing column error, got: %v", err)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	titles, entries := helpersTestCase(t)
+
+	got, err := groupBy(titles, "region", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for key, rows := range got {
+		counts[key] = len(rows)
+	}
+	if diff := cmp.Diff(map[string]int{"north": 2, "south": 1, "east": 1}, counts); diff != "" {
+		t.Errorf("group counts mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = groupBy(titles, "rainfall", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got[""]) != 1 {
+		t.Errorf("expected null values to group under an empty key")
+	}
+
+	if _, err := groupBy(titles, "missing", entries); err == nil || err.Error() != "groupBy: column not found: 'missing'" {
+		t.Errorf("expected missing column error, got: %v", err)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	got, err := reverse([]interface{}{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]interface{}{3, 2, 1}, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := reverse(5); err == nil {
+		t.Error("expected reversing a number to error")
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	titles, entries := helpersTestCase(t)
+	rain, err := column(titles, "rainfall", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		fn     func(interface{}) (float64, error)
+		in     interface{}
+		expect float64
+		err    string
+	}{
+		{"sum", sum, rain, 46500.75, ""},
+		{"sum", sum, []interface{}{}, 0, ""},
+		{"sum", sum, []interface{}{1, "2"}, 3, ""},
+		{"sum", sum, []interface{}{"x"}, 0, "sum: 'x' is not a number"},
+		{"mean", mean, rain, 15500.25, ""},
+		{"mean", mean, []interface{}{nil}, 0, "mean: no values"},
+		{"min", minValue, rain, 300, ""},
+		{"min", minValue, []interface{}{}, 0, "min: no values"},
+		{"max", maxValue, rain, 45000.25, ""},
+		{"max", maxValue, []interface{}{true}, 0, "max: value of type bool is not a number"},
+	}
+
+	for i, c := range cases {
+		got, err := c.fn(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d %s error mismatch. expected: '%s', got: '%v'", i, c.name, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d %s result mismatch. expected: %v, got: %v", i, c.name, c.expect, got)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	_, entries := helpersTestCase(t)
+	got, err := toJSON(entries[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := template.JS(`[["2019-01-05","north",1200.5]]`); got != expect {
+		t.Errorf("result mismatch. expected: %s, got: %s", expect, got)
+	}
+
+	got, err = toJSON("</script>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := template.JS(`"\u003c/script\u003e"`); got != expect {
+		t.Errorf("expected HTML characters to be escaped. expected: %s, got: %s", expect, got)
+	}
+
+	if _, err := toJSON(func() {}); err == nil {
+		t.Error("expected encoding a function to error")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	cases := []struct {
+		in, expect string
+	}{
+		{"**bold**", "<p><strong>bold</strong></p>\n"},
+		{"| a |\n| --- |\n| 1 |", "<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"<script>alert(1)</script>hi", "hi"},
+		{`[link](javascript:alert(1))`, "<p>link</p>\n"},
+	}
+
+	for i, c := range cases {
+		got, err := markdown(c.in)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+		if string(got) != c.expect {
+			t.Errorf("case %d result mismatch. expected: %q, got: %q", i, c.expect, got)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect template.URL
+	}{
+		{"https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{"HTTP://example.com", "http://example.com"},
+		{"/relative/path", "/relative/path"},
+		{"mailto:data@example.com", "mailto:data@example.com"},
+		{"javascript:alert(1)", "#ZgotmplZ"},
+		{" JavaScript:alert(1)", "#ZgotmplZ"},
+		{"data:text/html,hi", "#ZgotmplZ"},
+		{"http://[::1", "#ZgotmplZ"},
+	}
+
+	for i, c := range cases {
+		if got := safeURL(c.in); got != c.expect {
+			t.Errorf("case %d result mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
+
+func TestURLJoin(t *testing.T) {
+	cases := []struct {
+		base   string
+		elems  []string
+		expect template.URL
+		err    string
+	}{
+		{"https://example.com", []string{"a", "b c"}, "https://example.com/a/b%20c", ""},
+		{"https://example.com/data/", []string{"body.json"}, "https://example.com/data/body.json", ""},
+		{"https://example.com/data/", nil, "https://example.com/data/", ""},
+		{"/datasets", []string{"..", "x"}, "/x", ""},
+		{"javascript:alert(1)", []string{"a"}, "#ZgotmplZ", ""},
+		{"http://[::1", nil, "", "urlJoin: invalid url 'http://[::1'"},
+	}
+
+	for i, c := range cases {
+		got, err := urlJoin(c.base, c.elems...)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d result mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package dsviz
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// kinds of render limit
+const (
+	// LimitDeadline is hit when the render context is done
+	LimitDeadline = "deadline"
+	// LimitOutputBytes is hit when a render writes more than
+	// RenderCfg.MaxOutputBytes
+	LimitOutputBytes = "output bytes"
+	// LimitEntries is hit when a render decodes more than RenderCfg.MaxEntries
+	// body entries
+	LimitEntries = "entries"
+	// LimitBodyBytes is hit when a render reads more than RenderCfg.MaxBodyBytes
+	// of body data
+	LimitBodyBytes = "body bytes"
+	// LimitFunc is hit when a template calls a function that isn't in
+	// RenderCfg.AllowedFuncs
+	LimitFunc = "function"
+)
+
+// LimitError is returned when a render is aborted for exceeding one of the
+// limits set in RenderCfg
+type LimitError struct {
+	// Limit is the kind of limit that was hit
+	Limit string
+	// Max is the configured maximum for size and count limits
+	Max int64
+	// Func is the name of a disallowed function, set for LimitFunc errors
+	Func string
+	// Err is the context error, set for LimitDeadline errors
+	Err error
+}
+
+// Error implements the error interface
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case LimitDeadline:
+		return fmt.Sprintf("render deadline exceeded: %s", e.Err)
+	case LimitOutputBytes:
+		return fmt.Sprintf("output size limit exceeded: renders can write at most %d bytes", e.Max)
+	case LimitEntries:
+		return fmt.Sprintf("body entry limit exceeded: templates can read at most %d body entries", e.Max)
+	case LimitBodyBytes:
+		return fmt.Sprintf("body size limit exceeded: templates can read at most %d bytes of body data", e.Max)
+	case LimitFunc:
+		return fmt.Sprintf("template function not allowed: '%s'", e.Func)
+	}
+	return fmt.Sprintf("render limit exceeded: %s", e.Limit)
+}
+
+// limiter tracks the first limit a render hits. Templates wrap function
+// errors with position details, so renders report the recorded error instead
+// of the template execution error
+type limiter struct {
+	ctx context.Context
+	lk  sync.Mutex
+	hit *LimitError
+}
+
+func newLimiter(ctx context.Context) *limiter {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &limiter{ctx: ctx}
+}
+
+// exceed records a limit error, returning it
+func (l *limiter) exceed(err *LimitError) error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if l.hit == nil {
+		l.hit = err
+	}
+	return err
+}
+
+// check errors if the render context is done
+func (l *limiter) check() error {
+	if err := l.ctx.Err(); err != nil {
+		return l.exceed(&LimitError{Limit: LimitDeadline, Err: err})
+	}
+	return nil
+}
+
+// err returns the first limit hit, if any
+func (l *limiter) err() error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if l.hit == nil {
+		return nil
+	}
+	return l.hit
+}
+
+// limitWriter checks the render deadline and output size on each write
+type limitWriter struct {
+	w       io.Writer
+	max     int64
+	written int64
+	lim     *limiter
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if err := w.lim.check(); err != nil {
+		return 0, err
+	}
+	if w.max > 0 && w.written+int64(len(p)) > w.max {
+		return 0, w.lim.exceed(&LimitError{Limit: LimitOutputBytes, Max: w.max})
+	}
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// disallowedFunc stands in for template functions missing from an allow-list.
+// it accepts any arguments so templates still parse, and errors when called
+func disallowedFunc(name string, lim *limiter) func(...interface{}) (interface{}, error) {
+	return func(...interface{}) (interface{}, error) {
+		return nil, lim.exceed(&LimitError{Limit: LimitFunc, Func: name})
+	}
+}
+
+// checkFuncName is the template function injected into parsed templates to
+// check the render deadline
+const checkFuncName = "_dsvizCheckDeadline"
+
+// checkNode calls the check function without writing output. copies are
+// injected at the start of each template and range body
+var checkNode = func() parse.Node {
+	text := "{{ if " + checkFuncName + " }}{{ end }}"
+	trees, err := parse.Parse("check", text, "", "", map[string]interface{}{checkFuncName: true})
+	if err != nil {
+		panic(err)
+	}
+	return trees["check"].Root.Nodes[0]
+}()
+
+// checkFunc is the function checkNode calls. it never passes the if, and
+// aborts execution once the render context is done
+func checkFunc(lim *limiter) func() (bool, error) {
+	return func() (bool, error) {
+		return false, lim.check()
+	}
+}
+
+// injectChecks adds deadline checks to every template associated with tmpl,
+// so executions stop looping or recursing once the render context is done.
+// Executors that aren't go templates are left as-is
+func injectChecks(tmpl Executor, lim *limiter) {
+	var trees []*parse.Tree
+	fn := checkFunc(lim)
+	switch t := tmpl.(type) {
+	case *htmltemplate.Template:
+		t.Funcs(htmltemplate.FuncMap{checkFuncName: fn})
+		for _, at := range t.Templates() {
+			trees = append(trees, at.Tree)
+		}
+	case *template.Template:
+		t.Funcs(template.FuncMap{checkFuncName: fn})
+		for _, at := range t.Templates() {
+			trees = append(trees, at.Tree)
+		}
+	}
+	for _, tree := range trees {
+		if tree != nil && tree.Root != nil {
+			injectCheck(tree.Root)
+			walkChecks(tree.Root)
+		}
+	}
+}
+
+// injectCheck prepends a check to a list of nodes, if it doesn't already
+// start with one
+func injectCheck(list *parse.ListNode) {
+	if len(list.Nodes) > 0 && isCheck(list.Nodes[0]) {
+		return
+	}
+	list.Nodes = append([]parse.Node{checkNode.Copy()}, list.Nodes...)
+}
+
+// walkChecks injects a check into the body of every range within list
+func walkChecks(list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	for _, n := range list.Nodes {
+		switch x := n.(type) {
+		case *parse.RangeNode:
+			if x.List == nil {
+				x.List = &parse.ListNode{NodeType: parse.NodeList}
+			}
+			injectCheck(x.List)
+			walkChecks(x.List)
+			walkChecks(x.ElseList)
+		case *parse.IfNode:
+			walkChecks(x.List)
+			walkChecks(x.ElseList)
+		case *parse.WithNode:
+			walkChecks(x.List)
+			walkChecks(x.ElseList)
+		case *parse.ListNode:
+			walkChecks(x)
+		}
+	}
+}
+
+func isCheck(n parse.Node) bool {
+	ifn, ok := n.(*parse.IfNode)
+	if !ok || ifn.Pipe == nil || len(ifn.Pipe.Cmds) != 1 || len(ifn.Pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	id, ok := ifn.Pipe.Cmds[0].Args[0].(*parse.IdentifierNode)
+	return ok && id.Ident == checkFuncName
+}
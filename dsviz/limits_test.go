@@ -0,0 +1,146 @@
+package dsviz
+
+import (
+	"context"
+	htmltemplate "html/template"
+	"runtime"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestRenderLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		description string
+		tmpl        string
+		cfg         func(cfg *RenderCfg)
+		expect      string
+		limit       string
+		err         string
+	}{
+		{"no limits hit", `{{ len allBodyEntries }}`, nil, "4", "", ""},
+		{"output at limit", `{{ range allBodyEntries }}{{ index . 1 }}{{ end }}`, func(cfg *RenderCfg) { cfg.MaxOutputBytes = 4 }, "abcd", "", ""},
+		{"allowed funcs", `{{ len allBodyEntries }}`, func(cfg *RenderCfg) { cfg.AllowedFuncs = []string{"allBodyEntries"} }, "4", "", ""},
+
+		{"deadline", `{{ len allBodyEntries }}`, func(cfg *RenderCfg) { cfg.Context = cancelled }, "", LimitDeadline,
+			`render deadline exceeded: context canceled`},
+		{"output bytes", `{{ range allBodyEntries }}{{ index . 1 }}{{ end }}`, func(cfg *RenderCfg) { cfg.MaxOutputBytes = 3 }, "", LimitOutputBytes,
+			`output size limit exceeded: renders can write at most 3 bytes`},
+		{"entries", `{{ range eachBodyEntry 0 -1 }}{{ end }}`, func(cfg *RenderCfg) { cfg.MaxEntries = 1 }, "", LimitEntries,
+			`body entry limit exceeded: templates can read at most 1 body entries`},
+		{"body bytes", `{{ bodyEntries 0 4 }}`, func(cfg *RenderCfg) { cfg.MaxBodyBytes = 4 }, "", LimitBodyBytes,
+			`body size limit exceeded: templates can read at most 4 bytes of body data`},
+		{"disallowed func", `{{ title }}{{ len allBodyEntries }}`, func(cfg *RenderCfg) { cfg.AllowedFuncs = []string{"title"} }, "", LimitFunc,
+			`template function not allowed: 'allBodyEntries'`},
+		{"empty allow-list", `{{ title }}`, func(cfg *RenderCfg) { cfg.AllowedFuncs = []string{} }, "", LimitFunc,
+			`template function not allowed: 'title'`},
+	}
+
+	for _, c := range cases {
+		ds := newBodyDataset(testBody)
+		var configs []func(cfg *RenderCfg)
+		if c.cfg != nil {
+			configs = append(configs, c.cfg)
+		}
+		got, err := renderString(ds, c.tmpl, configs...)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case '%s' error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+			continue
+		}
+		if err != nil {
+			lerr, ok := err.(*LimitError)
+			if !ok {
+				t.Errorf("case '%s' expected a *LimitError, got: %T", c.description, err)
+			} else if lerr.Limit != c.limit {
+				t.Errorf("case '%s' limit mismatch. expected: '%s', got: '%s'", c.description, c.limit, lerr.Limit)
+			}
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case '%s' result mismatch. expected: '%s', got: '%s'", c.description, c.expect, got)
+		}
+	}
+}
+
+func TestRenderDeadlineDuringRender(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	ds := newBodyDataset(testBody)
+	funcs, err := NewTemplateFuncs(ds, func(cfg *RenderCfg) { cfg.Context = ctx })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer funcs.Close()
+
+	w := funcs.Writer(&strings.Builder{})
+	for i := 0; i < 100; i++ {
+		if _, err = w.Write([]byte("output")); err != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if lerr, ok := err.(*LimitError); !ok || lerr.Limit != LimitDeadline {
+		t.Errorf("expected writes to abort with a deadline error, got: %v", err)
+	}
+	if funcs.Err() != err {
+		t.Errorf("expected funcs to report the deadline error, got: %v", funcs.Err())
+	}
+}
+
+func TestRenderDeadlineBusyTemplate(t *testing.T) {
+	// nested ranges & recursive templates loop for seconds without writing
+	// output or calling funcs
+	items := make([]struct{}, 15000)
+	cases := []struct {
+		description string
+		tmpl        Executor
+	}{
+		{"html ranges", htmltemplate.Must(htmltemplate.New("busy").Parse(`{{ range .Items }}{{ range $.Items }}{{ end }}{{ end }}`))},
+		{"text recursion", template.Must(template.New("busy").Parse(`{{ define "r" }}{{ if . }}{{ template "r" false }}{{ template "r" true }}{{ end }}{{ end }}{{ template "r" true }}`))},
+	}
+
+	for _, c := range cases {
+		baseline := runtime.NumGoroutine()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		ds := newBodyDataset(testBody)
+		funcs, err := NewTemplateFuncs(ds, func(cfg *RenderCfg) { cfg.Context = ctx })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		start := time.Now()
+		err = funcs.Execute(c.tmpl, &strings.Builder{}, map[string]interface{}{"Items": items})
+		if lerr, ok := err.(*LimitError); !ok || lerr.Limit != LimitDeadline {
+			t.Errorf("case '%s' expected busy template to abort with a deadline error, got: %v", c.description, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("case '%s' expected render to return at the deadline, took %s", c.description, elapsed)
+		}
+		funcs.Close()
+		cancel()
+
+		// the abandoned execution must stop, not spin in the background
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if n := runtime.NumGoroutine(); n > baseline {
+			t.Errorf("case '%s' expected goroutines to return to %d after the render, got %d", c.description, baseline, n)
+		}
+	}
+}
+
+func TestRenderVegaLiteLimits(t *testing.T) {
+	ds := newBodyDataset(testBody)
+	ds.Viz.Format = FormatVegaLite
+	_, err := renderString(ds, `{"mark":"point"}`, func(cfg *RenderCfg) { cfg.MaxOutputBytes = 100 })
+	if lerr, ok := err.(*LimitError); !ok || lerr.Limit != LimitOutputBytes {
+		t.Errorf("expected output limit error, got: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"html/template"
 	"io"
 	"io/ioutil"
+	"math"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
@@ -18,24 +19,33 @@ const htmlTmplName = "index.html"
 // Render executes the viz component of a dataset, returning a resulting file of
 // running the viz script template file, with the host dataset as input. The
 // provided dataset must be fully deserialized, with all files Opened
+// Datasets without a viz script are rendered with the default template
 // Render replaces any file readers it consumes, making the dataset safe for
-// reuse after calling render
-func Render(ds *dataset.Dataset) (qfs.File, error) {
-	if ds.Viz == nil {
-		return nil, fmt.Errorf("no viz component")
+// reuse after calling render. Limits on the body data templates can read
+// default to DefaultRenderCfg, and can be adjusted with configuration functions
+func Render(ds *dataset.Dataset, configs ...func(cfg *RenderCfg)) (qfs.File, error) {
+	if ds.Viz == nil || ds.Viz.ScriptFile() == nil && (ds.Viz.Format == "" || ds.Viz.Format == "html") {
+		return renderDefault(ds, configs)
 	}
-	if ds.Viz.Format != "html" {
-		return nil, fmt.Errorf("render format must be 'html'")
+	switch ds.Viz.Format {
+	case "html":
+		return renderHTML(ds, configs)
+	case FormatVegaLite:
+		return renderVegaLite(ds, configs)
+	default:
+		return nil, fmt.Errorf("render format must be 'html' or 'vega-lite'")
 	}
-	return renderHTML(ds)
 }
 
 // PredefinedHTMLTemplates is a key-value set of templates to be add to HTML
 // renders. {{ block }} elements defined in any templates here will be available
-// to passed-in dataset template files used during Render
-var PredefinedHTMLTemplates map[string]string
+// to passed-in dataset template files used during Render. The default template
+// is registered as DefaultTemplateName
+var PredefinedHTMLTemplates = map[string]string{
+	DefaultTemplateName: DefaultTemplate,
+}
 
-func renderHTML(ds *dataset.Dataset) (qfs.File, error) {
+func renderHTML(ds *dataset.Dataset, configs []func(cfg *RenderCfg)) (qfs.File, error) {
 	script := ds.Viz.ScriptFile()
 	// tee the viz file to avoid losing script data
 	vizScriptBuf := &bytes.Buffer{}
@@ -50,36 +60,25 @@ func renderHTML(ds *dataset.Dataset) (qfs.File, error) {
 	// restore consumed script file
 	ds.Viz.SetScriptFile(qfs.NewMemfileReader(script.FileName(), vizScriptBuf))
 
-	vizDs, err := vizDataset(ds)
+	return executeHTML(ds, tmplBytes, configs)
+}
+
+// renderDefault renders a dataset with the predefined default template
+func renderDefault(ds *dataset.Dataset, configs []func(cfg *RenderCfg)) (qfs.File, error) {
+	if _, ok := PredefinedHTMLTemplates[DefaultTemplateName]; !ok {
+		return nil, fmt.Errorf("no viz component")
+	}
+	return executeHTML(ds, []byte(`{{ template "`+DefaultTemplateName+`" . }}`), configs)
+}
+
+func executeHTML(ds *dataset.Dataset, tmplBytes []byte, configs []func(cfg *RenderCfg)) (qfs.File, error) {
+	funcs, err := NewTemplateFuncs(ds, configs...)
 	if err != nil {
 		return nil, err
 	}
+	defer funcs.Close()
 
-	tmpl := template.New(htmlTmplName)
-
-	tmpl.Funcs(template.FuncMap{
-		"ds": func() map[string]interface{} {
-			return vizDs
-		},
-		"bodyEntries":    bodyEntriesFunc(ds),
-		"allBodyEntries": allBodyEntriesFunc(ds),
-		"filesize": func(n float64) string {
-			return printByteInfo(int(n))
-		},
-		"isType": isType,
-		"title": func() string {
-			if ds.Meta != nil && ds.Meta.Title != "" {
-				return ds.Meta.Title
-			}
-			return fmt.Sprintf("%s/%s", ds.Peername, ds.Name)
-		},
-		// TODO (b5):
-		// {{ timeParse }}
-		// 	parse a timestamp string, returning a golang *time.Time struct
-		// {{ timeFormat }}
-		// 	convert the textual representation of the datetime into the specified
-		// 	format using a template date
-	})
+	tmpl := template.New(htmlTmplName).Funcs(funcs.FuncMap)
 
 	for name, tmplText := range PredefinedHTMLTemplates {
 		tmpl.New(name).Parse(tmplText)
@@ -91,13 +90,156 @@ func renderHTML(ds *dataset.Dataset) (qfs.File, error) {
 
 	// do the render
 	tmplBuf := &bytes.Buffer{}
-	if err := tmpl.Execute(tmplBuf, ds); err != nil {
+	if err := funcs.Execute(tmpl, tmplBuf, ds); err != nil {
 		return nil, err
 	}
 
 	return qfs.NewMemfileReader(htmlTmplName, tmplBuf), nil
 }
 
+// TemplateFuncs is the set of functions available to templates, bound to a
+// dataset for the duration of a single render. Other template renderers like
+// readmes use the same function set so templates behave the same across
+// components. The body file is read once per render, callers must call Close
+// when rendering is complete to restore the dataset body file
+type TemplateFuncs struct {
+	FuncMap template.FuncMap
+	cfg     *RenderCfg
+	body    *body
+	allowed map[string]bool
+}
+
+// NewTemplateFuncs binds template functions to a dataset
+func NewTemplateFuncs(ds *dataset.Dataset, configs ...func(cfg *RenderCfg)) (*TemplateFuncs, error) {
+	cfg := DefaultRenderCfg()
+	for _, opt := range configs {
+		opt(cfg)
+	}
+
+	vizDs, err := vizDataset(ds)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBody(ds, cfg)
+	if err := b.lim.check(); err != nil {
+		return nil, err
+	}
+	funcs := &TemplateFuncs{
+		cfg:  cfg,
+		body: b,
+		FuncMap: template.FuncMap{
+			"ds": func() map[string]interface{} {
+				return vizDs
+			},
+			"bodyEntries": func(offset, limit int) (interface{}, error) {
+				return b.page(offset, limit)
+			},
+			"allBodyEntries": func() (interface{}, error) {
+				return b.page(0, -1)
+			},
+			"eachBodyEntry": func(offset, limit int) <-chan dsio.Entry {
+				return b.iterate(offset, limit)
+			},
+			"filesize": func(n float64) string {
+				return printByteInfo(int(n))
+			},
+			"isType": isType,
+			"title": func() string {
+				if ds.Meta != nil && ds.Meta.Title != "" {
+					return ds.Meta.Title
+				}
+				return fmt.Sprintf("%s/%s", ds.Peername, ds.Name)
+			},
+		},
+	}
+	for name, fn := range helperFuncs(ds) {
+		funcs.FuncMap[name] = fn
+	}
+	for name, fn := range chartFuncs(columnTitles(ds.Structure)) {
+		funcs.FuncMap[name] = fn
+	}
+	for name, fn := range defaultFuncs(ds, b, cfg) {
+		funcs.FuncMap[name] = fn
+	}
+
+	if cfg.AllowedFuncs != nil {
+		funcs.allowed = map[string]bool{}
+		for _, name := range cfg.AllowedFuncs {
+			funcs.allowed[name] = true
+		}
+		for name, fn := range funcs.FuncMap {
+			funcs.Set(name, fn)
+		}
+	}
+	return funcs, nil
+}
+
+// Set adds a function to the function map, replacing any function of the same
+// name. Functions missing from RenderCfg.AllowedFuncs are replaced by a stub
+// that aborts the render when called
+func (f *TemplateFuncs) Set(name string, fn interface{}) {
+	if f.allowed != nil && !f.allowed[name] {
+		fn = disallowedFunc(name, f.body.lim)
+	}
+	f.FuncMap[name] = fn
+}
+
+// Writer wraps a render destination, enforcing the configured deadline and
+// output size limit
+func (f *TemplateFuncs) Writer(w io.Writer) io.Writer {
+	return &limitWriter{w: w, max: f.cfg.MaxOutputBytes, lim: f.body.lim}
+}
+
+// Executor is a parsed template, satisfied by both html/template and
+// text/template templates
+type Executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// Execute renders a template to w within the configured limits, returning any
+// limit hit as a *LimitError. Templates can spin without writing output or
+// calling functions, so Execute injects a deadline check into the start of
+// every template and range body of html/template & text/template templates.
+// Execution runs in a goroutine and Execute returns as soon as the render
+// context is done, the execution stops at its next check
+func (f *TemplateFuncs) Execute(tmpl Executor, w io.Writer, data interface{}) error {
+	injectChecks(tmpl, f.body.lim)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tmpl.Execute(f.Writer(w), data)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			if lerr := f.Err(); lerr != nil {
+				return lerr
+			}
+			return err
+		}
+		return f.Err()
+	case <-f.body.lim.ctx.Done():
+		return f.body.lim.check()
+	}
+}
+
+// Err returns the first limit hit during the render as a *LimitError, or the
+// first error encountered by body entry iterators, which can't report errors
+// to the template directly
+func (f *TemplateFuncs) Err() error {
+	if err := f.body.lim.err(); err != nil {
+		return err
+	}
+	return f.body.err()
+}
+
+// Close stops any running body iterators and restores the dataset body file
+func (f *TemplateFuncs) Close() {
+	f.body.close()
+}
+
 // isType
 func isType(in interface{}, eq string) (bool, error) {
 	switch eq {
@@ -122,11 +264,16 @@ func isType(in interface{}, eq string) (bool, error) {
 			return true, nil
 		}
 		return false, nil
-	// TODO (b5):
-	// case "integer":
-	// TODO (b5):
-	// case "null":
-	// 	return in == nil, nil
+	case "integer":
+		switch n := in.(type) {
+		case int, int64:
+			return true, nil
+		case float64:
+			return n == math.Trunc(n) && !math.IsInf(n, 0), nil
+		}
+		return false, nil
+	case "null":
+		return in == nil, nil
 	default:
 		return false, fmt.Errorf("invalid type comparison value: '%s'", eq)
 	}
@@ -142,85 +289,6 @@ func vizDataset(ds *dataset.Dataset) (vizDs map[string]interface{}, err error) {
 	return
 }
 
-func allBodyEntriesFunc(ds *dataset.Dataset) func() (interface{}, error) {
-	return func() (interface{}, error) {
-		return bodyEntries(ds, 0, -1)
-	}
-}
-
-func bodyEntriesFunc(ds *dataset.Dataset) func(offset int, limit int) (interface{}, error) {
-	return func(offset, limit int) (interface{}, error) {
-		return bodyEntries(ds, offset, limit)
-	}
-}
-
-func bodyEntries(ds *dataset.Dataset, offset, limit int) (interface{}, error) {
-	if ds.Structure == nil {
-		return nil, fmt.Errorf("can't get_body. dataset has no structure component")
-	}
-
-	// load all body data
-	bodyFile := ds.BodyFile()
-	bodyBytesBuf := &bytes.Buffer{}
-	tr := io.TeeReader(bodyFile, bodyBytesBuf)
-	rr, err := dsio.NewEntryReader(ds.Structure, tr)
-	if err != nil {
-		return nil, fmt.Errorf("error allocating data reader: %s", err)
-	}
-
-	if offset >= 0 && limit >= 0 {
-		rr = &dsio.PagedReader{
-			Reader: rr,
-			Offset: offset,
-			Limit:  limit,
-		}
-	}
-
-	bodyEntries, err := readEntries(rr)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		// restore body file
-		ds.SetBodyFile(qfs.NewMemfileReader(bodyFile.FileName(), bodyBytesBuf))
-	}()
-
-	return bodyEntries, nil
-}
-
-// readEntries reads entries and returns them as a native go array or map
-// COPIED from github.com/qri-io/qri/base/entries.go
-func readEntries(reader dsio.EntryReader) (interface{}, error) {
-	obj := make(map[string]interface{})
-	array := make([]interface{}, 0)
-
-	tlt, err := dsio.GetTopLevelType(reader.Structure())
-	if err != nil {
-		return nil, err
-	}
-
-	for i := 0; ; i++ {
-		val, err := reader.ReadEntry()
-		if err != nil {
-			if err.Error() == "EOF" {
-				break
-			}
-			return nil, err
-		}
-		if tlt == "object" {
-			obj[val.Key] = val.Value
-		} else {
-			array = append(array, val.Value)
-		}
-	}
-
-	if tlt == "object" {
-		return obj, nil
-	}
-	return array, nil
-}
-
 const (
 	bite = 1 << (10 * iota)
 	kilobyte
@@ -11,11 +11,11 @@ import (
 )
 
 func TestRenderHTML(t *testing.T) {
-	if _, err := Render(&dataset.Dataset{}); err == nil {
-		t.Error("expected ds with no viz to error")
+	if _, err := Render(&dataset.Dataset{}); err != nil {
+		t.Errorf("expected ds with no viz to render the default template, got error: %s", err)
 	}
 	if _, err := Render(&dataset.Dataset{Viz: &dataset.Viz{Format: "WebGL"}}); err == nil {
-		t.Error("expected non-html viz format to error")
+		t.Error("expected unsupported viz format to error")
 	}
 
 	tcs, err := dstest.LoadTestCases("testdata")
@@ -35,6 +35,30 @@ func TestRenderHTML(t *testing.T) {
 		t.Fatal(err)
 	}
 	checkResult(t, tc, rendered)
+
+	tc = tcs["helpers"]
+	if rendered, err = Render(tc.Input); err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, tc, rendered)
+
+	tc = tcs["charts"]
+	if rendered, err = Render(tc.Input); err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, tc, rendered)
+
+	tc = tcs["vegalite"]
+	if rendered, err = Render(tc.Input); err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, tc, rendered)
+
+	tc = tcs["landing"]
+	if rendered, err = Render(tc.Input); err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, tc, rendered)
 }
 
 func checkResult(t *testing.T, tc dstest.TestCase, rendered qfs.File) {
@@ -59,6 +83,9 @@ func checkResult(t *testing.T, tc dstest.TestCase, rendered qfs.File) {
 }
 
 func TestPredefinedHTML(t *testing.T) {
+	prev := PredefinedHTMLTemplates
+	defer func() { PredefinedHTMLTemplates = prev }()
+
 	PredefinedHTMLTemplates = map[string]string{
 		"hi friend":  `{{ block "special_sauce" . }}<h1>special sauce</h1>{{ end }}`,
 		"bye friend": `{{ block "groovy_gravy" . }}<p>alright, what?</p>{{ end }}`,
@@ -129,6 +156,10 @@ func TestIsType(t *testing.T) {
 {{- if isType $data.str "boolean" }}NO!{{ end }}
 {{ if isType $data.bool "boolean" }}boolean{{ end }}
 {{ if isType $data.num "number" }}number{{ end }}
+{{ if isType $data.num "integer" }}integer{{ end }}
+{{- if isType $data.float "integer" }}NO!{{ end }}
+{{ if isType $data.null "null" }}null{{ end }}
+{{- if isType $data.str "null" }}NO!{{ end }}
 `
 
 	body := `{
@@ -137,7 +168,8 @@ func TestIsType(t *testing.T) {
 		"str": "",
 		"bool": false,
 		"null": null,
-		"num": 4
+		"num": 4,
+		"float": 4.5
 	}`
 
 	ds := &dataset.Dataset{
@@ -167,6 +199,8 @@ array
 string
 boolean
 number
+integer
+null
 `
 
 	if string(got) != exp {
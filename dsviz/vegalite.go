@@ -0,0 +1,454 @@
+package dsviz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qfs"
+)
+
+// FormatVegaLite is the viz format for Vega-Lite specifications
+const FormatVegaLite = "vega-lite"
+
+// VegaLiteScripts are the jsdelivr CDN URLs of the vega, vega-lite and
+// vega-embed runtimes. The runtime isn't bundled with this package, and pages
+// don't load it unless configured to. Set RenderCfg.VegaLiteScripts to these
+// URLs to opt in to drawing interactive charts from the CDN, at the cost of
+// pages that depend on network access to jsdelivr
+var VegaLiteScripts = []string{
+	"https://cdn.jsdelivr.net/npm/vega@5",
+	"https://cdn.jsdelivr.net/npm/vega-lite@4",
+	"https://cdn.jsdelivr.net/npm/vega-embed@6",
+}
+
+// VegaLiteSchema is the JSON schema vega-lite viz specs are checked against.
+// It's a structural subset of the Vega-Lite v4 schema: top level properties,
+// mark types, encoding channels and field definition types are checked,
+// deeper properties are left to the Vega-Lite runtime
+const VegaLiteSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Vega-Lite specification",
+  "type": "object",
+  "properties": {
+    "$schema": { "type": "string" },
+    "align": { "type": ["string", "object"] },
+    "autosize": { "type": ["string", "object"] },
+    "background": { "type": "string" },
+    "bounds": { "type": "string" },
+    "center": { "type": ["boolean", "object"] },
+    "columns": { "type": "number" },
+    "concat": { "$ref": "#/definitions/specList" },
+    "config": { "type": "object" },
+    "data": { "type": ["object", "null"] },
+    "datasets": { "type": "object" },
+    "description": { "type": "string" },
+    "encoding": { "$ref": "#/definitions/encoding" },
+    "facet": { "type": "object" },
+    "hconcat": { "$ref": "#/definitions/specList" },
+    "height": { "$ref": "#/definitions/size" },
+    "layer": { "$ref": "#/definitions/specList" },
+    "mark": { "$ref": "#/definitions/mark" },
+    "name": { "type": "string" },
+    "padding": { "type": ["number", "object"] },
+    "params": { "type": "array" },
+    "projection": { "type": "object" },
+    "repeat": { "type": ["array", "object"] },
+    "resolve": { "type": "object" },
+    "selection": { "type": "object" },
+    "spacing": { "type": ["number", "object"] },
+    "spec": { "type": "object" },
+    "title": { "type": ["string", "array", "object"] },
+    "transform": { "type": "array", "items": { "type": "object" } },
+    "usermeta": { "type": "object" },
+    "vconcat": { "$ref": "#/definitions/specList" },
+    "view": { "type": "object" },
+    "width": { "$ref": "#/definitions/size" }
+  },
+  "additionalProperties": false,
+  "anyOf": [
+    { "required": ["mark"] },
+    { "required": ["layer"] },
+    { "required": ["concat"] },
+    { "required": ["hconcat"] },
+    { "required": ["vconcat"] },
+    { "required": ["facet", "spec"] },
+    { "required": ["repeat", "spec"] }
+  ],
+  "definitions": {
+    "markType": {
+      "enum": ["arc", "area", "bar", "boxplot", "circle", "errorband", "errorbar", "geoshape", "image", "line", "point", "rect", "rule", "square", "text", "tick", "trail"]
+    },
+    "mark": {
+      "anyOf": [
+        { "$ref": "#/definitions/markType" },
+        {
+          "type": "object",
+          "properties": { "type": { "$ref": "#/definitions/markType" } },
+          "required": ["type"]
+        }
+      ]
+    },
+    "size": {
+      "anyOf": [
+        { "type": "number", "minimum": 0 },
+        { "const": "container" },
+        { "type": "object" }
+      ]
+    },
+    "specList": { "type": "array", "items": { "type": "object" } },
+    "encoding": {
+      "type": "object",
+      "propertyNames": {
+        "enum": ["x", "y", "x2", "y2", "xError", "yError", "xError2", "yError2", "theta", "theta2", "radius", "radius2", "longitude", "latitude", "longitude2", "latitude2", "color", "fill", "stroke", "opacity", "fillOpacity", "strokeOpacity", "strokeWidth", "strokeDash", "size", "angle", "shape", "text", "tooltip", "href", "url", "description", "detail", "key", "order", "row", "column", "facet"]
+      },
+      "additionalProperties": {
+        "anyOf": [
+          { "$ref": "#/definitions/channelDef" },
+          { "type": "array", "items": { "$ref": "#/definitions/channelDef" } },
+          { "type": "null" }
+        ]
+      }
+    },
+    "channelDef": {
+      "type": "object",
+      "properties": {
+        "field": { "type": ["string", "object"] },
+        "type": { "enum": ["quantitative", "temporal", "ordinal", "nominal", "geojson"] },
+        "aggregate": { "type": ["string", "object"] },
+        "bin": { "type": ["boolean", "object", "string", "null"] },
+        "timeUnit": { "type": ["string", "object"] },
+        "title": { "type": ["string", "array", "null"] }
+      }
+    }
+  }
+}`
+
+var vegaLiteSchema = jsonschema.Must(VegaLiteSchema)
+
+// ValidateVegaLite checks a Vega-Lite spec against VegaLiteSchema
+func ValidateVegaLite(spec []byte) error {
+	errs, err := vegaLiteSchema.ValidateBytes(spec)
+	if err != nil {
+		return fmt.Errorf("invalid vega-lite spec: %s", err)
+	}
+	if len(errs) > 0 {
+		for i := range errs {
+			if errs[i].PropertyPath == "" {
+				errs[i].PropertyPath = "/"
+			}
+		}
+		return fmt.Errorf("invalid vega-lite spec: %s", dataset.SchemaError(errs))
+	}
+	return nil
+}
+
+// vegaLiteOptions control how the dataset body is inlined into a spec. They're
+// read from the spec's "usermeta.qri" property:
+//
+//	"usermeta": { "qri": { "columns": ["year", "population"], "limit": 100 } }
+type vegaLiteOptions struct {
+	// Columns projects body entries to only the given fields
+	Columns []string `json:"columns,omitempty"`
+	// Limit caps the number of body entries included in the spec
+	Limit *int `json:"limit,omitempty"`
+}
+
+func readVegaLiteOptions(spec map[string]interface{}) (*vegaLiteOptions, error) {
+	opts := &vegaLiteOptions{}
+	usermeta, ok := spec["usermeta"].(map[string]interface{})
+	if !ok || usermeta["qri"] == nil {
+		return opts, nil
+	}
+
+	data, err := json.Marshal(usermeta["qri"])
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, opts); err != nil {
+		return nil, fmt.Errorf("invalid vega-lite spec: /usermeta/qri: %s", err)
+	}
+	if opts.Limit != nil && *opts.Limit < 0 {
+		return nil, fmt.Errorf("invalid vega-lite spec: /usermeta/qri/limit: must be zero or greater")
+	}
+	return opts, nil
+}
+
+func renderVegaLite(ds *dataset.Dataset, configs []func(cfg *RenderCfg)) (qfs.File, error) {
+	script := ds.Viz.ScriptFile()
+	if script == nil {
+		return nil, fmt.Errorf("vega-lite viz has no spec")
+	}
+	// tee the spec file to avoid losing script data
+	specBuf := &bytes.Buffer{}
+	specBytes, err := ioutil.ReadAll(io.TeeReader(script, specBuf))
+	if err != nil {
+		return nil, fmt.Errorf("reading vega-lite spec: %s", err.Error())
+	}
+	// restore consumed script file
+	ds.Viz.SetScriptFile(qfs.NewMemfileReader(script.FileName(), specBuf))
+
+	if err := ValidateVegaLite(specBytes); err != nil {
+		return nil, err
+	}
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal(specBytes, &spec); err != nil {
+		return nil, fmt.Errorf("invalid vega-lite spec: %s", err)
+	}
+	opts, err := readVegaLiteOptions(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultRenderCfg()
+	for _, opt := range configs {
+		opt(cfg)
+	}
+	b := newBody(ds, cfg)
+	defer b.close()
+	if err := b.lim.check(); err != nil {
+		return nil, err
+	}
+
+	limit := -1
+	if opts.Limit != nil {
+		limit = *opts.Limit
+	}
+	entries, err := b.page(0, limit)
+	if err != nil {
+		return nil, err
+	}
+	values, err := vegaLiteValues(columnTitles(ds.Structure), entries, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	inlineValues(spec, values)
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	pageTitle := ""
+	if ds.Meta != nil && ds.Meta.Title != "" {
+		pageTitle = ds.Meta.Title
+	} else if t, ok := spec["title"].(string); ok {
+		pageTitle = t
+	} else {
+		pageTitle = fmt.Sprintf("%s/%s", ds.Peername, ds.Name)
+	}
+
+	buf := &bytes.Buffer{}
+	w := &limitWriter{w: buf, max: cfg.MaxOutputBytes, lim: b.lim}
+	err = vegaLitePage.Execute(w, map[string]interface{}{
+		"Title":    pageTitle,
+		"Scripts":  cfg.VegaLiteScripts,
+		"Inline":   inlineScripts(cfg.VegaLiteInlineScripts),
+		"Spec":     template.JS(specJSON),
+		"Fallback": vegaLiteFallback(spec, values),
+	})
+	if err != nil {
+		if lerr := b.lim.err(); lerr != nil {
+			return nil, lerr
+		}
+		return nil, err
+	}
+	return qfs.NewMemfileReader(htmlTmplName, buf), nil
+}
+
+// vegaLiteValues converts body entries to the list of objects Vega-Lite uses
+// for inline data. Tabular rows are keyed by column title, object bodies
+// become key-value rows, and other values are wrapped in a "data" field,
+// matching how Vega-Lite treats primitive values
+func vegaLiteValues(titles []string, entries interface{}, columns []string) ([]interface{}, error) {
+	var rows []map[string]interface{}
+	switch e := entries.(type) {
+	case []interface{}:
+		for _, ent := range e {
+			switch v := ent.(type) {
+			case map[string]interface{}:
+				rows = append(rows, v)
+			case []interface{}:
+				row := make(map[string]interface{}, len(v))
+				for i, val := range v {
+					name := fmt.Sprintf("field_%d", i+1)
+					if i < len(titles) && titles[i] != "" {
+						name = titles[i]
+					}
+					row[name] = val
+				}
+				rows = append(rows, row)
+			default:
+				rows = append(rows, map[string]interface{}{"data": v})
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			rows = append(rows, map[string]interface{}{"key": key, "value": e[key]})
+		}
+	}
+
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		if len(columns) == 0 {
+			values[i] = row
+			continue
+		}
+		projected := make(map[string]interface{}, len(columns))
+		for _, col := range columns {
+			v, ok := row[col]
+			if !ok && !hasTitle(titles, col) {
+				return nil, fmt.Errorf("vega-lite: column not found: '%s'", col)
+			}
+			projected[col] = v
+		}
+		values[i] = projected
+	}
+	return values, nil
+}
+
+func hasTitle(titles []string, col string) bool {
+	for _, t := range titles {
+		if t == col {
+			return true
+		}
+	}
+	return false
+}
+
+// inlineValues sets the dataset body as the spec's top level data, replacing
+// any url or named data source. Other data properties like format are kept.
+// Sub-specs that load data from a url have their data removed, so they use the
+// inlined body instead
+func inlineValues(spec map[string]interface{}, values []interface{}) {
+	data, ok := spec["data"].(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+	}
+	delete(data, "url")
+	delete(data, "name")
+	delete(data, "sequence")
+	delete(data, "sphere")
+	delete(data, "graticule")
+	data["values"] = values
+	spec["data"] = data
+	stripSubSpecURLs(spec)
+}
+
+// stripSubSpecURLs removes url data sources from the layered, concatenated,
+// faceted & repeated views of a spec
+func stripSubSpecURLs(spec map[string]interface{}) {
+	var subs []interface{}
+	for _, key := range []string{"layer", "concat", "hconcat", "vconcat"} {
+		if list, ok := spec[key].([]interface{}); ok {
+			subs = append(subs, list...)
+		}
+	}
+	subs = append(subs, spec["spec"])
+
+	for _, s := range subs {
+		sub, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if data, ok := sub["data"].(map[string]interface{}); ok && data["url"] != nil {
+			delete(sub, "data")
+		}
+		stripSubSpecURLs(sub)
+	}
+}
+
+// vegaLiteFallback draws a static SVG chart for single-view specs that map
+// onto the dsviz chart functions, so pages show a chart even without
+// JavaScript. Specs that can't be drawn give no fallback
+func vegaLiteFallback(spec map[string]interface{}, values []interface{}) template.HTML {
+	mark, ok := spec["mark"].(string)
+	if m, isObj := spec["mark"].(map[string]interface{}); isObj {
+		mark, ok = m["type"].(string)
+	}
+	if !ok {
+		return ""
+	}
+	enc, _ := spec["encoding"].(map[string]interface{})
+	x, _ := enc["x"].(map[string]interface{})
+	y, _ := enc["y"].(map[string]interface{})
+	xField, _ := x["field"].(string)
+	yField, _ := y["field"].(string)
+
+	opts := []interface{}{}
+	if t, ok := spec["title"].(string); ok {
+		opts = append(opts, "title", t)
+	}
+	// vega-lite sizes describe the plot area, chart sizes include margins
+	if w, ok := spec["width"].(float64); ok {
+		opts = append(opts, "width", w+marginLeft+marginRight)
+	}
+	if h, ok := spec["height"].(float64); ok {
+		opts = append(opts, "height", h+marginTop+marginBottom)
+	}
+
+	var (
+		svg template.HTML
+		err error
+	)
+	switch {
+	case mark == "bar" && x["bin"] != nil && x["bin"] != false && xField != "":
+		svg, err = renderChart(nil, chartHistogram, xField, "", values, opts)
+	case mark == "bar" && xField != "" && yField != "":
+		svg, err = renderChart(nil, chartBar, xField, yField, values, opts)
+	case mark == "line" && xField != "" && yField != "":
+		svg, err = renderChart(nil, chartLine, xField, yField, values, opts)
+	case (mark == "point" || mark == "circle") && xField != "" && yField != "":
+		svg, err = renderChart(nil, chartScatter, xField, yField, values, opts)
+	default:
+		return ""
+	}
+	if err != nil {
+		return ""
+	}
+	return svg
+}
+
+// inlineScripts marks trusted javascript sources for inclusion in a page
+func inlineScripts(srcs []string) []template.JS {
+	scripts := make([]template.JS, len(srcs))
+	for i, src := range srcs {
+		scripts[i] = template.JS(src)
+	}
+	return scripts
+}
+
+var vegaLitePage = template.Must(template.New(htmlTmplName).Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{ .Title }}</title>
+  {{- range .Scripts }}
+  <script src="{{ . }}"></script>
+  {{- end }}
+  {{- range .Inline }}
+  <script>{{ . }}</script>
+  {{- end }}
+</head>
+<body>
+  <div id="vis">{{ .Fallback }}</div>
+  <script type="application/json" id="vega-lite-spec">{{ .Spec }}</script>
+  <script>
+    if (typeof vegaEmbed !== "undefined") {
+      vegaEmbed("#vis", JSON.parse(document.getElementById("vega-lite-spec").textContent), { actions: false });
+    }
+  </script>
+</body>
+</html>
+`))
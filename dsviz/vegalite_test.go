@@ -0,0 +1,174 @@
+package dsviz
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestValidateVegaLite(t *testing.T) {
+	cases := []struct {
+		description string
+		spec        string
+		err         string
+	}{
+		{"bar", `{"mark":"bar","encoding":{"x":{"field":"a","type":"nominal"}}}`, ""},
+		{"mark object", `{"mark":{"type":"line","point":true}}`, ""},
+		{"layer", `{"layer":[{"mark":"rule"}]}`, ""},
+		{"repeat", `{"repeat":["a","b"],"spec":{"mark":"point"}}`, ""},
+
+		{"not an object", `[]`, `invalid vega-lite spec: /: [] type should be object`},
+		{"no view", `{"width":100}`, `invalid vega-lite spec: /: {"width":100} did Not match any specified AnyOf schemas`},
+		{"unknown mark", `{"mark":"pie"}`, `invalid vega-lite spec: /mark: "pie" did Not match any specified AnyOf schemas`},
+		{"unknown property", `{"mark":"bar","colour":"red"}`, `invalid vega-lite spec: /colour: "red" cannot match schema`},
+		{"unknown channel", `{"mark":"bar","encoding":{"z":{"field":"a"}}}`, `invalid vega-lite spec: /encoding/z: "z" should be one of ["x", "y", "x2", "y2", "xError", "yError", "xError2", "yError2", "theta", "theta2", "radius", "radius2", "longitude", "latitude", "longitude2", "latitude2", "color", "fill", "stroke", "opacity", "fillOpacity", "strokeOpacity", "strokeWidth", "strokeDash", "size", "angle", "shape", "text", "tooltip", "href", "url", "description", "detail", "key", "order", "row", "column", "facet"]`},
+		{"bad field type", `{"mark":"bar","encoding":{"x":{"field":"a","type":"number"}}}`, `invalid vega-lite spec: /encoding/x: {"field":"a","type":... did Not match any specified AnyOf schemas`},
+	}
+
+	for _, c := range cases {
+		err := ValidateVegaLite([]byte(c.spec))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case '%s' error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+		}
+	}
+}
+
+// specPattern extracts the embedded spec from a rendered vega-lite page
+var specPattern = regexp.MustCompile(`<script type="application/json" id="vega-lite-spec">(.*)</script>`)
+
+func TestRenderVegaLite(t *testing.T) {
+	cases := []struct {
+		description string
+		body        string
+		spec        string
+		expect      []interface{}
+		err         string
+	}{
+		{"inline body", testBody, `{"mark":"point","data":{"url":"data.csv"}}`, []interface{}{
+			map[string]interface{}{"field_1": float64(1), "field_2": "a"},
+			map[string]interface{}{"field_1": float64(2), "field_2": "b"},
+			map[string]interface{}{"field_1": float64(3), "field_2": "c"},
+			map[string]interface{}{"field_1": float64(4), "field_2": "d"},
+		}, ""},
+		{"columns and limit", testBody, `{"mark":"point","usermeta":{"qri":{"columns":["field_2"],"limit":2}}}`, []interface{}{
+			map[string]interface{}{"field_2": "a"},
+			map[string]interface{}{"field_2": "b"},
+		}, ""},
+		{"zero limit", testBody, `{"mark":"point","usermeta":{"qri":{"limit":0}}}`, []interface{}{}, ""},
+		{"object body", `{"a":1,"b":2}`, `{"mark":"bar"}`, []interface{}{
+			map[string]interface{}{"key": "a", "value": float64(1)},
+			map[string]interface{}{"key": "b", "value": float64(2)},
+		}, ""},
+		{"primitive entries", `[true,"x"]`, `{"mark":"tick"}`, []interface{}{
+			map[string]interface{}{"data": true},
+			map[string]interface{}{"data": "x"},
+		}, ""},
+
+		{"invalid spec", testBody, `{"mark":"pie"}`, nil, `invalid vega-lite spec: /mark: "pie" did Not match any specified AnyOf schemas`},
+		{"invalid json", testBody, `{"mark":`, nil, `invalid vega-lite spec: error parsing JSON bytes: unexpected end of JSON input`},
+		{"missing column", testBody, `{"mark":"bar","usermeta":{"qri":{"columns":["nope"]}}}`, nil, `vega-lite: column not found: 'nope'`},
+		{"negative limit", testBody, `{"mark":"bar","usermeta":{"qri":{"limit":-1}}}`, nil, `invalid vega-lite spec: /usermeta/qri/limit: must be zero or greater`},
+		{"bad options", testBody, `{"mark":"bar","usermeta":{"qri":{"columns":"a"}}}`, nil, `invalid vega-lite spec: /usermeta/qri: json: cannot unmarshal string into Go struct field vegaLiteOptions.columns of type []string`},
+	}
+
+	for _, c := range cases {
+		ds := newBodyDataset(c.body)
+		if strings.HasPrefix(c.body, "{") {
+			ds.Structure.Schema = dataset.BaseSchemaObject
+		}
+		ds.Viz.Format = FormatVegaLite
+
+		got, err := renderString(ds, c.spec)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case '%s' error mismatch. expected: '%s', got: '%v'", c.description, c.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		match := specPattern.FindStringSubmatch(got)
+		if match == nil {
+			t.Errorf("case '%s' rendered page has no spec", c.description)
+			continue
+		}
+		spec := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(match[1]), &spec); err != nil {
+			t.Errorf("case '%s' invalid embedded spec: %s", c.description, err)
+			continue
+		}
+		data := spec["data"].(map[string]interface{})
+		if _, ok := data["url"]; ok {
+			t.Errorf("case '%s' expected data url to be removed", c.description)
+		}
+		if diff := cmp.Diff(c.expect, data["values"]); diff != "" {
+			t.Errorf("case '%s' data values mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestRenderVegaLiteSubSpecURLs(t *testing.T) {
+	ds := newBodyDataset(testBody)
+	ds.Viz.Format = FormatVegaLite
+
+	spec := `{
+		"data": {"url": "top.csv"},
+		"layer": [
+			{"mark": "point", "data": {"url": "https://example.com/a.csv"}},
+			{"vconcat": [{"mark": "line", "data": {"url": "b.csv"}}]}
+		],
+		"usermeta": {"qri": {"limit": 0}}
+	}`
+	got, err := renderString(ds, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, ".csv") {
+		t.Errorf("expected all data urls to be removed. got: %s", got)
+	}
+	match := specPattern.FindStringSubmatch(got)
+	if match == nil {
+		t.Fatal("rendered page has no spec")
+	}
+	expect := `{"data":{"values":[]},"layer":[{"mark":"point"},{"vconcat":[{"mark":"line"}]}],"usermeta":{"qri":{"limit":0}}}`
+	if match[1] != expect {
+		t.Errorf("spec mismatch.\nexpected: %s\ngot:      %s", expect, match[1])
+	}
+}
+
+func TestRenderVegaLiteScripts(t *testing.T) {
+	ds := newBodyDataset(testBody)
+	ds.Viz.Format = FormatVegaLite
+
+	// pages are self-contained unless scripts are configured
+	got, err := renderString(ds, `{"mark":"point"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "<script src=") {
+		t.Errorf("expected default page to load no scripts. got: %s", got)
+	}
+
+	got, err = renderString(ds, `{"mark":"point"}`, func(cfg *RenderCfg) {
+		cfg.VegaLiteScripts = []string{"/static/vega-embed.js"}
+		cfg.VegaLiteInlineScripts = []string{"var vegaEmbed = function() {};"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "cdn.jsdelivr.net") {
+		t.Errorf("expected default scripts to be replaced")
+	}
+	for _, expect := range []string{
+		`<script src="/static/vega-embed.js"></script>`,
+		`<script>var vegaEmbed = function() {};</script>`,
+	} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("expected page to contain: %s", expect)
+		}
+	}
+}
@@ -22,6 +22,7 @@ require (
 	github.com/jinzhu/copier v0.0.0-20180308034124-7e38e58719c3
 	github.com/k0kubun/colorstring v0.0.0-20150214042306-9440f1994b88 // indirect
 	github.com/libp2p/go-libp2p-core v0.2.3
+	github.com/microcosm-cc/bluemonday v1.0.2
 	github.com/mr-tron/base58 v1.1.2
 	github.com/multiformats/go-multihash v0.0.8
 	github.com/qri-io/compare v0.1.0
@@ -32,4 +33,5 @@ require (
 	github.com/yudai/gojsondiff v1.0.0
 	github.com/yudai/golcs v0.0.0-20170316035057-ecda9a501e82 // indirect
 	github.com/yudai/pp v2.0.1+incompatible // indirect
+	github.com/yuin/goldmark v1.1.1
 )
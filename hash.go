@@ -13,6 +13,8 @@ import (
 // It's important to note that this is *NOT* the same as an IPFS hash,
 // These hash functions should be used for other things like
 // checksumming, in-memory content-addressing, etc.
+// JSONHash depends on go's JSON encoding, use CanonicalHash for hashes that
+// must be reproducible by other implementations
 func JSONHash(m json.Marshaler) (hash string, err error) {
 	// marshal to cannoncical JSON representation
 	data, err := m.MarshalJSON()
@@ -0,0 +1,369 @@
+package jsonld
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+)
+
+// EU publications office vocabularies used by DCAT-AP
+const (
+	NamespaceFrequency = "http://publications.europa.eu/resource/authority/frequency/"
+	NamespaceFileType  = "http://publications.europa.eu/resource/authority/file-type/"
+	NamespaceMediaType = "http://www.iana.org/assignments/media-types/"
+)
+
+// DCATContext is the @context of encoded DCAT-AP documents
+var DCATContext = map[string]interface{}{
+	"dcat": NamespaceDCAT,
+	"dct":  NamespaceDCT,
+	"foaf": NamespaceFOAF,
+	"owl":  NamespaceOWL,
+	"rdf":  NamespaceRDF,
+	"xsd":  NamespaceXSD,
+}
+
+// frequencies maps accrual periodicities to the DCAT-AP frequency vocabulary
+var frequencies = map[string]string{
+	"R/PT1H": "HOURLY",
+	"R/PT2H": "BIHOURLY",
+	"R/PT3H": "TRIHOURLY",
+	"R/P1D":  "DAILY",
+	"R/P1W":  "WEEKLY",
+	"R/P2W":  "BIWEEKLY",
+	"R/P1M":  "MONTHLY",
+	"R/P2M":  "BIMONTHLY",
+	"R/P3M":  "QUARTERLY",
+	"R/P6M":  "ANNUAL_2",
+	"R/P1Y":  "ANNUAL",
+	"R/P2Y":  "BIENNIAL",
+	"R/P3Y":  "TRIENNIAL",
+	"R/P4Y":  "QUADRENNIAL",
+	"R/P5Y":  "QUINQUENNIAL",
+	"R/P10Y": "DECENNIAL",
+}
+
+// fileTypes maps data formats to the DCAT-AP file type vocabulary
+var fileTypes = map[dataset.DataFormat]string{
+	dataset.CSVDataFormat:  "CSV",
+	dataset.JSONDataFormat: "JSON",
+	dataset.XMLDataFormat:  "XML",
+	dataset.XLSXDataFormat: "XLSX",
+}
+
+// DCAT encodes a dataset as a DCAT-AP dcat:Dataset JSON-LD document. The
+// body is described as a dcat:Distribution using the structure format &
+// length and the meta access & download urls. Commit timestamps become the
+// modification date
+func DCAT(ds *dataset.Dataset) map[string]interface{} {
+	doc := map[string]interface{}{
+		"@context": DCATContext,
+		"@type":    "dcat:Dataset",
+	}
+	md := ds.Meta
+	if md == nil {
+		md = &dataset.Meta{}
+	}
+	if isIRI(md.Identifier) {
+		doc["@id"] = md.Identifier
+	}
+
+	setString(doc, "dct:title", md.Title)
+	setString(doc, "dct:description", md.Description)
+	setString(doc, "dct:identifier", md.Identifier)
+	setString(doc, "owl:versionInfo", md.Version)
+	setStrings(doc, "dcat:keyword", md.Keywords)
+	if len(md.Theme) > 0 {
+		doc["dcat:theme"] = refsOrLiterals(md.Theme)
+	}
+	if len(md.Language) > 0 {
+		doc["dct:language"] = refsOrLiterals(md.Language)
+	}
+	if md.HomeURL != "" {
+		doc["dcat:landingPage"] = ref(md.HomeURL)
+	}
+	if md.ReadmeURL != "" {
+		doc["foaf:page"] = ref(md.ReadmeURL)
+	}
+	if md.AccrualPeriodicity != "" {
+		doc["dct:accrualPeriodicity"] = dcatFrequency(md)
+	}
+
+	license := dcatLicense(md.License)
+	if license != nil {
+		doc["dct:license"] = license
+	}
+
+	if len(md.Contributors) > 0 {
+		var contributors []interface{}
+		for _, u := range md.Contributors {
+			if u == nil {
+				continue
+			}
+			agent := map[string]interface{}{"@type": "foaf:Agent"}
+			setString(agent, "foaf:name", u.Fullname)
+			setString(agent, "dct:identifier", u.ID)
+			if u.Email != "" {
+				agent["foaf:mbox"] = ref("mailto:" + u.Email)
+			}
+			contributors = append(contributors, agent)
+		}
+		doc["dct:contributor"] = contributors
+	}
+
+	if len(md.Citations) > 0 {
+		var sources []interface{}
+		for _, c := range md.Citations {
+			if c == nil {
+				continue
+			}
+			src := map[string]interface{}{}
+			setString(src, "@id", c.URL)
+			setString(src, "dct:title", c.Name)
+			if c.Email != "" {
+				src["foaf:mbox"] = ref("mailto:" + c.Email)
+			}
+			sources = append(sources, src)
+		}
+		doc["dct:source"] = sources
+	}
+
+	if ds.Commit != nil && !ds.Commit.Timestamp.IsZero() {
+		doc["dct:modified"] = map[string]interface{}{
+			"@value": ds.Commit.Timestamp.UTC().Format(time.RFC3339),
+			"@type":  "xsd:dateTime",
+		}
+	}
+
+	if ds.Structure != nil || md.AccessURL != "" || md.DownloadURL != "" {
+		doc["dcat:distribution"] = []interface{}{dcatDistribution(md, ds.Structure, license)}
+	}
+	return doc
+}
+
+// MetaDCAT encodes meta on its own as a DCAT-AP dcat:Dataset JSON-LD document
+func MetaDCAT(md *dataset.Meta) map[string]interface{} {
+	return DCAT(&dataset.Dataset{Meta: md})
+}
+
+// MarshalDCAT encodes a dataset as DCAT-AP JSON-LD
+func MarshalDCAT(ds *dataset.Dataset) ([]byte, error) {
+	return json.Marshal(DCAT(ds))
+}
+
+func refsOrLiterals(vals []string) []interface{} {
+	refs := make([]interface{}, len(vals))
+	for i, v := range vals {
+		if isIRI(v) {
+			refs[i] = ref(v)
+		} else {
+			refs[i] = v
+		}
+	}
+	return refs
+}
+
+// dcatFrequency uses the frequency vocabulary when the periodicity has an
+// equivalent term, falling back to a literal ISO 8601 value
+func dcatFrequency(md *dataset.Meta) interface{} {
+	if ri, err := md.AccrualInterval(); err == nil {
+		if term, ok := frequencies[ri.String()]; ok {
+			return ref(NamespaceFrequency + term)
+		}
+	}
+	return map[string]interface{}{
+		"@type":     "dct:Frequency",
+		"rdf:value": md.AccrualPeriodicity,
+	}
+}
+
+func dcatLicense(l *dataset.License) map[string]interface{} {
+	if l == nil || (l.Type == "" && l.URL == "") {
+		return nil
+	}
+	license := map[string]interface{}{"@type": "dct:LicenseDocument"}
+	setString(license, "@id", l.URL)
+	setString(license, "dct:identifier", l.Type)
+	return license
+}
+
+func dcatDistribution(md *dataset.Meta, st *dataset.Structure, license map[string]interface{}) map[string]interface{} {
+	dist := map[string]interface{}{"@type": "dcat:Distribution"}
+	// accessURL is mandatory in DCAT-AP, download urls also grant access
+	if md.AccessURL != "" {
+		dist["dcat:accessURL"] = ref(md.AccessURL)
+	} else if md.DownloadURL != "" {
+		dist["dcat:accessURL"] = ref(md.DownloadURL)
+	}
+	if md.DownloadURL != "" {
+		dist["dcat:downloadURL"] = ref(md.DownloadURL)
+	}
+	if license != nil {
+		dist["dct:license"] = license
+	}
+	if st == nil {
+		return dist
+	}
+
+	if df, err := dataset.ParseDataFormatString(st.Format); err == nil && df != dataset.UnknownDataFormat {
+		if ft, ok := fileTypes[df]; ok {
+			dist["dct:format"] = ref(NamespaceFileType + ft)
+		} else {
+			dist["dct:format"] = st.Format
+		}
+		if mt := df.MediaType(); mt != "" {
+			dist["dcat:mediaType"] = ref(NamespaceMediaType + mt)
+		}
+	} else {
+		setString(dist, "dct:format", st.Format)
+	}
+	if st.Length > 0 {
+		dist["dcat:byteSize"] = map[string]interface{}{
+			"@value": strconv.Itoa(st.Length),
+			"@type":  "xsd:decimal",
+		}
+	}
+	return dist
+}
+
+// UnmarshalDCAT decodes the first dcat:Dataset in a DCAT JSON-LD document.
+// The first distribution provides the body format, length and meta access &
+// download urls
+func UnmarshalDCAT(data []byte) (*dataset.Dataset, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decoding DCAT document: %s", err.Error())
+	}
+	n, ok := findNode(expand(v, &context{}), NamespaceDCAT+"Dataset")
+	if !ok {
+		return nil, fmt.Errorf("no dcat:Dataset found")
+	}
+
+	md := &dataset.Meta{
+		Title:       n.str(NamespaceDCT + "title"),
+		Description: n.str(NamespaceDCT + "description"),
+		Identifier:  n.str(NamespaceDCT + "identifier"),
+		Version:     n.str(NamespaceOWL + "versionInfo"),
+		Keywords:    n.strs(NamespaceDCAT + "keyword"),
+		Theme:       n.strs(NamespaceDCAT + "theme"),
+		Language:    n.strs(NamespaceDCT + "language"),
+		HomeURL:     n.str(NamespaceDCAT + "landingPage"),
+		ReadmeURL:   n.str(NamespaceFOAF + "page"),
+	}
+	if md.Identifier == "" {
+		md.Identifier = n.id()
+	}
+	if md.Version == "" {
+		md.Version = n.str(NamespaceDCAT + "version")
+	}
+	for _, f := range n.nodes(NamespaceDCT + "accrualPeriodicity") {
+		md.AccrualPeriodicity = periodicityFromFrequency(f)
+	}
+	if md.AccrualPeriodicity == "" {
+		md.AccrualPeriodicity = n.str(NamespaceDCT + "accrualPeriodicity")
+	}
+
+	for _, a := range append(n.nodes(NamespaceDCT+"creator"), n.nodes(NamespaceDCT+"contributor")...) {
+		md.Contributors = append(md.Contributors, &dataset.User{
+			ID:       a.str(NamespaceDCT + "identifier"),
+			Fullname: a.str(NamespaceFOAF + "name"),
+			Email:    strings.TrimPrefix(a.str(NamespaceFOAF+"mbox"), "mailto:"),
+		})
+	}
+	for _, s := range n.nodes(NamespaceDCT + "source") {
+		md.Citations = append(md.Citations, &dataset.Citation{
+			Name:  s.str(NamespaceDCT + "title"),
+			URL:   s.id(),
+			Email: strings.TrimPrefix(s.str(NamespaceFOAF+"mbox"), "mailto:"),
+		})
+	}
+	md.License = dcatLicenseValue(n)
+
+	ds := &dataset.Dataset{}
+	if modified := n.str(NamespaceDCT + "modified"); modified != "" {
+		if ts, err := time.Parse(time.RFC3339, modified); err == nil {
+			ds.Commit = &dataset.Commit{Timestamp: ts}
+		}
+	}
+
+	if dists := n.nodes(NamespaceDCAT + "distribution"); len(dists) > 0 {
+		dist := dists[0]
+		md.DownloadURL = dist.str(NamespaceDCAT + "downloadURL")
+		if access := dist.str(NamespaceDCAT + "accessURL"); access != md.DownloadURL {
+			md.AccessURL = access
+		}
+		if md.License == nil {
+			md.License = dcatLicenseValue(dist)
+		}
+		ds.Structure = dcatStructure(dist)
+	}
+
+	if !md.IsEmpty() {
+		ds.Meta = md
+	}
+	return ds, nil
+}
+
+func periodicityFromFrequency(f node) string {
+	if id := f.id(); strings.HasPrefix(id, NamespaceFrequency) {
+		term := strings.TrimPrefix(id, NamespaceFrequency)
+		for p, t := range frequencies {
+			if t == term {
+				return p
+			}
+		}
+	}
+	if v := f.str(NamespaceRDF + "value"); v != "" {
+		return v
+	}
+	return literal(f)
+}
+
+func dcatLicenseValue(n node) *dataset.License {
+	for _, l := range asSlice(n[NamespaceDCT+"license"]) {
+		switch x := l.(type) {
+		case string:
+			if isIRI(x) {
+				return &dataset.License{URL: x}
+			}
+			return &dataset.License{Type: x}
+		case node:
+			license := &dataset.License{URL: x.id(), Type: x.str(NamespaceDCT + "identifier")}
+			if license.Type == "" {
+				license.Type = x.str(NamespaceDCT + "title")
+			}
+			return license
+		}
+	}
+	return nil
+}
+
+func dcatStructure(dist node) *dataset.Structure {
+	st := &dataset.Structure{}
+	if n, err := strconv.Atoi(dist.str(NamespaceDCAT + "byteSize")); err == nil {
+		st.Length = n
+	}
+
+	format := dist.str(NamespaceDCT + "format")
+	if strings.HasPrefix(format, NamespaceFileType) {
+		format = strings.ToLower(strings.TrimPrefix(format, NamespaceFileType))
+	}
+	if df, err := dataset.ParseDataFormatString(strings.ToLower(format)); err == nil && df != dataset.UnknownDataFormat {
+		st.Format = df.String()
+	} else if df, err := dataset.ParseMediaType(strings.TrimPrefix(dist.str(NamespaceDCAT+"mediaType"), NamespaceMediaType)); err == nil {
+		st.Format = df.String()
+	} else if df, err := dataset.ParseMediaType(format); err == nil {
+		st.Format = df.String()
+	} else {
+		st.Format = format
+	}
+
+	if st.Format == "" && st.Length == 0 {
+		return nil
+	}
+	return st
+}
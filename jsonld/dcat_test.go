@@ -0,0 +1,229 @@
+package jsonld
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/qri-io/dataset"
+)
+
+var cmpDataset = []cmp.Option{
+	cmp.AllowUnexported(dataset.Dataset{}, dataset.Meta{}, dataset.Structure{}, dataset.Commit{}),
+	cmpopts.EquateEmpty(),
+}
+
+func testDataset() *dataset.Dataset {
+	return &dataset.Dataset{
+		Name:   "air_quality",
+		Commit: &dataset.Commit{Timestamp: time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC)},
+		Meta: &dataset.Meta{
+			Title:              "Air quality measurements",
+			Description:        "Hourly air quality readings",
+			Identifier:         "https://example.org/dataset/air-quality",
+			Version:            "1.2",
+			Keywords:           []string{"air", "pollution"},
+			Theme:              []string{"environment"},
+			Language:           []string{"en"},
+			HomeURL:            "https://example.org/air",
+			ReadmeURL:          "https://example.org/air/readme",
+			AccessURL:          "https://example.org/air/access",
+			DownloadURL:        "https://example.org/air.csv",
+			AccrualPeriodicity: "R/P1D",
+			License:            &dataset.License{Type: "CC-BY-4.0", URL: "http://creativecommons.org/licenses/by/4.0/"},
+			Contributors:       []*dataset.User{{ID: "QmUser", Fullname: "Ada Lovelace", Email: "ada@example.org"}},
+			Citations:          []*dataset.Citation{{Name: "sensor network", URL: "https://example.org/sensors", Email: "sensors@example.org"}},
+		},
+		Structure: &dataset.Structure{Format: "csv", Length: 2048},
+	}
+}
+
+func TestDCAT(t *testing.T) {
+	got, err := MarshalDCAT(testDataset())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{
+  "@context": {
+    "dcat": "http://www.w3.org/ns/dcat#",
+    "dct": "http://purl.org/dc/terms/",
+    "foaf": "http://xmlns.com/foaf/0.1/",
+    "owl": "http://www.w3.org/2002/07/owl#",
+    "rdf": "http://www.w3.org/1999/02/22-rdf-syntax-ns#",
+    "xsd": "http://www.w3.org/2001/XMLSchema#"
+  },
+  "@id": "https://example.org/dataset/air-quality",
+  "@type": "dcat:Dataset",
+  "dcat:distribution": [
+    {
+      "@type": "dcat:Distribution",
+      "dcat:accessURL": { "@id": "https://example.org/air/access" },
+      "dcat:byteSize": { "@type": "xsd:decimal", "@value": "2048" },
+      "dcat:downloadURL": { "@id": "https://example.org/air.csv" },
+      "dcat:mediaType": { "@id": "http://www.iana.org/assignments/media-types/text/csv" },
+      "dct:format": { "@id": "http://publications.europa.eu/resource/authority/file-type/CSV" },
+      "dct:license": {
+        "@id": "http://creativecommons.org/licenses/by/4.0/",
+        "@type": "dct:LicenseDocument",
+        "dct:identifier": "CC-BY-4.0"
+      }
+    }
+  ],
+  "dcat:keyword": ["air", "pollution"],
+  "dcat:landingPage": { "@id": "https://example.org/air" },
+  "dcat:theme": ["environment"],
+  "dct:accrualPeriodicity": { "@id": "http://publications.europa.eu/resource/authority/frequency/DAILY" },
+  "dct:contributor": [
+    {
+      "@type": "foaf:Agent",
+      "dct:identifier": "QmUser",
+      "foaf:mbox": { "@id": "mailto:ada@example.org" },
+      "foaf:name": "Ada Lovelace"
+    }
+  ],
+  "dct:description": "Hourly air quality readings",
+  "dct:identifier": "https://example.org/dataset/air-quality",
+  "dct:language": ["en"],
+  "dct:license": {
+    "@id": "http://creativecommons.org/licenses/by/4.0/",
+    "@type": "dct:LicenseDocument",
+    "dct:identifier": "CC-BY-4.0"
+  },
+  "dct:modified": { "@type": "xsd:dateTime", "@value": "2019-04-01T12:00:00Z" },
+  "dct:source": [
+    {
+      "@id": "https://example.org/sensors",
+      "dct:title": "sensor network",
+      "foaf:mbox": { "@id": "mailto:sensors@example.org" }
+    }
+  ],
+  "dct:title": "Air quality measurements",
+  "foaf:page": { "@id": "https://example.org/air/readme" },
+  "owl:versionInfo": "1.2"
+}`
+	assertJSONEqual(t, expect, got)
+}
+
+func TestDCATFrequency(t *testing.T) {
+	cases := []struct {
+		periodicity string
+		expect      string
+	}{
+		{"R/P1W", `{"@id":"http://publications.europa.eu/resource/authority/frequency/WEEKLY"}`},
+		{"P1M", `{"@id":"http://publications.europa.eu/resource/authority/frequency/MONTHLY"}`},
+		{"R/P10D", `{"@type":"dct:Frequency","rdf:value":"R/P10D"}`},
+		{"R5/2019-01-01T00:00:00Z/P1Y", `{"@type":"dct:Frequency","rdf:value":"R5/2019-01-01T00:00:00Z/P1Y"}`},
+		{"weekly", `{"@type":"dct:Frequency","rdf:value":"weekly"}`},
+	}
+
+	for i, c := range cases {
+		doc := MetaDCAT(&dataset.Meta{AccrualPeriodicity: c.periodicity})
+		got, err := json.Marshal(doc["dct:accrualPeriodicity"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != c.expect {
+			t.Errorf("case %d mismatch. expected: %s, got: %s", i, c.expect, got)
+			continue
+		}
+
+		data, _ := json.Marshal(doc)
+		ds, err := UnmarshalDCAT(data)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+		expect := c.periodicity
+		if expect == "P1M" {
+			expect = "R/P1M"
+		}
+		if ds.Meta.AccrualPeriodicity != expect {
+			t.Errorf("case %d decoded periodicity mismatch. expected: %s, got: %s", i, expect, ds.Meta.AccrualPeriodicity)
+		}
+	}
+}
+
+func TestDCATRoundTrip(t *testing.T) {
+	ds := testDataset()
+	data, err := MarshalDCAT(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalDCAT(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// names aren't part of DCAT
+	ds.Name = ""
+	if diff := cmp.Diff(ds, got, cmpDataset...); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUnmarshalDCAT(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/dcat_catalog.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalDCAT(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &dataset.Dataset{
+		Commit: &dataset.Commit{Timestamp: time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC)},
+		Meta: &dataset.Meta{
+			Title:              "Air quality measurements",
+			Description:        "Hourly air quality readings",
+			Identifier:         "https://example.org/dataset/air-quality",
+			Keywords:           []string{"air", "pollution"},
+			Theme:              []string{"http://publications.europa.eu/resource/authority/data-theme/ENVI"},
+			Language:           []string{"http://publications.europa.eu/resource/authority/language/ENG"},
+			HomeURL:            "https://example.org/air",
+			AccessURL:          "https://example.org/air",
+			DownloadURL:        "https://example.org/air.csv",
+			AccrualPeriodicity: "R/PT1H",
+			License:            &dataset.License{URL: "http://creativecommons.org/licenses/by/4.0/"},
+			Contributors:       []*dataset.User{{Fullname: "Environment Agency", Email: "data@example.org"}},
+		},
+		Structure: &dataset.Structure{Format: "csv", Length: 2048},
+	}
+	if diff := cmp.Diff(expect, got, cmpDataset...); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	cases := []struct {
+		data string
+		err  string
+	}{
+		{`{`, "decoding DCAT document: unexpected end of JSON input"},
+		{`{"@type":"dcat:Dataset"}`, "no dcat:Dataset found"},
+		{`{"@context":{"dcat":"http://www.w3.org/ns/dcat#"},"@type":"dcat:Catalog"}`, "no dcat:Dataset found"},
+		{`{"@context":{"@vocab":"http://www.w3.org/ns/dcat#"},"@type":"Dataset"}`, ""},
+	}
+	for i, c := range cases {
+		_, err := UnmarshalDCAT([]byte(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func assertJSONEqual(t *testing.T, expect string, got []byte) {
+	t.Helper()
+	var a, b interface{}
+	if err := json.Unmarshal([]byte(expect), &a); err != nil {
+		t.Fatalf("invalid expected json: %s", err)
+	}
+	if err := json.Unmarshal(got, &b); err != nil {
+		t.Fatalf("invalid json: %s", err)
+	}
+	if diff := cmp.Diff(a, b); diff != "" {
+		t.Errorf("json mismatch (-want +got):\n%s", diff)
+	}
+}
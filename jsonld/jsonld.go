@@ -0,0 +1,275 @@
+// Package jsonld encodes datasets as linked data for catalogs & search
+// engines. Two vocabularies are supported: DCAT-AP
+// (https://joinup.ec.europa.eu/collection/semantic-interoperability-community-semic/solution/dcat-application-profile-data-portals-europe)
+// and schema.org Dataset (https://schema.org/Dataset).
+//
+// Encoding produces compacted JSON-LD documents. Decoding accepts any
+// context that maps properties to the same IRIs through prefixes, terms, or
+// a default vocabulary. Remote contexts other than schema.org aren't
+// dereferenced
+package jsonld
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Namespaces used by encoded documents
+const (
+	NamespaceDCAT      = "http://www.w3.org/ns/dcat#"
+	NamespaceDCT       = "http://purl.org/dc/terms/"
+	NamespaceFOAF      = "http://xmlns.com/foaf/0.1/"
+	NamespaceOWL       = "http://www.w3.org/2002/07/owl#"
+	NamespaceRDF       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	NamespaceSchemaOrg = "http://schema.org/"
+	NamespaceXSD       = "http://www.w3.org/2001/XMLSchema#"
+)
+
+// node is a JSON-LD node object with property names & types expanded to
+// absolute IRIs
+type node map[string]interface{}
+
+// context resolves compact IRIs & terms
+type context struct {
+	terms map[string]string
+	vocab string
+}
+
+// parseContext reads the value of an @context property. Only the
+// schema.org remote context is recognized
+func parseContext(v interface{}, ctx *context) *context {
+	next := &context{terms: map[string]string{}}
+	if ctx != nil {
+		next.vocab = ctx.vocab
+		for k, iri := range ctx.terms {
+			next.terms[k] = iri
+		}
+	}
+
+	switch x := v.(type) {
+	case string:
+		if isSchemaOrg(x) {
+			next.vocab = NamespaceSchemaOrg
+		}
+	case []interface{}:
+		for _, c := range x {
+			next = parseContext(c, next)
+		}
+	case map[string]interface{}:
+		if vocab, ok := x["@vocab"].(string); ok {
+			next.vocab = normalizeIRI(vocab)
+		}
+		for term, def := range x {
+			if strings.HasPrefix(term, "@") {
+				continue
+			}
+			switch d := def.(type) {
+			case string:
+				next.terms[term] = d
+			case map[string]interface{}:
+				if id, ok := d["@id"].(string); ok {
+					next.terms[term] = id
+				}
+			}
+		}
+	}
+	return next
+}
+
+// isSchemaOrg checks for the schema.org context url
+func isSchemaOrg(s string) bool {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(s, "https://"), "http://"), "/")
+	return s == "schema.org"
+}
+
+// normalizeIRI treats the https schema.org namespace as equivalent to http
+func normalizeIRI(iri string) string {
+	if strings.HasPrefix(iri, "https://schema.org/") {
+		return "http://" + strings.TrimPrefix(iri, "https://")
+	}
+	return iri
+}
+
+// expandIRI resolves a term or compact IRI to an absolute IRI. vocab
+// controls whether unprefixed terms resolve against the default vocabulary
+func (ctx *context) expandIRI(s string, vocab bool) string {
+	if strings.HasPrefix(s, "@") {
+		return s
+	}
+	for i := 0; i < 8; i++ {
+		iri, ok := ctx.terms[s]
+		if !ok || iri == s {
+			break
+		}
+		s = iri
+	}
+	if i := strings.Index(s, ":"); i > 0 && !strings.HasPrefix(s[i:], "://") {
+		if prefix, ok := ctx.terms[s[:i]]; ok {
+			return normalizeIRI(prefix + s[i+1:])
+		}
+	}
+	if strings.Contains(s, "://") {
+		return normalizeIRI(s)
+	}
+	if vocab && ctx.vocab != "" {
+		return ctx.vocab + s
+	}
+	return s
+}
+
+// expand converts a JSON-LD document value to use absolute IRIs for property
+// names & types
+func expand(v interface{}, ctx *context) interface{} {
+	switch x := v.(type) {
+	case []interface{}:
+		expanded := make([]interface{}, len(x))
+		for i, item := range x {
+			expanded[i] = expand(item, ctx)
+		}
+		return expanded
+	case map[string]interface{}:
+		if c, ok := x["@context"]; ok {
+			ctx = parseContext(c, ctx)
+		}
+		n := node{}
+		for key, val := range x {
+			if key == "@context" {
+				continue
+			}
+			switch key {
+			case "@type":
+				var types []interface{}
+				for _, t := range asSlice(val) {
+					if s, ok := t.(string); ok {
+						types = append(types, ctx.expandIRI(s, true))
+					}
+				}
+				n[key] = types
+			case "@id":
+				if s, ok := val.(string); ok {
+					n[key] = ctx.expandIRI(s, false)
+				}
+			default:
+				n[ctx.expandIRI(key, true)] = expand(val, ctx)
+			}
+		}
+		return n
+	}
+	return v
+}
+
+// findNode searches an expanded document for the first node with a type
+func findNode(v interface{}, typ string) (node, bool) {
+	switch x := v.(type) {
+	case []interface{}:
+		for _, item := range x {
+			if n, ok := findNode(item, typ); ok {
+				return n, true
+			}
+		}
+	case node:
+		if x.isA(typ) {
+			return x, true
+		}
+		if graph, ok := x["@graph"]; ok {
+			return findNode(graph, typ)
+		}
+	}
+	return nil, false
+}
+
+func asSlice(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.([]interface{}); ok {
+		return s
+	}
+	return []interface{}{v}
+}
+
+// isA checks a node for a type IRI
+func (n node) isA(typ string) bool {
+	for _, t := range asSlice(n["@type"]) {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// strs gives the string values of a property. value objects give their
+// @value, node references their @id
+func (n node) strs(iri string) []string {
+	var strs []string
+	for _, v := range asSlice(n[iri]) {
+		if s := literal(v); s != "" {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// str gives the first string value of a property
+func (n node) str(iri string) string {
+	if strs := n.strs(iri); len(strs) > 0 {
+		return strs[0]
+	}
+	return ""
+}
+
+// nodes gives the node objects of a property
+func (n node) nodes(iri string) []node {
+	var nodes []node
+	for _, v := range asSlice(n[iri]) {
+		if child, ok := v.(node); ok {
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
+
+// id gives the @id of a node
+func (n node) id() string {
+	s, _ := n["@id"].(string)
+	return s
+}
+
+func literal(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64, bool:
+		return fmt.Sprintf("%v", x)
+	case node:
+		if val, ok := x["@value"]; ok {
+			return literal(val)
+		}
+		return x.id()
+	}
+	return ""
+}
+
+// ref creates a node reference
+func ref(iri string) map[string]interface{} {
+	return map[string]interface{}{"@id": iri}
+}
+
+// isIRI checks if a string looks like an absolute IRI
+func isIRI(s string) bool {
+	return strings.Contains(s, "://") || strings.HasPrefix(s, "mailto:") || strings.HasPrefix(s, "urn:")
+}
+
+// setString assigns a value to a document key when it's not empty
+func setString(doc map[string]interface{}, key, val string) {
+	if val != "" {
+		doc[key] = val
+	}
+}
+
+// setStrings assigns values to a document key when there are any
+func setStrings(doc map[string]interface{}, key string, vals []string) {
+	if len(vals) > 0 {
+		doc[key] = vals
+	}
+}
@@ -0,0 +1,240 @@
+package jsonld
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qri-io/dataset"
+)
+
+// SchemaOrgContext is the @context of encoded schema.org documents. schema.org
+// has no update frequency, so accrual periodicity is written with the DCAT
+// property
+var SchemaOrgContext = []interface{}{
+	"https://schema.org/",
+	map[string]interface{}{"dct": NamespaceDCT},
+}
+
+// SchemaOrg encodes a dataset as a schema.org Dataset JSON-LD document, in
+// the form search engines expect for dataset discovery. Datasets without a
+// meta title are named by the dataset name. The body is described as a
+// DataDownload distribution
+func SchemaOrg(ds *dataset.Dataset) map[string]interface{} {
+	doc := map[string]interface{}{
+		"@context": SchemaOrgContext,
+		"@type":    "Dataset",
+	}
+	md := ds.Meta
+	if md == nil {
+		md = &dataset.Meta{}
+	}
+
+	setString(doc, "name", ds.Name)
+	setString(doc, "name", md.Title)
+	setString(doc, "description", md.Description)
+	setString(doc, "identifier", md.Identifier)
+	setString(doc, "version", md.Version)
+	setString(doc, "url", md.HomeURL)
+	setStrings(doc, "keywords", md.Keywords)
+	setStrings(doc, "inLanguage", md.Language)
+	setString(doc, "dct:accrualPeriodicity", md.AccrualPeriodicity)
+
+	if len(md.Theme) > 0 {
+		about := make([]interface{}, len(md.Theme))
+		for i, theme := range md.Theme {
+			if isIRI(theme) {
+				about[i] = ref(theme)
+			} else {
+				about[i] = map[string]interface{}{"@type": "Thing", "name": theme}
+			}
+		}
+		doc["about"] = about
+	}
+	if md.ReadmeURL != "" {
+		doc["subjectOf"] = map[string]interface{}{"@type": "CreativeWork", "url": md.ReadmeURL}
+	}
+
+	if l := md.License; l != nil && (l.Type != "" || l.URL != "") {
+		license := map[string]interface{}{"@type": "CreativeWork"}
+		setString(license, "name", l.Type)
+		setString(license, "url", l.URL)
+		doc["license"] = license
+	}
+
+	if len(md.Contributors) > 0 {
+		var contributors []interface{}
+		for _, u := range md.Contributors {
+			if u == nil {
+				continue
+			}
+			person := map[string]interface{}{"@type": "Person"}
+			setString(person, "name", u.Fullname)
+			setString(person, "email", u.Email)
+			setString(person, "identifier", u.ID)
+			contributors = append(contributors, person)
+		}
+		doc["contributor"] = contributors
+	}
+
+	if len(md.Citations) > 0 {
+		var citations []interface{}
+		for _, c := range md.Citations {
+			if c == nil {
+				continue
+			}
+			work := map[string]interface{}{"@type": "CreativeWork"}
+			setString(work, "name", c.Name)
+			setString(work, "url", c.URL)
+			if c.Email != "" {
+				work["creator"] = map[string]interface{}{"@type": "Person", "email": c.Email}
+			}
+			citations = append(citations, work)
+		}
+		doc["citation"] = citations
+	}
+
+	if ds.Commit != nil && !ds.Commit.Timestamp.IsZero() {
+		doc["dateModified"] = ds.Commit.Timestamp.UTC().Format(time.RFC3339)
+	}
+
+	if ds.Structure != nil || md.AccessURL != "" || md.DownloadURL != "" {
+		dist := map[string]interface{}{"@type": "DataDownload"}
+		setString(dist, "url", md.AccessURL)
+		setString(dist, "contentUrl", md.DownloadURL)
+		if st := ds.Structure; st != nil {
+			if df, err := dataset.ParseDataFormatString(st.Format); err == nil && df.MediaType() != "" {
+				dist["encodingFormat"] = df.MediaType()
+			} else {
+				setString(dist, "encodingFormat", st.Format)
+			}
+			if st.Length > 0 {
+				dist["contentSize"] = strconv.Itoa(st.Length)
+			}
+		}
+		doc["distribution"] = []interface{}{dist}
+	}
+	return doc
+}
+
+// MetaSchemaOrg encodes meta on its own as a schema.org Dataset JSON-LD
+// document
+func MetaSchemaOrg(md *dataset.Meta) map[string]interface{} {
+	return SchemaOrg(&dataset.Dataset{Meta: md})
+}
+
+// MarshalSchemaOrg encodes a dataset as schema.org JSON-LD
+func MarshalSchemaOrg(ds *dataset.Dataset) ([]byte, error) {
+	return json.Marshal(SchemaOrg(ds))
+}
+
+// UnmarshalSchemaOrg decodes the first schema.org Dataset in a JSON-LD
+// document. The first distribution provides the body format, length and meta
+// access & download urls
+func UnmarshalSchemaOrg(data []byte) (*dataset.Dataset, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decoding schema.org document: %s", err.Error())
+	}
+	n, ok := findNode(expand(v, &context{}), NamespaceSchemaOrg+"Dataset")
+	if !ok {
+		return nil, fmt.Errorf("no schema.org Dataset found")
+	}
+
+	s := func(term string) string { return NamespaceSchemaOrg + term }
+	md := &dataset.Meta{
+		Title:              n.str(s("name")),
+		Description:        n.str(s("description")),
+		Identifier:         n.str(s("identifier")),
+		Version:            n.str(s("version")),
+		HomeURL:            n.str(s("url")),
+		Keywords:           n.strs(s("keywords")),
+		Language:           n.strs(s("inLanguage")),
+		AccrualPeriodicity: n.str(NamespaceDCT + "accrualPeriodicity"),
+	}
+	for _, about := range asSlice(n[s("about")]) {
+		if t, ok := about.(node); ok && t.str(s("name")) != "" {
+			md.Theme = append(md.Theme, t.str(s("name")))
+		} else if theme := literal(about); theme != "" {
+			md.Theme = append(md.Theme, theme)
+		}
+	}
+	for _, subject := range n.nodes(s("subjectOf")) {
+		md.ReadmeURL = subject.str(s("url"))
+		break
+	}
+
+	for _, l := range asSlice(n[s("license")]) {
+		switch x := l.(type) {
+		case string:
+			if isIRI(x) {
+				md.License = &dataset.License{URL: x}
+			} else {
+				md.License = &dataset.License{Type: x}
+			}
+		case node:
+			md.License = &dataset.License{Type: x.str(s("name")), URL: x.str(s("url"))}
+			if md.License.URL == "" {
+				md.License.URL = x.id()
+			}
+		}
+		break
+	}
+
+	for _, p := range append(n.nodes(s("creator")), n.nodes(s("contributor"))...) {
+		md.Contributors = append(md.Contributors, &dataset.User{
+			ID:       p.str(s("identifier")),
+			Fullname: p.str(s("name")),
+			Email:    p.str(s("email")),
+		})
+	}
+	for _, c := range asSlice(n[s("citation")]) {
+		switch x := c.(type) {
+		case string:
+			md.Citations = append(md.Citations, &dataset.Citation{Name: x})
+		case node:
+			cite := &dataset.Citation{Name: x.str(s("name")), URL: x.str(s("url"))}
+			for _, creator := range x.nodes(s("creator")) {
+				cite.Email = creator.str(s("email"))
+				break
+			}
+			md.Citations = append(md.Citations, cite)
+		}
+	}
+
+	ds := &dataset.Dataset{}
+	if modified := n.str(s("dateModified")); modified != "" {
+		if ts, err := time.Parse(time.RFC3339, modified); err == nil {
+			ds.Commit = &dataset.Commit{Timestamp: ts}
+		}
+	}
+
+	if dists := n.nodes(s("distribution")); len(dists) > 0 {
+		dist := dists[0]
+		md.AccessURL = dist.str(s("url"))
+		md.DownloadURL = dist.str(s("contentUrl"))
+
+		st := &dataset.Structure{}
+		format := dist.str(s("encodingFormat"))
+		if df, err := dataset.ParseMediaType(format); err == nil {
+			st.Format = df.String()
+		} else if df, err := dataset.ParseDataFormatString(strings.ToLower(format)); err == nil {
+			st.Format = df.String()
+		} else {
+			st.Format = format
+		}
+		if length, err := strconv.Atoi(dist.str(s("contentSize"))); err == nil {
+			st.Length = length
+		}
+		if st.Format != "" || st.Length != 0 {
+			ds.Structure = st
+		}
+	}
+
+	if !md.IsEmpty() {
+		ds.Meta = md
+	}
+	return ds, nil
+}
@@ -0,0 +1,137 @@
+package jsonld
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+)
+
+func TestSchemaOrg(t *testing.T) {
+	got, err := MarshalSchemaOrg(testDataset())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{
+  "@context": ["https://schema.org/", { "dct": "http://purl.org/dc/terms/" }],
+  "@type": "Dataset",
+  "about": [{ "@type": "Thing", "name": "environment" }],
+  "citation": [
+    {
+      "@type": "CreativeWork",
+      "creator": { "@type": "Person", "email": "sensors@example.org" },
+      "name": "sensor network",
+      "url": "https://example.org/sensors"
+    }
+  ],
+  "contributor": [
+    {
+      "@type": "Person",
+      "email": "ada@example.org",
+      "identifier": "QmUser",
+      "name": "Ada Lovelace"
+    }
+  ],
+  "dateModified": "2019-04-01T12:00:00Z",
+  "dct:accrualPeriodicity": "R/P1D",
+  "description": "Hourly air quality readings",
+  "distribution": [
+    {
+      "@type": "DataDownload",
+      "contentSize": "2048",
+      "contentUrl": "https://example.org/air.csv",
+      "encodingFormat": "text/csv",
+      "url": "https://example.org/air/access"
+    }
+  ],
+  "identifier": "https://example.org/dataset/air-quality",
+  "inLanguage": ["en"],
+  "keywords": ["air", "pollution"],
+  "license": {
+    "@type": "CreativeWork",
+    "name": "CC-BY-4.0",
+    "url": "http://creativecommons.org/licenses/by/4.0/"
+  },
+  "name": "Air quality measurements",
+  "subjectOf": { "@type": "CreativeWork", "url": "https://example.org/air/readme" },
+  "url": "https://example.org/air",
+  "version": "1.2"
+}`
+	assertJSONEqual(t, expect, got)
+
+	// datasets without a title are named by the dataset name
+	doc := SchemaOrg(&dataset.Dataset{Name: "air_quality"})
+	if doc["name"] != "air_quality" {
+		t.Errorf("expected name fallback. got: %v", doc["name"])
+	}
+}
+
+func TestSchemaOrgRoundTrip(t *testing.T) {
+	ds := testDataset()
+	data, err := MarshalSchemaOrg(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalSchemaOrg(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// titles take precedence over names
+	ds.Name = ""
+	if diff := cmp.Diff(ds, got, cmpDataset...); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUnmarshalSchemaOrg(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/schemaorg_dataset.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalSchemaOrg(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &dataset.Dataset{
+		Commit: &dataset.Commit{Timestamp: time.Date(2014, 10, 31, 8, 0, 0, 0, time.UTC)},
+		Meta: &dataset.Meta{
+			Title:       "NCDC Storm Events Database",
+			Description: "Storm Data is provided by the National Weather Service (NWS)",
+			HomeURL:     "https://catalog.data.gov/dataset/ncdc-storm-events-database",
+			Identifier:  "https://doi.org/10.1000/182",
+			Keywords:    []string{"ATMOSPHERE > ATMOSPHERIC PHENOMENA > CYCLONES", "ATMOSPHERE > ATMOSPHERIC PHENOMENA > DROUGHT"},
+			License:     &dataset.License{URL: "https://creativecommons.org/publicdomain/zero/1.0/"},
+			DownloadURL: "http://www.ncdc.noaa.gov/stormevents/ftp.jsp",
+			Contributors: []*dataset.User{{
+				Fullname: "OC/NOAA/NESDIS/NCEI > National Centers for Environmental Information",
+				Email:    "NCEI.Info@noaa.gov",
+			}},
+			Citations: []*dataset.Citation{{Name: "Smith, J. (2009) Storm Data"}},
+		},
+		Structure: &dataset.Structure{Format: "csv"},
+	}
+	if diff := cmp.Diff(expect, got, cmpDataset...); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	cases := []struct {
+		data string
+		err  string
+	}{
+		{`[`, "decoding schema.org document: unexpected end of JSON input"},
+		{`{"@context":"https://schema.org","@type":"Person"}`, "no schema.org Dataset found"},
+		{`{"@context":"http://schema.org/","@graph":[{"@type":"Dataset","name":"a"}]}`, ""},
+		{`{"@type":"https://schema.org/Dataset"}`, ""},
+	}
+	for i, c := range cases {
+		_, err := UnmarshalSchemaOrg([]byte(c.data))
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package dataset
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 )
 
 // CurrentSpecVersion is the current verion of the dataset spec
@@ -23,6 +24,8 @@ const (
 	KindViz = Kind("vz:" + CurrentSpecVersion)
 	// KindReadme is the current kind for dataset readme
 	KindReadme = Kind("rm:" + CurrentSpecVersion)
+	// KindStats is the current kind for dataset stats
+	KindStats = Kind("sa:" + CurrentSpecVersion)
 )
 
 // Kind is a short identifier for all types of qri dataset objects
@@ -40,9 +43,12 @@ func (k Kind) String() string {
 
 // Valid checks to see if a kind string is valid
 func (k Kind) Valid() error {
-	if len(k) < 4 {
+	if len(k) < 4 || k[2] != ':' {
 		return fmt.Errorf("invalid kind: '%s'. kind must be in the form [type]:[version]", k.String())
 	}
+	if _, err := k.VersionNumber(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -56,6 +62,19 @@ func (k Kind) Version() string {
 	return k.String()[3:]
 }
 
+// VersionNumber returns the version portion of the kind identifier as an
+// integer
+func (k Kind) VersionNumber() (int, error) {
+	if len(k) < 4 {
+		return 0, fmt.Errorf("invalid kind: '%s'. kind must be in the form [type]:[version]", k.String())
+	}
+	v, err := strconv.Atoi(k.Version())
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid kind: '%s'. version must be a non-negative integer", k.String())
+	}
+	return v, nil
+}
+
 // UnmarshalJSON implements the JSON.Unmarshaler interface,
 // rejecting any strings that are not a valid kind
 func (k *Kind) UnmarshalJSON(data []byte) error {
@@ -17,6 +17,9 @@ func TestKindValid(t *testing.T) {
 		{"as:0", ""},
 		{"ps:0", ""},
 		{"ps:0", ""},
+		{"ds0:1", "invalid kind: 'ds0:1'. kind must be in the form [type]:[version]"},
+		{"ds:a", "invalid kind: 'ds:a'. version must be a non-negative integer"},
+		{"ds:-1", "invalid kind: 'ds:-1'. version must be a non-negative integer"},
 	}
 
 	for i, c := range cases {
@@ -67,6 +70,30 @@ func TestKindVersion(t *testing.T) {
 	}
 }
 
+func TestKindVersionNumber(t *testing.T) {
+	cases := []struct {
+		Kind   Kind
+		expect int
+		err    string
+	}{
+		{"st:2", 2, ""},
+		{"ds:23", 23, ""},
+		{"ds", 0, "invalid kind: 'ds'. kind must be in the form [type]:[version]"},
+		{"ds:v1", 0, "invalid kind: 'ds:v1'. version must be a non-negative integer"},
+	}
+
+	for i, c := range cases {
+		got, err := c.Kind.VersionNumber()
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if c.expect != got {
+			t.Errorf("case %d response mismatch. expected: %d, got: %d", i, c.expect, got)
+		}
+	}
+}
+
 func TestKindUnmarshalJSON(t *testing.T) {
 	cases := []struct {
 		input  string
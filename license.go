@@ -0,0 +1,500 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownLicense indicates a license that can't be resolved to an SPDX
+// license identifier
+var ErrUnknownLicense = fmt.Errorf("unknown license")
+
+// SPDXLicenseURL gives the canonical url of an SPDX license identifier
+func SPDXLicenseURL(id string) string {
+	return "https://spdx.org/licenses/" + id + ".html"
+}
+
+// licenseNamePhrases rewrite common license names to the words of their
+// identifiers. order matters: longer phrases must come first
+var licenseNamePhrases = []struct{ phrase, replace string }{
+	{"open data commons open database", "odbl"},
+	{"open data commons public domain dedication and", "pddl"},
+	{"open data commons attribution", "odc by"},
+	{"open government licence canada", "ogl canada"},
+	{"open government licence", "ogl uk"},
+	{"open government license", "ogl uk"},
+	{"gnu affero general public", "agpl"},
+	{"gnu lesser general public", "lgpl"},
+	{"gnu library general public", "lgpl"},
+	{"gnu general public", "gpl"},
+	{"mozilla public", "mpl"},
+	{"eclipse public", "epl"},
+	{"creative commons", "cc"},
+	{"cc zero", "cc0"},
+	{"public domain dedication", "cc0"},
+	{"non commercial", "nc"},
+	{"noncommercial", "nc"},
+	{"no derivatives", "nd"},
+	{"noderivatives", "nd"},
+	{"noderivs", "nd"},
+	{"share alike", "sa"},
+	{"sharealike", "sa"},
+	{"attribution", "by"},
+}
+
+// licenseFillerWords are dropped when matching license names
+var licenseFillerWords = map[string]bool{
+	"license": true, "licence": true, "version": true, "international": true,
+	"unported": true, "generic": true, "universal": true, "the": true, "public": true,
+}
+
+// licenseAliases are common short names that don't match an identifier
+var licenseAliases = map[string]string{
+	"odbl":      "ODbL-1.0",
+	"pddl":      "PDDL-1.0",
+	"odcby":     "ODC-By-1.0",
+	"cc0":       "CC0-1.0",
+	"apache":    "Apache-2.0",
+	"apache2":   "Apache-2.0",
+	"gpl2":      "GPL-2.0-only",
+	"gpl3":      "GPL-3.0-only",
+	"oglcanada": "OGL-Canada-2.0",
+	"ogluk":     "OGL-UK-3.0",
+	"ogl":       "OGL-UK-3.0",
+	"ogl3":      "OGL-UK-3.0",
+	"unlicense": "Unlicense",
+}
+
+var (
+	licenseKeyChars   = regexp.MustCompile(`[^a-z0-9.+]`)
+	licenseVersionTag = regexp.MustCompile(`\bv(\d)`)
+	licenseIndex      map[string]string
+	licenseIndexOnce  sync.Once
+)
+
+// licenseKey reduces an identifier or name to characters that distinguish
+// license identifiers
+func licenseKey(s string) string {
+	return licenseKeyChars.ReplaceAllString(strings.ToLower(s), "")
+}
+
+func spdxIndex() map[string]string {
+	licenseIndexOnce.Do(func() {
+		licenseIndex = make(map[string]string, len(spdxLicenses)+len(licenseAliases))
+		for id := range spdxLicenses {
+			licenseIndex[licenseKey(id)] = id
+		}
+		for alias, id := range licenseAliases {
+			if _, ok := licenseIndex[alias]; !ok {
+				licenseIndex[alias] = id
+			}
+		}
+	})
+	return licenseIndex
+}
+
+// NormalizeLicenseID resolves a license identifier, name or url to an SPDX
+// license identifier. Matching ignores case, spacing & punctuation, so
+// "cc by 4.0" resolves to "CC-BY-4.0", and understands common license names
+// like "Creative Commons Attribution-ShareAlike 4.0 International"
+func NormalizeLicenseID(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ErrUnknownLicense
+	}
+	if _, ok := spdxLicenses[s]; ok {
+		return s, nil
+	}
+	index := spdxIndex()
+	if id, ok := index[licenseKey(s)]; ok {
+		return id, nil
+	}
+	if strings.Contains(s, "://") {
+		if id, ok := licenseURLID(s); ok {
+			return id, nil
+		}
+		return "", ErrUnknownLicense
+	}
+
+	name := " " + strings.Join(strings.FieldsFunc(strings.ToLower(s), isLicenseNameSeparator), " ") + " "
+	for _, p := range licenseNamePhrases {
+		name = strings.Replace(name, " "+p.phrase+" ", " "+p.replace+" ", -1)
+	}
+	name = licenseVersionTag.ReplaceAllString(name, "$1")
+	var words []string
+	for _, w := range strings.Fields(name) {
+		if !licenseFillerWords[w] {
+			words = append(words, w)
+		}
+	}
+	if id, ok := index[licenseKey(strings.Join(words, ""))]; ok {
+		return id, nil
+	}
+	return "", ErrUnknownLicense
+}
+
+func isLicenseNameSeparator(r rune) bool {
+	return r == ' ' || r == '-' || r == '_' || r == ',' || r == '(' || r == ')'
+}
+
+// licenseURLID matches well-known license urls
+func licenseURLID(s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	parts := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	index := spdxIndex()
+
+	switch {
+	case host == "creativecommons.org" && len(parts) >= 3 && parts[0] == "licenses":
+		// creativecommons.org/licenses/[terms]/[version]/[jurisdiction]
+		id := "CC-" + strings.ToUpper(parts[1]) + "-" + parts[2]
+		if len(parts) >= 4 && !strings.HasPrefix(parts[3], "legalcode") && !strings.HasPrefix(parts[3], "deed") {
+			id += "-" + strings.ToUpper(parts[3])
+		}
+		id, ok := index[licenseKey(id)]
+		return id, ok
+	case host == "creativecommons.org" && len(parts) >= 3 && parts[0] == "publicdomain" && parts[1] == "zero":
+		id := "CC0-" + parts[2]
+		_, ok := spdxLicenses[id]
+		return id, ok
+	case host == "opendatacommons.org" && len(parts) >= 2 && parts[0] == "licenses":
+		id, ok := index[licenseKey(strings.TrimSuffix(parts[1], "-v1.0"))]
+		return id, ok
+	case host == "nationalarchives.gov.uk" && len(parts) >= 4 && parts[1] == "open-government-licence" && parts[2] == "version":
+		id := "OGL-UK-" + parts[3] + ".0"
+		_, ok := spdxLicenses[id]
+		return id, ok
+	case host == "apache.org" && len(parts) >= 2 && parts[0] == "licenses":
+		id, ok := index[licenseKey("apache"+strings.TrimPrefix(parts[1], "LICENSE-"))]
+		return id, ok
+	}
+
+	// spdx.org/licenses/[id].html, opensource.org/licenses/[id] & the like
+	if len(parts) > 0 {
+		last := parts[len(parts)-1]
+		for _, ext := range []string{".html", ".json", ".txt", ".php", ".en"} {
+			last = strings.TrimSuffix(last, ext)
+		}
+		if id, ok := index[licenseKey(last)]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// licenseURLKey normalizes a license url for comparison
+func licenseURLKey(s string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Host == "" {
+		return s
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return host + strings.TrimSuffix(u.EscapedPath(), "/") + "?" + u.RawQuery
+}
+
+// SPDXID resolves the license to an SPDX license identifier, trying the
+// license type, then url. SPDXID returns an empty string when the license
+// isn't recognized
+func (l *License) SPDXID() string {
+	if l == nil {
+		return ""
+	}
+	if id, err := NormalizeLicenseID(l.Type); err == nil {
+		return id
+	}
+	if id, err := NormalizeLicenseID(l.URL); err == nil {
+		return id
+	}
+	return ""
+}
+
+// Validate checks that the license type is an SPDX license identifier,
+// suggesting the identifier when type can be normalized to one
+func (l *License) Validate() error {
+	if l == nil || l.Type == "" {
+		return fmt.Errorf("license type is required")
+	}
+	if deprecated, ok := spdxLicenses[l.Type]; ok {
+		if deprecated {
+			return fmt.Errorf("license type '%s' is a deprecated SPDX identifier", l.Type)
+		}
+		return nil
+	}
+	if id := l.SPDXID(); id != "" {
+		return fmt.Errorf("license type '%s' isn't an SPDX identifier, did you mean '%s'?", l.Type, id)
+	}
+	return fmt.Errorf("license type '%s' isn't an SPDX identifier", l.Type)
+}
+
+// Normalize sets the license type to its SPDX identifier, and the url to the
+// canonical SPDX license url when no url is set
+func (l *License) Normalize() error {
+	id := l.SPDXID()
+	if id == "" {
+		return fmt.Errorf("%s: '%s'", ErrUnknownLicense, l.Type)
+	}
+	l.Type = id
+	if l.URL == "" {
+		l.URL = SPDXLicenseURL(id)
+	}
+	return nil
+}
+
+// LicenseTerms summarizes the conditions a license places on derived works.
+// Terms are a simplified model for flagging likely conflicts, not a
+// substitute for legal review
+type LicenseTerms struct {
+	// PublicDomain licenses place no conditions on reuse
+	PublicDomain bool
+	// Attribution requires crediting the licensor
+	Attribution bool
+	// ShareAlike requires derived works use the same or a compatible license
+	ShareAlike bool
+	// NonCommercial prohibits commercial use
+	NonCommercial bool
+	// NoDerivatives prohibits derived works
+	NoDerivatives bool
+}
+
+// licenseFamilies assign terms to identifier prefixes
+var licenseFamilies = []struct {
+	prefix string
+	terms  LicenseTerms
+}{
+	{"CC-BY-NC-ND-", LicenseTerms{Attribution: true, NonCommercial: true, NoDerivatives: true}},
+	{"CC-BY-NC-SA-", LicenseTerms{Attribution: true, NonCommercial: true, ShareAlike: true}},
+	{"CC-BY-NC-", LicenseTerms{Attribution: true, NonCommercial: true}},
+	{"CC-BY-ND-", LicenseTerms{Attribution: true, NoDerivatives: true}},
+	{"CC-BY-SA-", LicenseTerms{Attribution: true, ShareAlike: true}},
+	{"CC-BY-", LicenseTerms{Attribution: true}},
+	{"CC0-", LicenseTerms{PublicDomain: true}},
+	{"CC-PDDC", LicenseTerms{PublicDomain: true}},
+	{"PDDL-", LicenseTerms{PublicDomain: true}},
+	{"Unlicense", LicenseTerms{PublicDomain: true}},
+	{"0BSD", LicenseTerms{PublicDomain: true}},
+	{"ODbL-", LicenseTerms{Attribution: true, ShareAlike: true}},
+	{"ODC-By-", LicenseTerms{Attribution: true}},
+	{"OGL-", LicenseTerms{Attribution: true}},
+	{"AGPL-", LicenseTerms{Attribution: true, ShareAlike: true}},
+	{"GPL-", LicenseTerms{Attribution: true, ShareAlike: true}},
+	{"EUPL-", LicenseTerms{Attribution: true, ShareAlike: true}},
+	{"LGPL-", LicenseTerms{Attribution: true}},
+	{"MPL-", LicenseTerms{Attribution: true}},
+	{"EPL-", LicenseTerms{Attribution: true}},
+	{"Apache-", LicenseTerms{Attribution: true}},
+	{"BSD-", LicenseTerms{Attribution: true}},
+	{"MIT", LicenseTerms{Attribution: true}},
+	{"ISC", LicenseTerms{Attribution: true}},
+	{"Zlib", LicenseTerms{Attribution: true}},
+}
+
+// Terms gives the conditions of an SPDX license. ok is false for licenses
+// whose terms aren't known
+func Terms(id string) (terms LicenseTerms, ok bool) {
+	if _, known := spdxLicenses[id]; !known {
+		return LicenseTerms{}, false
+	}
+	for _, f := range licenseFamilies {
+		if strings.HasPrefix(id, f.prefix) || id == strings.TrimSuffix(f.prefix, "-") {
+			return f.terms, true
+		}
+	}
+	return LicenseTerms{}, false
+}
+
+// ccVersion trims the jurisdiction from a creative commons license version
+func ccVersion(s string) string {
+	return strings.SplitN(s, "-", 2)[0]
+}
+
+var gplVersion = regexp.MustCompile(`^(A?GPL)-(\d)\.0(-only|-or-later|\+)?$`)
+
+// shareAlikeCompatible checks if work under license a may be relicensed
+// under share-alike license b
+func shareAlikeCompatible(a, b string) bool {
+	if a == b {
+		return true
+	}
+	// creative commons share-alike licenses permit later versions and
+	// jurisdiction ports of the same terms
+	for _, family := range []string{"CC-BY-SA-", "CC-BY-NC-SA-"} {
+		if strings.HasPrefix(a, family) && strings.HasPrefix(b, family) {
+			return ccVersion(strings.TrimPrefix(b, family)) >= ccVersion(strings.TrimPrefix(a, family))
+		}
+	}
+	// CC BY-SA 4.0 adaptations may be licensed under GPLv3
+	if a == "CC-BY-SA-4.0" && strings.HasPrefix(b, "GPL-3.0") {
+		return true
+	}
+	// "or later" GPL licenses accept later GPL versions
+	am, bm := gplVersion.FindStringSubmatch(a), gplVersion.FindStringSubmatch(b)
+	if am != nil && bm != nil && am[1] == bm[1] {
+		orLater := am[3] == "-or-later" || am[3] == "+"
+		return am[2] == bm[2] || orLater && bm[2] > am[2]
+	}
+	return false
+}
+
+// shareAlikeCombinable checks if works under two share-alike licenses can be
+// combined under either license, or a license both permit relicensing to
+func shareAlikeCombinable(a, b string) bool {
+	for _, target := range []string{a, b, "GPL-3.0-only", "GPL-3.0-or-later"} {
+		if shareAlikeCompatible(a, target) && shareAlikeCompatible(b, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// LicenseConflict describes a license incompatibility between two transform
+// resources, or between a resource and the dataset a transform produces
+type LicenseConflict struct {
+	// Resource is the name of the resource with a conflicting license
+	Resource string
+	// With is the name of the other resource in the conflict, empty when the
+	// conflict is with the produced dataset
+	With string
+	// Reason describes the conflict
+	Reason string
+}
+
+// String implements the stringer interface
+func (c LicenseConflict) String() string {
+	if c.With == "" {
+		return fmt.Sprintf("%s: %s", c.Resource, c.Reason)
+	}
+	return fmt.Sprintf("%s, %s: %s", c.Resource, c.With, c.Reason)
+}
+
+// LicenseConflicts checks a set of named input licenses for conflicts when
+// combined into a single derived work with the output license. A nil output
+// license skips checks against the output. Inputs without a license or with
+// a license of unknown terms are reported as conflicts, because their
+// compatibility can't be established
+func LicenseConflicts(output *License, inputs map[string]*License) []LicenseConflict {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var conflicts []LicenseConflict
+	ids := map[string]string{}
+	terms := map[string]LicenseTerms{}
+	for _, name := range names {
+		l := inputs[name]
+		if l == nil || (l.Type == "" && l.URL == "") {
+			conflicts = append(conflicts, LicenseConflict{Resource: name, Reason: "no license"})
+			continue
+		}
+		id := l.SPDXID()
+		t, ok := Terms(id)
+		if !ok {
+			conflicts = append(conflicts, LicenseConflict{Resource: name, Reason: fmt.Sprintf("unrecognized license '%s'", licenseLabel(l))})
+			continue
+		}
+		if t.NoDerivatives {
+			conflicts = append(conflicts, LicenseConflict{Resource: name, Reason: fmt.Sprintf("%s doesn't permit derived works", id)})
+		}
+		ids[name], terms[name] = id, t
+	}
+
+	for i, a := range names {
+		if _, ok := ids[a]; !ok {
+			continue
+		}
+		for _, b := range names[i+1:] {
+			if _, ok := ids[b]; !ok {
+				continue
+			}
+			if reason := combineConflict(ids[a], terms[a], ids[b], terms[b]); reason != "" {
+				conflicts = append(conflicts, LicenseConflict{Resource: a, With: b, Reason: reason})
+			}
+		}
+	}
+
+	if output == nil {
+		return conflicts
+	}
+	outID := output.SPDXID()
+	outTerms, outKnown := Terms(outID)
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			continue
+		}
+		t := terms[name]
+		switch {
+		case t.ShareAlike && !shareAlikeCompatible(id, outID):
+			conflicts = append(conflicts, LicenseConflict{Resource: name, Reason: fmt.Sprintf("%s requires derived works use the same license, dataset is licensed %s", id, licenseLabel(output))})
+		case t.NonCommercial && outKnown && !outTerms.NonCommercial:
+			conflicts = append(conflicts, LicenseConflict{Resource: name, Reason: fmt.Sprintf("%s prohibits commercial use, dataset license %s permits it", id, outID)})
+		}
+	}
+	return conflicts
+}
+
+// combineConflict describes why two licenses can't be combined, returning an
+// empty string for compatible licenses
+func combineConflict(a string, at LicenseTerms, b string, bt LicenseTerms) string {
+	switch {
+	case at.ShareAlike && bt.ShareAlike && !shareAlikeCombinable(a, b):
+		return fmt.Sprintf("%s and %s both require derived works use their own license", a, b)
+	case at.ShareAlike && !at.NonCommercial && bt.NonCommercial:
+		return fmt.Sprintf("%s requires derived works permit commercial use, %s prohibits it", a, b)
+	case bt.ShareAlike && !bt.NonCommercial && at.NonCommercial:
+		return fmt.Sprintf("%s requires derived works permit commercial use, %s prohibits it", b, a)
+	}
+	return ""
+}
+
+func licenseLabel(l *License) string {
+	if l == nil {
+		return "unlicensed"
+	}
+	if l.Type != "" {
+		return l.Type
+	}
+	return l.URL
+}
+
+// TransformLicenseConflicts resolves the resources of a dataset's transform
+// and checks their licenses against each other and the dataset license.
+// Conflicts are keyed by transform resource name
+func TransformLicenseConflicts(ctx context.Context, ds *Dataset, resolver DatasetResolver) ([]LicenseConflict, error) {
+	if ds == nil || ds.Transform == nil || len(ds.Transform.Resources) == 0 {
+		return nil, nil
+	}
+	if resolver == nil {
+		return nil, ErrNoResolver
+	}
+
+	inputs := map[string]*License{}
+	for name, r := range ds.Transform.Resources {
+		if r == nil {
+			continue
+		}
+		res, err := resolver.ResolveDataset(ctx, r.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resolving resource %s: %s", name, err.Error())
+		}
+		inputs[name] = nil
+		if res != nil && res.Meta != nil {
+			inputs[name] = res.Meta.License
+		}
+	}
+
+	var output *License
+	if ds.Meta != nil {
+		output = ds.Meta.License
+	}
+	return LicenseConflicts(output, inputs), nil
+}
@@ -0,0 +1,234 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeLicenseID(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect string
+		err    string
+	}{
+		{"MIT", "MIT", ""},
+		{"mit", "MIT", ""},
+		{"MIT License", "MIT", ""},
+		{"cc by 4.0", "CC-BY-4.0", ""},
+		{"CC BY-SA 4.0", "CC-BY-SA-4.0", ""},
+		{"cc-by-nc-nd-4.0", "CC-BY-NC-ND-4.0", ""},
+		{"Creative Commons Attribution 4.0 International", "CC-BY-4.0", ""},
+		{"Creative Commons Attribution-ShareAlike 3.0 Unported", "CC-BY-SA-3.0", ""},
+		{"Creative Commons Attribution-NonCommercial-ShareAlike 4.0", "CC-BY-NC-SA-4.0", ""},
+		{"CC0", "CC0-1.0", ""},
+		{"CC0 1.0 Universal", "CC0-1.0", ""},
+		{"Apache License, Version 2.0", "Apache-2.0", ""},
+		{"GNU General Public License v3.0 or later", "GPL-3.0-or-later", ""},
+		{"Open Data Commons Open Database License v1.0", "ODbL-1.0", ""},
+		{"odbl", "ODbL-1.0", ""},
+		{"Open Government Licence v3.0", "OGL-UK-3.0", ""},
+		{"https://creativecommons.org/licenses/by-sa/4.0/", "CC-BY-SA-4.0", ""},
+		{"http://creativecommons.org/licenses/by/3.0/de/", "CC-BY-3.0-DE", ""},
+		{"https://creativecommons.org/licenses/by/4.0/legalcode", "CC-BY-4.0", ""},
+		{"https://creativecommons.org/publicdomain/zero/1.0/", "CC0-1.0", ""},
+		{"http://opendatacommons.org/licenses/pddl/", "PDDL-1.0", ""},
+		{"https://opendatacommons.org/licenses/odbl/1-0/", "ODbL-1.0", ""},
+		{"http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/", "OGL-UK-3.0", ""},
+		{"https://www.apache.org/licenses/LICENSE-2.0", "Apache-2.0", ""},
+		{"https://spdx.org/licenses/BSD-3-Clause.html", "BSD-3-Clause", ""},
+		{"https://opensource.org/licenses/MIT", "MIT", ""},
+		{"", "", "unknown license"},
+		{"all rights reserved", "", "unknown license"},
+		{"https://example.com/license", "", "unknown license"},
+	}
+
+	for i, c := range cases {
+		got, err := NormalizeLicenseID(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d %q error mismatch. expected: '%s', got: '%s'", i, c.in, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d %q mismatch. expected: '%s', got: '%s'", i, c.in, c.expect, got)
+		}
+	}
+}
+
+func TestLicenseValidate(t *testing.T) {
+	cases := []struct {
+		l   *License
+		err string
+	}{
+		{&License{Type: "CC-BY-4.0"}, ""},
+		{nil, "license type is required"},
+		{&License{URL: "https://opensource.org/licenses/MIT"}, "license type is required"},
+		{&License{Type: "GPL-2.0"}, "license type 'GPL-2.0' is a deprecated SPDX identifier"},
+		{&License{Type: "cc by 4.0"}, "license type 'cc by 4.0' isn't an SPDX identifier, did you mean 'CC-BY-4.0'?"},
+		{&License{Type: "custom"}, "license type 'custom' isn't an SPDX identifier"},
+	}
+
+	for i, c := range cases {
+		err := c.l.Validate()
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestLicenseNormalize(t *testing.T) {
+	cases := []struct {
+		l      *License
+		expect *License
+		err    string
+	}{
+		{&License{Type: "cc by 4.0"}, &License{Type: "CC-BY-4.0", URL: "https://spdx.org/licenses/CC-BY-4.0.html"}, ""},
+		{&License{Type: "mit", URL: "https://example.com/mit"}, &License{Type: "MIT", URL: "https://example.com/mit"}, ""},
+		{&License{URL: "https://creativecommons.org/licenses/by/4.0/"}, &License{Type: "CC-BY-4.0", URL: "https://creativecommons.org/licenses/by/4.0/"}, ""},
+		{&License{Type: "custom"}, &License{Type: "custom"}, "unknown license: 'custom'"},
+	}
+
+	for i, c := range cases {
+		err := c.l.Normalize()
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, c.l); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	cases := []struct {
+		id     string
+		expect LicenseTerms
+		ok     bool
+	}{
+		{"CC-BY-4.0", LicenseTerms{Attribution: true}, true},
+		{"CC-BY-NC-SA-4.0", LicenseTerms{Attribution: true, NonCommercial: true, ShareAlike: true}, true},
+		{"CC0-1.0", LicenseTerms{PublicDomain: true}, true},
+		{"ODbL-1.0", LicenseTerms{Attribution: true, ShareAlike: true}, true},
+		{"MIT", LicenseTerms{Attribution: true}, true},
+		{"MIT-0", LicenseTerms{Attribution: true}, true},
+		{"Unlicense", LicenseTerms{PublicDomain: true}, true},
+		{"Beerware", LicenseTerms{}, false},
+		{"not-a-license", LicenseTerms{}, false},
+	}
+
+	for i, c := range cases {
+		got, ok := Terms(c.id)
+		if ok != c.ok {
+			t.Errorf("case %d ok mismatch. expected: %t, got: %t", i, c.ok, ok)
+		}
+		if got != c.expect {
+			t.Errorf("case %d terms mismatch. expected: %#v, got: %#v", i, c.expect, got)
+		}
+	}
+}
+
+func TestLicenseConflicts(t *testing.T) {
+	cases := []struct {
+		description string
+		output      *License
+		inputs      map[string]*License
+		expect      []LicenseConflict
+	}{
+		{"permissive inputs",
+			&License{Type: "CC-BY-4.0"},
+			map[string]*License{"a": {Type: "MIT"}, "b": {Type: "CC0-1.0"}, "c": {Type: "CC-BY-4.0"}},
+			nil},
+		{"share-alike inputs require share-alike output",
+			&License{Type: "CC-BY-4.0"},
+			map[string]*License{"a": {Type: "CC-BY-SA-4.0"}, "b": {Type: "MIT"}},
+			[]LicenseConflict{{Resource: "a", Reason: "CC-BY-SA-4.0 requires derived works use the same license, dataset is licensed CC-BY-4.0"}}},
+		{"later share-alike versions are compatible",
+			&License{Type: "CC-BY-SA-4.0"},
+			map[string]*License{"a": {Type: "CC-BY-SA-3.0"}, "b": {Type: "cc by-sa 4.0"}},
+			nil},
+		{"earlier share-alike versions aren't",
+			&License{Type: "CC-BY-SA-3.0"},
+			map[string]*License{"a": {Type: "CC-BY-SA-4.0"}},
+			[]LicenseConflict{{Resource: "a", Reason: "CC-BY-SA-4.0 requires derived works use the same license, dataset is licensed CC-BY-SA-3.0"}}},
+		{"competing share-alike licenses",
+			nil,
+			map[string]*License{"osm": {Type: "ODbL-1.0"}, "wiki": {Type: "CC-BY-SA-4.0"}},
+			[]LicenseConflict{{Resource: "osm", With: "wiki", Reason: "ODbL-1.0 and CC-BY-SA-4.0 both require derived works use their own license"}}},
+		{"gpl or later",
+			&License{Type: "GPL-3.0-only"},
+			map[string]*License{"a": {Type: "GPL-2.0-or-later"}, "b": {Type: "GPL-3.0-only"}, "c": {Type: "CC-BY-SA-4.0"}},
+			nil},
+		{"non-commercial & share-alike",
+			&License{Type: "CC-BY-NC-SA-4.0"},
+			map[string]*License{"a": {Type: "CC-BY-NC-4.0"}, "b": {Type: "CC-BY-SA-4.0"}},
+			[]LicenseConflict{
+				{Resource: "a", With: "b", Reason: "CC-BY-SA-4.0 requires derived works permit commercial use, CC-BY-NC-4.0 prohibits it"},
+				{Resource: "b", Reason: "CC-BY-SA-4.0 requires derived works use the same license, dataset is licensed CC-BY-NC-SA-4.0"},
+			}},
+		{"non-commercial input, commercial output",
+			&License{Type: "MIT"},
+			map[string]*License{"a": {Type: "CC-BY-NC-4.0"}},
+			[]LicenseConflict{{Resource: "a", Reason: "CC-BY-NC-4.0 prohibits commercial use, dataset license MIT permits it"}}},
+		{"no derivatives, missing & unknown licenses",
+			nil,
+			map[string]*License{"a": {Type: "CC-BY-ND-4.0"}, "b": nil, "c": {Type: "custom terms"}},
+			[]LicenseConflict{
+				{Resource: "a", Reason: "CC-BY-ND-4.0 doesn't permit derived works"},
+				{Resource: "b", Reason: "no license"},
+				{Resource: "c", Reason: "unrecognized license 'custom terms'"},
+			}},
+	}
+
+	for _, c := range cases {
+		got := LicenseConflicts(c.output, c.inputs)
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%s: result mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestTransformLicenseConflicts(t *testing.T) {
+	ctx := context.Background()
+	resolver := mapResolver{
+		"/osm":  {Meta: &Meta{License: &License{Type: "ODbL-1.0"}}},
+		"/wiki": {Meta: &Meta{License: &License{URL: "https://creativecommons.org/licenses/by-sa/4.0/"}}},
+		"/bare": {},
+	}
+	ds := &Dataset{
+		Meta: &Meta{License: &License{Type: "ODbL-1.0"}},
+		Transform: &Transform{Resources: map[string]*TransformResource{
+			"osm":  {Path: "/osm"},
+			"wiki": {Path: "/wiki"},
+			"bare": {Path: "/bare"},
+		}},
+	}
+
+	got, err := TransformLicenseConflicts(ctx, ds, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []LicenseConflict{
+		{Resource: "bare", Reason: "no license"},
+		{Resource: "osm", With: "wiki", Reason: "ODbL-1.0 and CC-BY-SA-4.0 both require derived works use their own license"},
+		{Resource: "wiki", Reason: "CC-BY-SA-4.0 requires derived works use the same license, dataset is licensed ODbL-1.0"},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+	if s := got[1].String(); s != "osm, wiki: ODbL-1.0 and CC-BY-SA-4.0 both require derived works use their own license" {
+		t.Errorf("string mismatch. got: %s", s)
+	}
+
+	if got, err := TransformLicenseConflicts(ctx, &Dataset{}, nil); err != nil || got != nil {
+		t.Errorf("expected datasets without transforms to have no conflicts. got: %v, %v", got, err)
+	}
+	if _, err := TransformLicenseConflicts(ctx, ds, nil); err != ErrNoResolver {
+		t.Errorf("expected no resolver error, got: %v", err)
+	}
+	ds.Transform.Resources["missing"] = &TransformResource{Path: "/missing"}
+	if _, err := TransformLicenseConflicts(ctx, ds, resolver); err == nil || err.Error() != "resolving resource missing: not found" {
+		t.Errorf("expected resolution error, got: %v", err)
+	}
+}
@@ -110,13 +110,16 @@ func (md *Meta) Meta() map[string]interface{} {
 
 // UnmarshalMeta tries to extract a metadata type from an empty
 // interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
-func UnmarshalMeta(v interface{}) (*Meta, error) {
+func UnmarshalMeta(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Meta, error) {
 	switch r := v.(type) {
 	case *Meta:
 		return r, nil
 	case Meta:
 		return &r, nil
 	case []byte:
+		if err := checkStrict(KindMeta, r, configs); err != nil {
+			return nil, err
+		}
 		metadata := &Meta{}
 		err := json.Unmarshal(r, metadata)
 		return metadata, err
@@ -392,6 +395,11 @@ func (md *Meta) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	data, err := upgradeJSON(data, KindMeta)
+	if err != nil {
+		return err
+	}
+
 	d := _metadata{}
 	if err := json.Unmarshal(data, &d); err != nil {
 		return fmt.Errorf("error unmarshling dataset metadata: %s", err.Error())
@@ -516,68 +524,12 @@ type Theme struct {
 }
 
 // AccuralDuration takes an ISO 8601 periodicity measure & returns a
-// time.Duration invalid periodicities return time.Duration(0)
+// time.Duration using nominal 365 day years & 30 day months. invalid
+// periodicities return time.Duration(0)
 func AccuralDuration(p string) time.Duration {
-	switch p {
-	// Decennial
-	case "R/P10Y":
-		return time.Duration(time.Hour * 24 * 365 * 10)
-	// Quadrennial
-	case "R/P4Y":
-		return time.Duration(time.Hour * 24 * 365 * 4)
-	// Annual
-	case "R/P1Y":
-		return time.Duration(time.Hour * 24 * 365)
-	// Bimonthly
-	case "R/P2M":
-		return time.Duration(time.Hour * 24 * 30 * 10)
-	// Semiweekly
-	case "R/P3.5D":
-		// TODO - inaccurate
-		return time.Duration(time.Hour * 24 * 4)
-	// Daily
-	case "R/P1D":
-		return time.Duration(time.Hour * 24)
-	// Biweekly
-	case "R/P2W":
-		return time.Duration(time.Hour * 24 * 14)
-	// Semiannual
-	case "R/P6M":
-		return time.Duration(time.Hour * 24 * 30 * 6)
-	// Biennial
-	case "R/P2Y":
-		return time.Duration(time.Hour * 24 * 365 * 2)
-	// Triennial
-	case "R/P3Y":
-		return time.Duration(time.Hour * 24 * 365 * 3)
-	// Three times a week
-	case "R/P0.33W":
-		return time.Duration((time.Hour * 24 * 7) / 3)
-	// Three times a month
-	case "R/P0.33M":
-		return time.Duration((time.Hour * 24 * 30) / 3)
-	// Continuously updated
-	case "R/PT1S":
-		return time.Second
-	// Monthly
-	case "R/P1M":
-		return time.Duration(time.Hour * 24 * 30)
-	// Quarterly
-	case "R/P3M":
-		return time.Duration((time.Hour * 24 * 365) / 7)
-	// Semimonthly
-	case "R/P0.5M":
-		return time.Duration(time.Hour * 24 * 15)
-	// Three times a year
-	case "R/P4M":
-		return time.Duration((time.Hour * 24 * 365) / 4)
-	// Weekly
-	case "R/P1W":
-		return time.Duration(time.Hour * 24 * 7)
-	// Hourly
-	case "R/PT1H":
-		return time.Hour
-	default:
+	ri, err := parseAccrualPeriodicity(p)
+	if err != nil {
 		return time.Duration(0)
 	}
+	return ri.Duration.Approximate()
 }
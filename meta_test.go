@@ -334,20 +334,23 @@ func TestAccrualDuration(t *testing.T) {
 		{"R/P10Y", time.Duration(315360000000000000)},
 		{"R/P4Y", time.Duration(126144000000000000)},
 		{"R/P1Y", time.Duration(31536000000000000)},
-		{"R/P2M", time.Duration(25920000000000000)},
-		{"R/P3.5D", time.Duration(345600000000000)},
+		{"R/P2M", time.Hour * 24 * 60},
+		{"R/P3.5D", time.Hour * 84},
 		{"R/P1D", time.Duration(86400000000000)},
 		{"R/P2W", time.Duration(1209600000000000)},
 		{"R/P6M", time.Duration(15552000000000000)},
 		{"R/P2Y", time.Duration(63072000000000000)},
 		{"R/P3Y", time.Duration(94608000000000000)},
-		{"R/P0.33W", time.Duration(201600000000000)},
-		{"R/P0.33M", time.Duration(864000000000000)},
+		{"R/P0.33W", time.Duration(199584000000000)},
+		{"R/P0.33M", time.Duration(855360000000000)},
 		{"R/PT1S", time.Duration(1000000000)},
 		{"R/P1M", time.Duration(2592000000000000)},
-		{"R/P3M", time.Duration(4505142857142857)},
+		{"R/P3M", time.Hour * 24 * 90},
 		{"R/P0.5M", time.Duration(1296000000000000)},
-		{"R/P4M", time.Duration(7884000000000000)},
+		{"R/P4M", time.Hour * 24 * 120},
+		{"P1W", time.Duration(604800000000000)},
+		{"R5/2019-01-01/P1D", time.Duration(86400000000000)},
+		{"R/P1X", time.Duration(0)},
 		{"R/P1W", time.Duration(604800000000000)},
 		{"R/PT1H", time.Duration(3600000000000)},
 	}
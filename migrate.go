@@ -0,0 +1,283 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Migration upgrades documents of one kind type from version From to version
+// From+1. Migrations work on decoded JSON so documents from any version of the
+// spec can be read, even when they no longer fit go types
+type Migration struct {
+	// Type is the kind type this migration applies to, eg: "ds" or "st"
+	Type string
+	// From is the version this migration upgrades from
+	From int
+	// Up converts a document from version From to From+1 in place
+	Up func(doc map[string]interface{}) error
+	// Down converts a document from version From+1 to From in place. Down is
+	// optional, migrations without Down can't be reversed
+	Down func(doc map[string]interface{}) error
+}
+
+// datasetComponents are the fields of a dataset that hold components
+var datasetComponents = []struct {
+	key  string
+	kind Kind
+}{
+	{"commit", KindCommit},
+	{"meta", KindMeta},
+	{"readme", KindReadme},
+	{"stats", KindStats},
+	{"structure", KindStructure},
+	{"transform", KindTransform},
+	{"viz", KindViz},
+}
+
+// MigrationRegistry holds migrations between versions of the dataset spec
+type MigrationRegistry struct {
+	current int
+	steps   map[string]map[int]Migration
+}
+
+// NewMigrationRegistry creates an empty registry that migrates documents up to
+// version current
+func NewMigrationRegistry(current int) *MigrationRegistry {
+	return &MigrationRegistry{
+		current: current,
+		steps:   map[string]map[int]Migration{},
+	}
+}
+
+// Register adds a migration to the registry. Only one migration can be
+// registered for each type & version
+func (r *MigrationRegistry) Register(m Migration) error {
+	if len(m.Type) != 2 {
+		return fmt.Errorf("invalid migration type: '%s'", m.Type)
+	}
+	if m.From < 0 || m.From >= r.current {
+		return fmt.Errorf("invalid migration version for %s: %d. must be less than current version %d", m.Type, m.From, r.current)
+	}
+	if m.Up == nil {
+		return fmt.Errorf("migration %s:%d requires an Up function", m.Type, m.From)
+	}
+	if r.steps[m.Type] == nil {
+		r.steps[m.Type] = map[int]Migration{}
+	}
+	if _, ok := r.steps[m.Type][m.From]; ok {
+		return fmt.Errorf("migration %s:%d is already registered", m.Type, m.From)
+	}
+	r.steps[m.Type][m.From] = m
+	return nil
+}
+
+// Migrate converts a decoded document to the given version one step at a
+// time, upgrading or downgrading as needed. The document kind is read from
+// it's "qri" field, documents without a kind are left untouched.
+// Steps with no registered migration only change the kind version.
+// Dataset documents are migrated before the components they hold, components
+// of a dataset without a kind are migrated from version 0
+func (r *MigrationRegistry) Migrate(doc map[string]interface{}, version int) error {
+	if version < 0 || version > r.current {
+		return fmt.Errorf("unsupported spec version: %d", version)
+	}
+	str, ok := doc["qri"].(string)
+	if !ok || str == "" {
+		return nil
+	}
+	kind := Kind(str)
+	v, err := kind.VersionNumber()
+	if err != nil {
+		return err
+	}
+	if v > r.current {
+		return fmt.Errorf("unsupported kind %s: newer than current spec version %d", kind, r.current)
+	}
+
+	t := kind.Type()
+	for ; v < version; v++ {
+		if m, ok := r.steps[t][v]; ok {
+			if err := m.Up(doc); err != nil {
+				return fmt.Errorf("migrating %s:%d to version %d: %s", t, v, v+1, err.Error())
+			}
+		}
+		doc["qri"] = fmt.Sprintf("%s:%d", t, v+1)
+	}
+	for ; v > version; v-- {
+		if m, ok := r.steps[t][v-1]; ok {
+			if m.Down == nil {
+				return fmt.Errorf("migration %s:%d can't be reversed", t, v-1)
+			}
+			if err := m.Down(doc); err != nil {
+				return fmt.Errorf("migrating %s:%d to version %d: %s", t, v, v-1, err.Error())
+			}
+		}
+		doc["qri"] = fmt.Sprintf("%s:%d", t, v-1)
+	}
+
+	if t == KindDataset.Type() {
+		for _, c := range datasetComponents {
+			if cmp, ok := doc[c.key].(map[string]interface{}); ok {
+				// components without a kind are from version 0
+				if str, _ := cmp["qri"].(string); str == "" {
+					cmp["qri"] = fmt.Sprintf("%s:0", c.kind.Type())
+				}
+				if err := r.Migrate(cmp, version); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MigrateJSON converts a JSON-encoded document to the given spec version.
+// Valid JSON that isn't an object, like a path reference, is returned as-is
+func (r *MigrationRegistry) MigrateJSON(data []byte, version int) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decoding document: %s", err.Error())
+	}
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	if err := r.Migrate(doc, version); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// migrations is the registry of changes to the dataset spec
+var migrations = NewMigrationRegistry(currentSpecVersion())
+
+func currentSpecVersion() int {
+	v, err := strconv.Atoi(CurrentSpecVersion)
+	if err != nil {
+		panic(fmt.Errorf("invalid CurrentSpecVersion: %s", CurrentSpecVersion))
+	}
+	return v
+}
+
+// RegisterMigration adds a migration to the package registry, migrations
+// registered here are applied when unmarshaling documents with older kinds
+func RegisterMigration(m Migration) error {
+	return migrations.Register(m)
+}
+
+// MigrateJSON converts a JSON-encoded dataset or component to the given spec
+// version using registered migrations. Use MigrateJSON with a version older
+// than CurrentSpecVersion to export documents for older readers
+func MigrateJSON(data []byte, version int) ([]byte, error) {
+	return migrations.MigrateJSON(data, version)
+}
+
+// MarshalJSONVersion encodes a dataset or component as JSON in the given
+// version of the spec
+func MarshalJSONVersion(v json.Marshaler, version int) ([]byte, error) {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return MigrateJSON(data, version)
+}
+
+// upgradeJSON migrates JSON object data with an older kind to the current
+// spec version, data that is already current is returned unchanged. Objects
+// without a kind are documents of kind's type from version 0. The version is
+// read without decoding the document, so only documents that need migrating
+// are decoded
+func upgradeJSON(data []byte, kind Kind) ([]byte, error) {
+	str, isObject := documentKind(data)
+	if !isObject {
+		return data, nil
+	}
+	v := 0
+	if str != "" {
+		var err error
+		if v, err = Kind(str).VersionNumber(); err != nil {
+			return nil, err
+		}
+	}
+	if v == migrations.current {
+		return data, nil
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if str == "" {
+		doc["qri"] = fmt.Sprintf("%s:0", kind.Type())
+	}
+	if err := migrations.Migrate(doc, migrations.current); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// documentKind scans JSON data for the "qri" field of a top-level object,
+// stopping as soon as it's found. isObject is false if data isn't an object
+func documentKind(data []byte) (kind string, isObject bool) {
+	depth := 0
+	expectKey := false
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r', ':':
+		case '{':
+			depth++
+			expectKey = depth == 1
+		case '[':
+			if depth == 0 {
+				return "", false
+			}
+			depth++
+		case '}', ']':
+			if depth--; depth == 0 {
+				return "", true
+			}
+		case ',':
+			expectKey = depth == 1
+		case '"':
+			end := stringEnd(data, i)
+			if end < 0 {
+				return "", depth > 0
+			}
+			if expectKey && string(data[i+1:end]) == "qri" {
+				// the value is the next string after the colon
+				j := end + 1
+				for j < len(data) && (data[j] == ' ' || data[j] == '\t' || data[j] == '\n' || data[j] == '\r' || data[j] == ':') {
+					j++
+				}
+				if j < len(data) && data[j] == '"' {
+					if valEnd := stringEnd(data, j); valEnd > 0 {
+						json.Unmarshal(data[j:valEnd+1], &kind)
+					}
+				}
+				return kind, true
+			}
+			expectKey = false
+			i = end
+		default:
+			if depth == 0 {
+				return "", false
+			}
+		}
+	}
+	return "", depth > 0
+}
+
+// stringEnd gives the index of the closing quote of the JSON string starting
+// at data[start], or -1 if the string isn't terminated
+func stringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,215 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// renameField produces a migration func that moves a document field
+func renameField(from, to string) func(doc map[string]interface{}) error {
+	return func(doc map[string]interface{}) error {
+		if v, ok := doc[from]; ok {
+			doc[to] = v
+			delete(doc, from)
+		}
+		return nil
+	}
+}
+
+func testMigrationRegistry(t *testing.T) *MigrationRegistry {
+	r := NewMigrationRegistry(2)
+	migrations := []Migration{
+		{Type: "st", From: 0, Up: renameField("fmt", "format"), Down: renameField("format", "fmt")},
+		{Type: "st", From: 1, Up: renameField("len", "length"), Down: renameField("length", "len")},
+		{Type: "ds", From: 1, Up: renameField("prev", "previousPath")},
+		{Type: "md", From: 0, Up: func(doc map[string]interface{}) error {
+			return fmt.Errorf("oh noes")
+		}},
+	}
+	for _, m := range migrations {
+		if err := r.Register(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestMigrationRegistryRegister(t *testing.T) {
+	up := renameField("a", "b")
+	cases := []struct {
+		m   Migration
+		err string
+	}{
+		{Migration{Type: "st", From: 0, Up: up}, "migration st:0 is already registered"},
+		{Migration{Type: "st", From: 2, Up: up}, "invalid migration version for st: 2. must be less than current version 2"},
+		{Migration{Type: "st", From: -1, Up: up}, "invalid migration version for st: -1. must be less than current version 2"},
+		{Migration{Type: "structure", From: 0, Up: up}, "invalid migration type: 'structure'"},
+		{Migration{Type: "vz", From: 0}, "migration vz:0 requires an Up function"},
+		{Migration{Type: "vz", From: 0, Up: up}, ""},
+	}
+
+	r := testMigrationRegistry(t)
+	for i, c := range cases {
+		err := r.Register(c.m)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+func TestMigrationRegistryMigrateJSON(t *testing.T) {
+	cases := []struct {
+		description string
+		in          string
+		version     int
+		expect      string
+		err         string
+	}{
+		{"path reference is unchanged", `"/ipfs/QmFoo"`, 2, `"/ipfs/QmFoo"`, ""},
+		{"no kind is unchanged", `{"fmt":"csv"}`, 2, `{"fmt":"csv"}`, ""},
+		{"current version is unchanged", `{"qri":"st:2","format":"csv"}`, 2, `{"format":"csv","qri":"st:2"}`, ""},
+		{"upgrade in steps", `{"qri":"st:0","fmt":"csv","len":10}`, 2, `{"format":"csv","length":10,"qri":"st:2"}`, ""},
+		{"upgrade one step", `{"qri":"st:0","fmt":"csv","len":10}`, 1, `{"format":"csv","len":10,"qri":"st:1"}`, ""},
+		{"downgrade", `{"qri":"st:2","format":"csv","length":10}`, 0, `{"fmt":"csv","len":10,"qri":"st:0"}`, ""},
+		{"unregistered steps bump version", `{"qri":"vz:0","format":"html"}`, 2, `{"format":"html","qri":"vz:2"}`, ""},
+		{"dataset components",
+			`{"qri":"ds:0","prev":"/ipfs/QmPrev","structure":{"qri":"st:0","fmt":"json"},"viz":"/ipfs/QmViz"}`, 2,
+			`{"previousPath":"/ipfs/QmPrev","qri":"ds:2","structure":{"format":"json","qri":"st:2"},"viz":"/ipfs/QmViz"}`, ""},
+
+		{"irreversible", `{"qri":"ds:2"}`, 0, "", "migration ds:1 can't be reversed"},
+		{"failing migration", `{"qri":"md:0"}`, 2, "", "migrating md:0 to version 1: oh noes"},
+		{"newer version", `{"qri":"st:3"}`, 2, "", "unsupported kind st:3: newer than current spec version 2"},
+		{"invalid version", `{"qri":"st:x"}`, 2, "", "invalid kind: 'st:x'. version must be a non-negative integer"},
+		{"unsupported target", `{"qri":"st:0"}`, 3, "", "unsupported spec version: 3"},
+		{"invalid json", `{"qri":"st:0"`, 2, "", "decoding document: unexpected end of JSON input"},
+	}
+
+	r := testMigrationRegistry(t)
+	for _, c := range cases {
+		got, err := r.MigrateJSON([]byte(c.in), c.version)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("%s: error mismatch. expected: '%s', got: '%s'", c.description, c.err, err)
+			continue
+		}
+		if c.err == "" && string(got) != c.expect {
+			t.Errorf("%s: result mismatch.\nexpected: %s\ngot:      %s", c.description, c.expect, string(got))
+		}
+	}
+}
+
+func TestUnmarshalUpgrades(t *testing.T) {
+	prev := migrations
+	defer func() { migrations = prev }()
+	migrations = testMigrationRegistry(t)
+
+	st := &Structure{}
+	if err := json.Unmarshal([]byte(`{"qri":"st:0","fmt":"csv","len":10}`), st); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&Structure{Qri: "st:2", Format: "csv", Length: 10}, st); diff != "" {
+		t.Errorf("structure mismatch (-want +got):\n%s", diff)
+	}
+
+	ds := &Dataset{}
+	if err := json.Unmarshal([]byte(`{"qri":"ds:1","prev":"/ipfs/QmPrev","structure":{"qri":"st:1","format":"json","len":2}}`), ds); err != nil {
+		t.Fatal(err)
+	}
+	expect := &Dataset{Qri: "ds:2", PreviousPath: "/ipfs/QmPrev", Structure: &Structure{Qri: "st:2", Format: "json", Length: 2}}
+	if err := CompareDatasets(expect, ds); err != nil {
+		t.Errorf("dataset mismatch: %s", err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"qri":"md:0"}`), &Meta{}); err == nil || err.Error() != "migrating md:0 to version 1: oh noes" {
+		t.Errorf("expected migration error, got: %s", err)
+	}
+}
+
+func TestUnmarshalNewerKind(t *testing.T) {
+	err := json.Unmarshal([]byte(`{"qri":"cm:5","title":"from the future"}`), &Commit{})
+	expect := "unsupported kind cm:5: newer than current spec version 0"
+	if err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: '%s', got: '%s'", expect, err)
+	}
+}
+
+func TestMarshalJSONVersion(t *testing.T) {
+	prev := migrations
+	defer func() { migrations = prev }()
+	migrations = testMigrationRegistry(t)
+
+	st := &Structure{Qri: "st:2", Format: "csv", Length: 10}
+	got, err := MarshalJSONVersion(st, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"fmt":"csv","len":10,"qri":"st:0"}`
+	if string(got) != expect {
+		t.Errorf("result mismatch.\nexpected: %s\ngot:      %s", expect, string(got))
+	}
+
+	if _, err := MigrateJSON([]byte(`{"qri":"ds:2"}`), 0); err == nil {
+		t.Errorf("expected downgrading an irreversible migration to error")
+	}
+}
+
+func TestUnmarshalUnversioned(t *testing.T) {
+	prev := migrations
+	defer func() { migrations = prev }()
+	migrations = testMigrationRegistry(t)
+
+	st := &Structure{}
+	if err := json.Unmarshal([]byte(`{"fmt":"csv","len":10}`), st); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&Structure{Qri: "st:2", Format: "csv", Length: 10}, st); diff != "" {
+		t.Errorf("structure mismatch (-want +got):\n%s", diff)
+	}
+
+	ds := &Dataset{}
+	if err := json.Unmarshal([]byte(`{"qri":"ds:1","structure":{"fmt":"json"}}`), ds); err != nil {
+		t.Fatal(err)
+	}
+	expect := &Dataset{Qri: "ds:2", Structure: &Structure{Qri: "st:2", Format: "json"}}
+	if err := CompareDatasets(expect, ds); err != nil {
+		t.Errorf("dataset mismatch: %s", err)
+	}
+}
+
+func TestUpgradeJSONCurrent(t *testing.T) {
+	// current documents are returned without being decoded or re-encoded
+	data := []byte(`{"qri":"st:0","format":"csv","schema":{"qri":"st:5"}}`)
+	got, err := upgradeJSON(data, KindStructure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &got[0] != &data[0] {
+		t.Errorf("expected current document to be returned as-is")
+	}
+}
+
+func TestDocumentKind(t *testing.T) {
+	cases := []struct {
+		data     string
+		kind     string
+		isObject bool
+	}{
+		{`"/ipfs/QmFoo"`, "", false},
+		{`[{"qri":"ds:0"}]`, "", false},
+		{`{}`, "", true},
+		{`{"qri":"st:1"}`, "st:1", true},
+		{` { "qri" : "st:1" } `, "st:1", true},
+		{`{"structure":{"qri":"st:0"},"qri":"ds:1"}`, "ds:1", true},
+		{`{"keywords":["qri"],"title":"qri","n":1}`, "", true},
+		{`{"title":"a \"qri\" title","qri":"md:0"}`, "md:0", true},
+		{`{"qri":5}`, "", true},
+	}
+	for i, c := range cases {
+		kind, isObject := documentKind([]byte(c.data))
+		if kind != c.kind || isObject != c.isObject {
+			t.Errorf("case %d mismatch. expected: (%q, %t), got: (%q, %t)", i, c.kind, c.isObject, kind, isObject)
+		}
+	}
+}
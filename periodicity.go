@@ -0,0 +1,369 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nominal lengths used when a calendar duration must be expressed as a fixed
+// span of time, for example when a duration has fractional months
+const (
+	nominalDay   = 24 * time.Hour
+	nominalWeek  = 7 * nominalDay
+	nominalMonth = 30 * nominalDay
+	nominalYear  = 365 * nominalDay
+)
+
+// Duration is an ISO 8601 duration in the form P[n]Y[n]M[n]W[n]DT[n]H[n]M[n]S.
+// Unlike time.Duration, years, months and days are kept as calendar units so
+// adding "P1M" to January 31st lands at the end of February. Any component may
+// be fractional, eg: "P0.5M"
+type Duration struct {
+	Years   float64
+	Months  float64
+	Weeks   float64
+	Days    float64
+	Hours   float64
+	Minutes float64
+	Seconds float64
+}
+
+// ParseDuration parses an ISO 8601 duration string like "P1Y2M10DT2H30M"
+func ParseDuration(s string) (Duration, error) {
+	d := Duration{}
+	if len(s) < 2 || s[0] != 'P' {
+		return Duration{}, fmt.Errorf("invalid duration '%s': must start with 'P'", s)
+	}
+
+	var (
+		num     string
+		inTime  bool
+		seen    = map[string]bool{}
+		matched bool
+		// timeMatched is set once a unit follows the 'T' designator
+		timeMatched bool
+	)
+	for _, r := range s[1:] {
+		switch {
+		case r >= '0' && r <= '9' || r == '.' || r == ',':
+			if r == ',' {
+				r = '.'
+			}
+			num += string(r)
+			continue
+		case r == 'T':
+			if inTime || num != "" {
+				return Duration{}, fmt.Errorf("invalid duration '%s'", s)
+			}
+			inTime = true
+			continue
+		}
+
+		if num == "" {
+			return Duration{}, fmt.Errorf("invalid duration '%s': missing value before '%c'", s, r)
+		}
+		val, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return Duration{}, fmt.Errorf("invalid duration '%s': %s", s, err.Error())
+		}
+		num = ""
+
+		unit := string(r)
+		if inTime {
+			unit = "T" + unit
+		}
+		if seen[unit] {
+			return Duration{}, fmt.Errorf("invalid duration '%s': repeated unit '%c'", s, r)
+		}
+		seen[unit] = true
+		matched = true
+		timeMatched = inTime
+
+		switch unit {
+		case "Y":
+			d.Years = val
+		case "M":
+			d.Months = val
+		case "W":
+			d.Weeks = val
+		case "D":
+			d.Days = val
+		case "TH":
+			d.Hours = val
+		case "TM":
+			d.Minutes = val
+		case "TS":
+			d.Seconds = val
+		default:
+			return Duration{}, fmt.Errorf("invalid duration '%s': unknown unit '%c'", s, r)
+		}
+	}
+
+	if num != "" || !matched {
+		return Duration{}, fmt.Errorf("invalid duration '%s'", s)
+	}
+	if inTime && !timeMatched {
+		return Duration{}, fmt.Errorf("invalid duration '%s': 'T' must be followed by a time unit", s)
+	}
+	return d, nil
+}
+
+// String formats a duration in ISO 8601 form
+func (d Duration) String() string {
+	f := func(v float64, unit string) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64) + unit
+	}
+	date := f(d.Years, "Y") + f(d.Months, "M") + f(d.Weeks, "W") + f(d.Days, "D")
+	tm := f(d.Hours, "H") + f(d.Minutes, "M") + f(d.Seconds, "S")
+	if tm != "" {
+		tm = "T" + tm
+	}
+	if date == "" && tm == "" {
+		return "PT0S"
+	}
+	return "P" + date + tm
+}
+
+// IsZero reports whether the duration has no length
+func (d Duration) IsZero() bool {
+	return d == Duration{}
+}
+
+// Approximate converts a duration to a fixed time.Duration, using 365 day
+// years, 30 day months & 24 hour days. Durations longer than time.Duration
+// can represent, roughly 292 years, are clamped to the largest time.Duration
+func (d Duration) Approximate() time.Duration {
+	ns := d.Years*float64(nominalYear) +
+		d.Months*float64(nominalMonth) +
+		d.Weeks*float64(nominalWeek) +
+		d.Days*float64(nominalDay) +
+		d.Hours*float64(time.Hour) +
+		d.Minutes*float64(time.Minute) +
+		d.Seconds*float64(time.Second)
+	if ns >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(ns)
+}
+
+// Scale multiplies each component of the duration by n
+func (d Duration) Scale(n float64) Duration {
+	return Duration{
+		Years:   d.Years * n,
+		Months:  d.Months * n,
+		Weeks:   d.Weeks * n,
+		Days:    d.Days * n,
+		Hours:   d.Hours * n,
+		Minutes: d.Minutes * n,
+		Seconds: d.Seconds * n,
+	}
+}
+
+// AddTo adds the duration to a time. Whole years, months, weeks & days are
+// added on the calendar, fractional parts as nominal spans of time
+func (d Duration) AddTo(t time.Time) time.Time {
+	years, fy := math.Modf(d.Years)
+	months, fm := math.Modf(d.Months)
+	weeks, fw := math.Modf(d.Weeks)
+	days, fd := math.Modf(d.Days)
+
+	t = t.AddDate(int(years), int(months), int(weeks)*7+int(days))
+	rest := Duration{
+		Years:   fy,
+		Months:  fm,
+		Weeks:   fw,
+		Days:    fd,
+		Hours:   d.Hours,
+		Minutes: d.Minutes,
+		Seconds: d.Seconds,
+	}
+	return t.Add(rest.Approximate())
+}
+
+// RepeatingInterval is an ISO 8601 repeating interval, in one of the forms:
+//
+//	R[n]/duration
+//	R[n]/start/duration
+//	R[n]/duration/end
+//	R[n]/start/end
+//
+// Omitting n repeats without bound
+type RepeatingInterval struct {
+	// Repetitions is the number of times the interval repeats, -1 for
+	// unbounded repetition
+	Repetitions int
+	// Start is the start of the first interval, if given
+	Start time.Time
+	// End is the end of the last interval, if given
+	End time.Time
+	// Duration is the length of each interval
+	Duration Duration
+}
+
+// isoTimeLayouts are accepted formats for interval start & end times
+var isoTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func parseISOTime(s string) (time.Time, error) {
+	for _, layout := range isoTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s'", s)
+}
+
+// ParseRepeatingInterval parses an ISO 8601 repeating interval string like
+// "R/P1W" or "R12/2019-01-01/P1M"
+func ParseRepeatingInterval(s string) (*RepeatingInterval, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || len(parts[0]) == 0 || parts[0][0] != 'R' {
+		return nil, fmt.Errorf("invalid repeating interval '%s': must be in the form R[n]/[start/]duration", s)
+	}
+
+	ri := &RepeatingInterval{Repetitions: -1}
+	if n := parts[0][1:]; n != "" {
+		reps, err := strconv.Atoi(n)
+		if err != nil || reps < 0 {
+			return nil, fmt.Errorf("invalid repeating interval '%s': invalid repetition count '%s'", s, n)
+		}
+		ri.Repetitions = reps
+	}
+
+	var err error
+	switch {
+	case len(parts) == 2:
+		ri.Duration, err = ParseDuration(parts[1])
+	case strings.HasPrefix(parts[1], "P"):
+		if ri.Duration, err = ParseDuration(parts[1]); err == nil {
+			ri.End, err = parseISOTime(parts[2])
+		}
+	default:
+		if ri.Start, err = parseISOTime(parts[1]); err != nil {
+			break
+		}
+		if strings.HasPrefix(parts[2], "P") {
+			ri.Duration, err = ParseDuration(parts[2])
+		} else if ri.End, err = parseISOTime(parts[2]); err == nil {
+			if !ri.End.After(ri.Start) {
+				err = fmt.Errorf("end must be after start")
+			}
+			ri.Duration = Duration{Seconds: ri.End.Sub(ri.Start).Seconds()}
+			ri.End = time.Time{}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid repeating interval '%s': %s", s, err.Error())
+	}
+	if ri.Duration.Approximate() <= 0 {
+		return nil, fmt.Errorf("invalid repeating interval '%s': duration must be positive", s)
+	}
+	return ri, nil
+}
+
+// String formats a repeating interval in ISO 8601 form
+func (ri *RepeatingInterval) String() string {
+	s := "R"
+	if ri.Repetitions >= 0 {
+		s += strconv.Itoa(ri.Repetitions)
+	}
+	if !ri.Start.IsZero() {
+		s += "/" + ri.Start.Format(time.RFC3339)
+	}
+	s += "/" + ri.Duration.String()
+	if !ri.End.IsZero() {
+		s += "/" + ri.End.Format(time.RFC3339)
+	}
+	return s
+}
+
+// occurrence gives the k-th interval boundary counted from an anchor time
+func (ri *RepeatingInterval) occurrence(anchor time.Time, k int) time.Time {
+	return ri.Duration.Scale(float64(k)).AddTo(anchor)
+}
+
+// Next gives the first interval boundary strictly after t. Intervals without
+// a start or end are anchored at t itself, making the next boundary t plus
+// one duration. Next returns false when no boundaries remain after t
+func (ri *RepeatingInterval) Next(t time.Time) (time.Time, bool) {
+	// boundaries are anchor + k*duration for k in [lo, hi]
+	anchor, lo, hi := t, 1, 1
+	switch {
+	case !ri.Start.IsZero():
+		anchor, lo, hi = ri.Start, 0, ri.Repetitions
+	case !ri.End.IsZero():
+		anchor, lo, hi = ri.End, -ri.Repetitions, 0
+		if ri.Repetitions < 0 {
+			lo = math.MinInt32
+		}
+	}
+
+	// estimate the first boundary after t. approximate month and year lengths
+	// drift over long spans, so step back until the boundary isn't after t
+	// before scanning forward
+	step := ri.Duration.Approximate()
+	k := int(t.Sub(anchor) / step)
+	if k < lo {
+		k = lo
+	}
+	for k > lo && ri.occurrence(anchor, k).After(t) {
+		k--
+	}
+	for ; hi < 0 || k <= hi; k++ {
+		if occ := ri.occurrence(anchor, k); occ.After(t) {
+			return occ, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// AccrualInterval parses the AccrualPeriodicity of meta as a repeating
+// interval. A bare duration like "P1W" is accepted as "R/P1W"
+func (md *Meta) AccrualInterval() (*RepeatingInterval, error) {
+	if md.AccrualPeriodicity == "" {
+		return nil, fmt.Errorf("accrualPeriodicity is not set")
+	}
+	return parseAccrualPeriodicity(md.AccrualPeriodicity)
+}
+
+func parseAccrualPeriodicity(p string) (*RepeatingInterval, error) {
+	if strings.HasPrefix(p, "P") {
+		p = "R/" + p
+	}
+	return ParseRepeatingInterval(p)
+}
+
+// NextUpdate gives the time a dataset last updated at lastUpdate is next
+// expected to change, according to AccrualPeriodicity. If the periodicity
+// specifies start or end dates the next scheduled time after lastUpdate is
+// returned. NextUpdate returns the zero time when no updates are scheduled
+func (md *Meta) NextUpdate(lastUpdate time.Time) (time.Time, error) {
+	ri, err := md.AccrualInterval()
+	if err != nil {
+		return time.Time{}, err
+	}
+	next, _ := ri.Next(lastUpdate)
+	return next, nil
+}
+
+// IsStale reports whether a dataset last updated at lastUpdate has missed an
+// expected update by time t
+func (md *Meta) IsStale(lastUpdate, t time.Time) (bool, error) {
+	next, err := md.NextUpdate(lastUpdate)
+	if err != nil {
+		return false, err
+	}
+	return !next.IsZero() && t.After(next), nil
+}
@@ -0,0 +1,210 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect Duration
+		str    string
+		err    string
+	}{
+		{"P1Y", Duration{Years: 1}, "P1Y", ""},
+		{"P3M", Duration{Months: 3}, "P3M", ""},
+		{"P0.33W", Duration{Weeks: 0.33}, "P0.33W", ""},
+		{"P3,5D", Duration{Days: 3.5}, "P3.5D", ""},
+		{"PT1M", Duration{Minutes: 1}, "PT1M", ""},
+		{"P1Y2M10DT2H30M15S", Duration{Years: 1, Months: 2, Days: 10, Hours: 2, Minutes: 30, Seconds: 15}, "P1Y2M10DT2H30M15S", ""},
+
+		{"", Duration{}, "", "invalid duration '': must start with 'P'"},
+		{"P", Duration{}, "", "invalid duration 'P': must start with 'P'"},
+		{"PT", Duration{}, "", "invalid duration 'PT'"},
+		{"P1", Duration{}, "", "invalid duration 'P1'"},
+		{"PD", Duration{}, "", "invalid duration 'PD': missing value before 'D'"},
+		{"P1D1D", Duration{}, "", "invalid duration 'P1D1D': repeated unit 'D'"},
+		{"P1X", Duration{}, "", "invalid duration 'P1X': unknown unit 'X'"},
+		{"PT1Y", Duration{}, "", "invalid duration 'PT1Y': unknown unit 'Y'"},
+		{"P1.2.3D", Duration{}, "", "invalid duration 'P1.2.3D': strconv.ParseFloat: parsing \"1.2.3\": invalid syntax"},
+		{"P1DT", Duration{}, "", "invalid duration 'P1DT': 'T' must be followed by a time unit"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseDuration(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+		if c.err == "" && got.String() != c.str {
+			t.Errorf("case %d string mismatch. expected: '%s', got: '%s'", i, c.str, got.String())
+		}
+	}
+}
+
+func TestDurationAddTo(t *testing.T) {
+	start := time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		d      Duration
+		expect time.Time
+	}{
+		{Duration{Months: 1}, time.Date(2019, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{Duration{Years: 1, Days: 1}, time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Duration{Days: 3.5}, time.Date(2019, 2, 3, 12, 0, 0, 0, time.UTC)},
+		{Duration{Weeks: 1, Hours: 6}, time.Date(2019, 2, 7, 6, 0, 0, 0, time.UTC)},
+		{Duration{Months: -2}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for i, c := range cases {
+		if got := c.d.AddTo(start); !got.Equal(c.expect) {
+			t.Errorf("case %d expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestParseRepeatingInterval(t *testing.T) {
+	jan1 := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		in     string
+		expect *RepeatingInterval
+		err    string
+	}{
+		{"R/P1W", &RepeatingInterval{Repetitions: -1, Duration: Duration{Weeks: 1}}, ""},
+		{"R5/P1D", &RepeatingInterval{Repetitions: 5, Duration: Duration{Days: 1}}, ""},
+		{"R12/2019-01-01/P1M", &RepeatingInterval{Repetitions: 12, Start: jan1, Duration: Duration{Months: 1}}, ""},
+		{"R/2019-01-01T00:00:00Z/PT1H", &RepeatingInterval{Repetitions: -1, Start: jan1, Duration: Duration{Hours: 1}}, ""},
+		{"R2/P1Y/2019-01-01", &RepeatingInterval{Repetitions: 2, End: jan1, Duration: Duration{Years: 1}}, ""},
+		{"R/2019-01-01/2019-01-02", &RepeatingInterval{Repetitions: -1, Start: jan1, Duration: Duration{Seconds: 86400}}, ""},
+		// durations longer than time.Duration can hold are still positive
+		{"R/P300Y", &RepeatingInterval{Repetitions: -1, Duration: Duration{Years: 300}}, ""},
+
+		{"", nil, "invalid repeating interval '': must be in the form R[n]/[start/]duration"},
+		{"P1D", nil, "invalid repeating interval 'P1D': must be in the form R[n]/[start/]duration"},
+		{"R/a/b/c", nil, "invalid repeating interval 'R/a/b/c': must be in the form R[n]/[start/]duration"},
+		{"Rx/P1D", nil, "invalid repeating interval 'Rx/P1D': invalid repetition count 'x'"},
+		{"R/P1Q", nil, "invalid repeating interval 'R/P1Q': invalid duration 'P1Q': unknown unit 'Q'"},
+		{"R/P0D", nil, "invalid repeating interval 'R/P0D': duration must be positive"},
+		{"R/yesterday/P1D", nil, "invalid repeating interval 'R/yesterday/P1D': invalid time 'yesterday'"},
+		{"R/2019-01-02/2019-01-01", nil, "invalid repeating interval 'R/2019-01-02/2019-01-01': end must be after start"},
+	}
+
+	for i, c := range cases {
+		got, err := ParseRepeatingInterval(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d result mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestRepeatingIntervalNext(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	cases := []struct {
+		interval string
+		after    time.Time
+		expect   time.Time
+		ok       bool
+	}{
+		{"R/P1W", at("2019-01-01T00:00:00Z"), at("2019-01-08T00:00:00Z"), true},
+		{"R/2019-01-31/P1M", at("2019-01-01T00:00:00Z"), at("2019-01-31T00:00:00Z"), true},
+		{"R/2019-01-31/P1M", at("2019-01-31T00:00:00Z"), at("2019-03-03T00:00:00Z"), true},
+		{"R/2019-01-01/P1M", at("2021-06-15T00:00:00Z"), at("2021-07-01T00:00:00Z"), true},
+		{"R/2019-01-01/PT1H", at("2019-03-01T10:30:00Z"), at("2019-03-01T11:00:00Z"), true},
+		{"R3/2019-01-01/P1D", at("2019-01-02T12:00:00Z"), at("2019-01-03T00:00:00Z"), true},
+		{"R3/2019-01-01/P1D", at("2019-01-04T00:00:00Z"), time.Time{}, false},
+		{"R2/P1D/2019-01-10", at("2019-01-01T00:00:00Z"), at("2019-01-08T00:00:00Z"), true},
+		{"R2/P1D/2019-01-10", at("2019-01-08T06:00:00Z"), at("2019-01-09T00:00:00Z"), true},
+		{"R2/P1D/2019-01-10", at("2019-01-10T00:00:00Z"), time.Time{}, false},
+		{"R/1900-01-01/P300Y", at("2019-01-01T00:00:00Z"), at("2200-01-01T00:00:00Z"), true},
+		// anchors decades from t accumulate calendar drift
+		{"R/1900-01-01/P1M", at("2020-01-15T00:00:00Z"), at("2020-02-01T00:00:00Z"), true},
+		{"R/1900-01-01/P1Y", at("2020-06-01T00:00:00Z"), at("2021-01-01T00:00:00Z"), true},
+		{"R/P1M/2100-01-01", at("1950-03-15T00:00:00Z"), at("1950-04-01T00:00:00Z"), true},
+	}
+
+	for i, c := range cases {
+		ri, err := ParseRepeatingInterval(c.interval)
+		if err != nil {
+			t.Fatalf("case %d unexpected error: %s", i, err)
+		}
+		got, ok := ri.Next(c.after)
+		if ok != c.ok {
+			t.Errorf("case %d ok mismatch. expected: %t, got: %t", i, c.ok, ok)
+		}
+		if !got.Equal(c.expect) {
+			t.Errorf("case %d expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestMetaNextUpdate(t *testing.T) {
+	last := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		periodicity string
+		expect      time.Time
+		err         string
+	}{
+		{"R/P1M", time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC), ""},
+		{"P1W", time.Date(2019, 1, 8, 0, 0, 0, 0, time.UTC), ""},
+		{"R/2018-12-25/P1W", time.Date(2019, 1, 8, 0, 0, 0, 0, time.UTC), ""},
+		{"R1/2018-01-01/P1D", time.Time{}, ""},
+		{"", time.Time{}, "accrualPeriodicity is not set"},
+		{"weekly", time.Time{}, "invalid repeating interval 'weekly': must be in the form R[n]/[start/]duration"},
+	}
+
+	for i, c := range cases {
+		md := &Meta{AccrualPeriodicity: c.periodicity}
+		got, err := md.NextUpdate(last)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if !got.Equal(c.expect) {
+			t.Errorf("case %d expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestMetaIsStale(t *testing.T) {
+	last := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		periodicity string
+		at          time.Time
+		expect      bool
+		err         string
+	}{
+		{"R/P1D", time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC), false, ""},
+		{"R/P1D", time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), false, ""},
+		{"R/P1D", time.Date(2019, 1, 2, 0, 0, 1, 0, time.UTC), true, ""},
+		{"R1/2018-01-01/P1D", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), false, ""},
+		{"", time.Time{}, false, "accrualPeriodicity is not set"},
+	}
+
+	for i, c := range cases {
+		md := &Meta{AccrualPeriodicity: c.periodicity}
+		got, err := md.IsStale(last, c.at)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d expected: %t, got: %t", i, c.expect, got)
+		}
+	}
+}
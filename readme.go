@@ -195,6 +195,11 @@ func (r *Readme) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	data, err := upgradeJSON(data, KindReadme)
+	if err != nil {
+		return err
+	}
+
 	_r := _readme{}
 	if err := json.Unmarshal(data, &_r); err != nil {
 		return err
@@ -208,13 +213,16 @@ func (r *Readme) UnmarshalJSON(data []byte) error {
 
 // UnmarshalReadme tries to extract a resource type from an empty
 // interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
-func UnmarshalReadme(v interface{}) (*Readme, error) {
+func UnmarshalReadme(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Readme, error) {
 	switch q := v.(type) {
 	case *Readme:
 		return q, nil
 	case Readme:
 		return &q, nil
 	case []byte:
+		if err := checkStrict(KindReadme, q, configs); err != nil {
+			return nil, err
+		}
 		r := Readme{}
 		err := json.Unmarshal(q, &r)
 		return &r, err
@@ -0,0 +1,194 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/mr-tron/base58/base58"
+)
+
+var (
+	// ErrNoSignature is the error for verifying a commit that hasn't been signed
+	ErrNoSignature = fmt.Errorf("commit has no signature")
+	// ErrInvalidSignature is the error for a signature that doesn't match the
+	// commit & public key it's checked against
+	ErrInvalidSignature = fmt.Errorf("invalid commit signature")
+)
+
+// CommitSignatureVersion identifies the payload commit signatures are made
+// over. The payload is a canonical JSON object (see CanonicalVersion) with the
+// fields:
+//
+//	version:      CommitSignatureVersion
+//	bodyPath:     the body path of the dataset, if any
+//	previousPath: the previous path of the dataset, if any
+//	commit:       the canonical commit, without its signature. covers author,
+//	              message, timestamp & title
+//	components:   the canonical hash of each other non-empty component
+//	              (meta, readme, stats, structure, transform, viz), or the
+//	              component path for components that only hold a path
+//
+// Any change to the payload produces a new version
+const CommitSignatureVersion = "sig1:" + CanonicalVersion
+
+// SignCommit signs a dataset with a private key, writing the base58-encoded
+// signature to ds.Commit.Signature. The signature is made over the payload
+// described by CommitSignatureVersion, binding the commit author, every
+// component of the dataset & its place in history
+func SignCommit(ds *Dataset, pk crypto.PrivKey) error {
+	if pk == nil {
+		return fmt.Errorf("private key is required")
+	}
+	data, err := commitSignableBytes(ds)
+	if err != nil {
+		return err
+	}
+	sig, err := pk.Sign(data)
+	if err != nil {
+		return fmt.Errorf("signing commit: %s", err.Error())
+	}
+	ds.Commit.Signature = base58.Encode(sig)
+	return nil
+}
+
+// VerifyCommit checks the commit signature of a dataset was created by the
+// private key matching pub, and that no field covered by the signature payload
+// has changed since signing. See CommitSignatureVersion for the covered fields
+func VerifyCommit(ds *Dataset, pub crypto.PubKey) error {
+	if pub == nil {
+		return fmt.Errorf("public key is required")
+	}
+	data, err := commitSignableBytes(ds)
+	if err != nil {
+		return err
+	}
+	if ds.Commit.Signature == "" {
+		return ErrNoSignature
+	}
+	sig, err := base58.Decode(ds.Commit.Signature)
+	if err != nil {
+		return fmt.Errorf("decoding signature: %s", err.Error())
+	}
+	ok, err := pub.Verify(data, sig)
+	if err != nil {
+		return fmt.Errorf("verifying signature: %s", err.Error())
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// commitSignableBytes is the payload commit signatures are made over
+func commitSignableBytes(ds *Dataset) ([]byte, error) {
+	if ds.Commit == nil {
+		return nil, fmt.Errorf("commit is required")
+	}
+	if ds.Structure == nil {
+		return nil, fmt.Errorf("structure is required")
+	}
+
+	hashes, err := CanonicalHashes(ds)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]string{"structure": ds.Structure.Path}
+	if ds.Meta != nil {
+		paths["meta"] = ds.Meta.Path
+	}
+	if ds.Readme != nil {
+		paths["readme"] = ds.Readme.Path
+	}
+	if ds.Stats != nil {
+		paths["stats"] = ds.Stats.Path
+	}
+	if ds.Transform != nil {
+		paths["transform"] = ds.Transform.Path
+	}
+	if ds.Viz != nil {
+		paths["viz"] = ds.Viz.Path
+	}
+
+	components := map[string]interface{}{}
+	for _, key := range []string{"meta", "readme", "stats", "structure", "transform", "viz"} {
+		if hash, ok := hashes[key]; ok {
+			components[key] = hash
+		} else if paths[key] != "" {
+			components[key] = paths[key]
+		}
+	}
+
+	cm := canonicalCommit(ds.Commit)
+	delete(cm, "signature")
+
+	obj := canonicalObject{
+		"version":    CommitSignatureVersion,
+		"commit":     map[string]interface{}(cm),
+		"components": components,
+	}
+	obj.setString("bodyPath", ds.BodyPath)
+	obj.setString("previousPath", ds.PreviousPath)
+	return canonicalEncode(map[string]interface{}(obj))
+}
+
+// DatasetResolver fetches datasets by path
+type DatasetResolver interface {
+	ResolveDataset(ctx context.Context, path string) (*Dataset, error)
+}
+
+// PubKeyFunc gives the public key expected to have signed a dataset, usually
+// by looking up the commit author
+type PubKeyFunc func(ds *Dataset) (crypto.PubKey, error)
+
+// VerifyCommitChain verifies the commit signature of ds and every version in
+// its history, following PreviousPath with resolver until a dataset with no
+// previous path is reached. The public key for each version comes from
+// pubKey, allowing versions to be signed by different authors. Signatures
+// cover the commit author & PreviousPath, so a verified chain shows each
+// version was signed by the key pubKey gives for it, and versions can't be
+// spliced onto another history
+func VerifyCommitChain(ctx context.Context, ds *Dataset, resolver DatasetResolver, pubKey PubKeyFunc) error {
+	if resolver == nil {
+		return ErrNoResolver
+	}
+	if pubKey == nil {
+		return fmt.Errorf("public key func is required")
+	}
+
+	seen := map[string]bool{}
+	if ds == nil {
+		return fmt.Errorf("dataset is required")
+	}
+
+	for {
+		if ds.Path != "" {
+			if seen[ds.Path] {
+				return fmt.Errorf("history of dataset contains a cycle at path: %s", ds.Path)
+			}
+			seen[ds.Path] = true
+		}
+
+		pub, err := pubKey(ds)
+		if err != nil {
+			return fmt.Errorf("getting public key for version %s: %s", ds.Path, err.Error())
+		}
+		if err := VerifyCommit(ds, pub); err != nil {
+			return fmt.Errorf("verifying version %s: %s", ds.Path, err.Error())
+		}
+
+		if ds.PreviousPath == "" {
+			return nil
+		}
+		prev := ds.PreviousPath
+		if ds, err = resolver.ResolveDataset(ctx, prev); err != nil {
+			return fmt.Errorf("resolving version %s: %s", prev, err.Error())
+		}
+		if ds == nil {
+			return fmt.Errorf("resolving version %s: dataset not found", prev)
+		}
+		if ds.Path == "" {
+			ds.Path = prev
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func testKeyPair(seed int64) (crypto.PrivKey, crypto.PubKey) {
+	pk, pub, err := crypto.GenerateKeyPairWithReader(crypto.Ed25519, 0, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		panic(err)
+	}
+	return pk, pub
+}
+
+func signedVersion(pk crypto.PrivKey, path, prev, checksum string) *Dataset {
+	return authoredVersion(pk, "", path, prev, checksum)
+}
+
+func authoredVersion(pk crypto.PrivKey, author, path, prev, checksum string) *Dataset {
+	ds := &Dataset{
+		Path:         path,
+		PreviousPath: prev,
+		Commit:       &Commit{Timestamp: time.Date(2001, 01, 01, 01, 01, 01, 0, time.UTC), Title: path},
+		Structure:    &Structure{Checksum: checksum},
+	}
+	if author != "" {
+		ds.Commit.Author = &User{ID: author}
+	}
+	if err := SignCommit(ds, pk); err != nil {
+		panic(err)
+	}
+	return ds
+}
+
+func TestSignCommit(t *testing.T) {
+	pk, pub := testKeyPair(1)
+	_, otherPub := testKeyPair(2)
+
+	ds := signedVersion(pk, "", "", "checksum")
+	if ds.Commit.Signature == "" {
+		t.Fatal("expected signature to be set")
+	}
+	if err := VerifyCommit(ds, pub); err != nil {
+		t.Errorf("verifying signed commit: %s", err)
+	}
+	if err := VerifyCommit(ds, otherPub); err != ErrInvalidSignature {
+		t.Errorf("expected verifying with another key to fail with ErrInvalidSignature. got: %s", err)
+	}
+
+	ds.Structure.Checksum = "tampered"
+	if err := VerifyCommit(ds, pub); err != ErrInvalidSignature {
+		t.Errorf("expected verifying a modified dataset to fail with ErrInvalidSignature. got: %s", err)
+	}
+
+	tamper := map[string]func(ds *Dataset){
+		"author":  func(ds *Dataset) { ds.Commit.Author = &User{ID: "mallory"} },
+		"title":   func(ds *Dataset) { ds.Commit.Title = "tampered" },
+		"message": func(ds *Dataset) { ds.Commit.Message = "tampered" },
+		"meta":    func(ds *Dataset) { ds.Meta.Title = "tampered" },
+		"viz":     func(ds *Dataset) { ds.Viz = &Viz{Format: "html"} },
+		"body":    func(ds *Dataset) { ds.BodyPath = "/other/body.json" },
+	}
+	for name, fn := range tamper {
+		ds = signedVersion(pk, "", "", "checksum")
+		ds.Meta = &Meta{Title: "title"}
+		ds.Commit.Author = &User{ID: "author"}
+		if err := SignCommit(ds, pk); err != nil {
+			t.Fatal(err)
+		}
+		fn(ds)
+		if err := VerifyCommit(ds, pub); err != ErrInvalidSignature {
+			t.Errorf("expected verifying a dataset with a modified %s to fail with ErrInvalidSignature. got: %s", name, err)
+		}
+	}
+
+	ds = signedVersion(pk, "", "/prev", "checksum")
+	ds.Path = "/ipfs/QmPath"
+	ds.Commit.Path = "/ipfs/QmCommit"
+	if err := VerifyCommit(ds, pub); err != nil {
+		t.Errorf("expected derived paths to be outside the signature. got: %s", err)
+	}
+	ds.PreviousPath = "/other"
+	if err := VerifyCommit(ds, pub); err != ErrInvalidSignature {
+		t.Errorf("expected verifying a dataset with a modified previous path to fail with ErrInvalidSignature. got: %s", err)
+	}
+
+	cases := []struct {
+		ds  *Dataset
+		err string
+	}{
+		{&Dataset{}, "commit is required"},
+		{&Dataset{Commit: &Commit{}}, "structure is required"},
+	}
+	for i, c := range cases {
+		err := SignCommit(c.ds, pk)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+	if err := SignCommit(&Dataset{}, nil); err == nil || err.Error() != "private key is required" {
+		t.Errorf("expected missing private key error. got: %s", err)
+	}
+}
+
+func TestVerifyCommit(t *testing.T) {
+	_, pub := testKeyPair(1)
+	cases := []struct {
+		ds  *Dataset
+		pub crypto.PubKey
+		err string
+	}{
+		{&Dataset{}, nil, "public key is required"},
+		{&Dataset{}, pub, "commit is required"},
+		{&Dataset{Commit: &Commit{}, Structure: &Structure{}}, pub, "commit has no signature"},
+		{&Dataset{Commit: &Commit{Signature: "0OIl"}, Structure: &Structure{}}, pub, "decoding signature: Invalid base58 digit ('0')"},
+		{&Dataset{Commit: &Commit{Signature: "abc"}, Structure: &Structure{}}, pub, "invalid commit signature"},
+	}
+	for i, c := range cases {
+		err := VerifyCommit(c.ds, c.pub)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
+
+type mapResolver map[string]*Dataset
+
+func (r mapResolver) ResolveDataset(ctx context.Context, path string) (*Dataset, error) {
+	if ds, ok := r[path]; ok {
+		return ds, nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+func TestVerifyCommitChain(t *testing.T) {
+	ctx := context.Background()
+	alicePk, alicePub := testKeyPair(1)
+	bobPk, bobPub := testKeyPair(2)
+
+	keys := map[string]crypto.PubKey{"alice": alicePub, "bob": bobPub}
+	byAuthor := func(ds *Dataset) (crypto.PubKey, error) {
+		if ds.Commit == nil || ds.Commit.Author == nil {
+			return nil, fmt.Errorf("no author")
+		}
+		pub, ok := keys[ds.Commit.Author.ID]
+		if !ok {
+			return nil, fmt.Errorf("unknown author: %s", ds.Commit.Author.ID)
+		}
+		return pub, nil
+	}
+	v1 := authoredVersion(alicePk, "alice", "/v1", "", "a")
+	v2 := authoredVersion(bobPk, "bob", "/v2", "/v1", "b")
+	v3 := authoredVersion(alicePk, "alice", "/v3", "/v2", "c")
+	forged := authoredVersion(bobPk, "alice", "/forged", "/v2", "d")
+	dangling := authoredVersion(alicePk, "alice", "/dangling", "/missing", "e")
+	loopA := authoredVersion(alicePk, "alice", "/loopA", "/loopB", "f")
+	loopB := authoredVersion(alicePk, "alice", "/loopB", "/loopA", "g")
+
+	// spliced is signed onto v2, then moved onto a different history
+	spliced := authoredVersion(alicePk, "alice", "/spliced", "/v2", "c")
+	spliced.PreviousPath = "/v1"
+
+	resolver := mapResolver{"/v1": v1, "/v2": v2, "/v3": v3, "/loopA": loopA, "/loopB": loopB}
+
+	cases := []struct {
+		ds       *Dataset
+		resolver DatasetResolver
+		err      string
+	}{
+		{v3, resolver, ""},
+		{v1, resolver, ""},
+		{v3, nil, "no resolver available to fetch path"},
+		{nil, resolver, "dataset is required"},
+		{forged, resolver, "verifying version /forged: invalid commit signature"},
+		{spliced, resolver, "verifying version /spliced: invalid commit signature"},
+		{dangling, resolver, "resolving version /missing: not found"},
+		{loopA, resolver, "history of dataset contains a cycle at path: /loopA"},
+		{authoredVersion(alicePk, "eve", "/eve", "", "h"), resolver, "getting public key for version /eve: unknown author: eve"},
+	}
+
+	for i, c := range cases {
+		err := VerifyCommitChain(ctx, c.ds, c.resolver, byAuthor)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+
+	if err := VerifyCommitChain(ctx, v3, resolver, nil); err == nil || err.Error() != "public key func is required" {
+		t.Errorf("expected missing public key func error. got: %s", err)
+	}
+}
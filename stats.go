@@ -0,0 +1,136 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Stats is a component that holds statistical profiles of a dataset body,
+// like those produced by the stats package. Stats values are plain old data
+// so they can be stored & compared without depending on the format of any
+// particular profiler
+type Stats struct {
+	// Path is the location of stats, transient
+	// derived
+	Path string `json:"path,omitempty"`
+	// Qri should always be "sa:0"
+	// derived
+	Qri string `json:"qri,omitempty"`
+	// Stats is the statistical profile of a dataset body
+	Stats interface{} `json:"stats,omitempty"`
+}
+
+// NewStatsRef creates an empty struct with it's path set
+func NewStatsRef(path string) *Stats {
+	return &Stats{Path: path}
+}
+
+// DropTransientValues removes values that cannot be recorded when the
+// dataset is rendered immutable, usually by storing it in a cafs
+func (sa *Stats) DropTransientValues() {
+	sa.Path = ""
+}
+
+// DropDerivedValues resets all set-on-save fields to their default values
+func (sa *Stats) DropDerivedValues() {
+	sa.Qri = ""
+	sa.Path = ""
+}
+
+// IsEmpty checks to see if stats has any fields other than the internal path
+func (sa *Stats) IsEmpty() bool {
+	return sa.Stats == nil
+}
+
+// Assign collapses all properties of a group of Stats components onto one
+func (sa *Stats) Assign(sas ...*Stats) {
+	for _, s := range sas {
+		if s == nil {
+			continue
+		}
+
+		if s.Path != "" {
+			sa.Path = s.Path
+		}
+		if s.Qri != "" {
+			sa.Qri = s.Qri
+		}
+		if s.Stats != nil {
+			sa.Stats = s.Stats
+		}
+	}
+}
+
+// _stats is a private struct for marshaling into & out of.
+type _stats Stats
+
+// MarshalJSON satisfies the json.Marshaler interface
+func (sa *Stats) MarshalJSON() ([]byte, error) {
+	// if we're dealing with an empty object that has a path specified, marshal
+	// to a string instead
+	if sa.Path != "" && sa.IsEmpty() {
+		return json.Marshal(sa.Path)
+	}
+	if sa.Qri == "" {
+		sa.Qri = KindStats.String()
+	}
+	return sa.MarshalJSONObject()
+}
+
+// MarshalJSONObject always marshals to a json Object, even if Stats is empty
+// or a reference
+func (sa *Stats) MarshalJSONObject() ([]byte, error) {
+	data := map[string]interface{}{
+		"qri": sa.Qri,
+	}
+	if sa.Stats != nil {
+		data["stats"] = sa.Stats
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface
+func (sa *Stats) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*sa = Stats{Path: s}
+		return nil
+	}
+
+	data, err := upgradeJSON(data, KindStats)
+	if err != nil {
+		return err
+	}
+
+	_sa := _stats{}
+	if err := json.Unmarshal(data, &_sa); err != nil {
+		return fmt.Errorf("unmarshaling stats: %s", err.Error())
+	}
+	if _sa.Qri == "" {
+		_sa.Qri = KindStats.String()
+	}
+
+	*sa = Stats(_sa)
+	return nil
+}
+
+// UnmarshalStats tries to extract a stats type from an empty
+// interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
+func UnmarshalStats(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Stats, error) {
+	switch q := v.(type) {
+	case *Stats:
+		return q, nil
+	case Stats:
+		return &q, nil
+	case []byte:
+		if err := checkStrict(KindStats, q, configs); err != nil {
+			return nil, err
+		}
+		sa := &Stats{}
+		err := json.Unmarshal(q, sa)
+		return sa, err
+	default:
+		err := fmt.Errorf("couldn't parse stats, value is invalid type")
+		return nil, err
+	}
+}
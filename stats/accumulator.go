@@ -0,0 +1,307 @@
+package stats
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/vals"
+)
+
+// Accumulator builds a profile one entry at a time. Accumulator implements
+// dsio.EntryWriter, allowing profiles to be computed while writing a body
+type Accumulator struct {
+	st      *dataset.Structure
+	cfg     *Config
+	count   int64
+	columns []*columnAcc
+	// declared is true when columns come from the schema. without declared
+	// columns they're discovered from array positions or object keys
+	declared bool
+	byTitle  map[string]*columnAcc
+}
+
+var _ dsio.EntryWriter = (*Accumulator)(nil)
+
+// NewAccumulator creates an accumulator for entries of a given structure
+func NewAccumulator(st *dataset.Structure, configs ...func(cfg *Config)) *Accumulator {
+	cfg := DefaultConfig()
+	for _, config := range configs {
+		config(cfg)
+	}
+
+	acc := &Accumulator{
+		st:      st,
+		cfg:     cfg,
+		byTitle: map[string]*columnAcc{},
+	}
+	if cols, err := dsio.Columns(st); err == nil {
+		acc.declared = true
+		for _, col := range cols {
+			acc.columns = append(acc.columns, newColumnAcc(col, cfg))
+		}
+	}
+	return acc
+}
+
+// Structure gives the structure of profiled entries
+func (a *Accumulator) Structure() *dataset.Structure {
+	return a.st
+}
+
+// WriteEntry adds an entry to the profile
+func (a *Accumulator) WriteEntry(ent dsio.Entry) error {
+	a.count++
+	if a.declared {
+		for _, col := range a.columns {
+			col.add(col.col.Value(ent.Value))
+		}
+		return nil
+	}
+
+	switch x := ent.Value.(type) {
+	case []interface{}:
+		for i, v := range x {
+			a.discovered(strconv.Itoa(i), i).add(v)
+		}
+	case map[string]interface{}:
+		for key, v := range x {
+			a.discovered(key, -1).add(v)
+		}
+	default:
+		a.discovered("value", -1).add(x)
+	}
+	return nil
+}
+
+// discovered gets or creates a column found while reading entries. columns
+// discovered after the first entry are backfilled with nulls
+func (a *Accumulator) discovered(title string, index int) *columnAcc {
+	if col, ok := a.byTitle[title]; ok {
+		return col
+	}
+	col := newColumnAcc(dsio.Column{Title: title, Index: index}, a.cfg)
+	col.count = a.count - 1
+	col.nulls = a.count - 1
+	a.byTitle[title] = col
+	a.columns = append(a.columns, col)
+	return col
+}
+
+// Close finalizes the accumulator
+func (a *Accumulator) Close() error {
+	if !a.declared {
+		// values missing from later entries count as null
+		for _, col := range a.columns {
+			for col.count < a.count {
+				col.add(nil)
+			}
+		}
+	}
+	return nil
+}
+
+// Profile gives the profile of all entries written so far
+func (a *Accumulator) Profile() *Profile {
+	p := &Profile{
+		Count:   a.count,
+		Columns: make([]*ColumnProfile, len(a.columns)),
+	}
+	for i, col := range a.columns {
+		p.Columns[i] = col.profile(a.cfg)
+	}
+	return p
+}
+
+// columnAcc accumulates statistics for a single column
+type columnAcc struct {
+	col     dsio.Column
+	count   int64
+	nulls   int64
+	types   map[vals.Type]int64
+	min     interface{}
+	max     interface{}
+	numbers *numericAcc
+	lengths *numericAcc
+	top     *topK
+}
+
+func newColumnAcc(col dsio.Column, cfg *Config) *columnAcc {
+	capacity := cfg.TopK * 10
+	if capacity < 100 {
+		capacity = 100
+	}
+	return &columnAcc{
+		col:     col,
+		types:   map[vals.Type]int64{},
+		numbers: newNumericAcc(cfg.SketchSize),
+		lengths: newNumericAcc(cfg.SketchSize),
+		top:     newTopK(capacity),
+	}
+}
+
+func (c *columnAcc) add(v interface{}) {
+	c.count++
+	c.types[inferType(v)]++
+	if v == nil {
+		c.nulls++
+		return
+	}
+
+	if c.min == nil || compare(v, c.min) < 0 {
+		c.min = v
+	}
+	if c.max == nil || compare(v, c.max) > 0 {
+		c.max = v
+	}
+
+	switch x := v.(type) {
+	case int:
+		c.numbers.add(float64(x))
+	case int64:
+		c.numbers.add(float64(x))
+	case float64:
+		c.numbers.add(x)
+	case string:
+		c.lengths.add(float64(utf8.RuneCountInString(x)))
+	}
+
+	switch v.(type) {
+	case []interface{}, map[string]interface{}:
+		// only scalar values are counted for frequency
+	default:
+		c.top.add(v)
+	}
+}
+
+func (c *columnAcc) profile(cfg *Config) *ColumnProfile {
+	p := &ColumnProfile{
+		Title:        c.col.Title,
+		Type:         c.col.Type.String(),
+		Count:        c.count,
+		NullCount:    c.nulls,
+		Types:        map[string]int64{},
+		Min:          c.min,
+		Max:          c.max,
+		Numeric:      c.numbers.profile(cfg),
+		StringLength: c.lengths.profile(cfg),
+	}
+	for t, n := range c.types {
+		p.Types[t.String()] = n
+	}
+	if cfg.TopK > 0 {
+		p.TopK = c.top.top(cfg.TopK)
+	}
+	return p
+}
+
+// inferType determines the type of a decoded value. strings are examined
+// for values that look like other types, which is common in CSV data
+func inferType(v interface{}) vals.Type {
+	switch x := v.(type) {
+	case nil:
+		return vals.TypeNull
+	case int, int64:
+		return vals.TypeInteger
+	case float64:
+		return vals.TypeNumber
+	case bool:
+		return vals.TypeBoolean
+	case []interface{}:
+		return vals.TypeArray
+	case map[string]interface{}:
+		return vals.TypeObject
+	case string:
+		if x == "" {
+			return vals.TypeString
+		}
+		switch t := vals.ParseType([]byte(x)); t {
+		case vals.TypeObject, vals.TypeArray:
+			// string content that merely starts with a bracket is still a string
+			if _, err := vals.ParseJSON([]byte(x)); err != nil {
+				return vals.TypeString
+			}
+			return t
+		default:
+			return t
+		}
+	}
+	return vals.TypeUnknown
+}
+
+// compare orders two values, comparing numbers numerically & everything else
+// by the ordering dsio uses for strings
+func compare(a, b interface{}) int {
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+	if aNum && bNum {
+		if af < bf {
+			return -1
+		} else if af > bf {
+			return 1
+		}
+		return 0
+	}
+	return dsio.CompareValues(a, b, vals.TypeString)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// numericAcc computes running numeric statistics, using Welford's method
+// for mean & variance
+type numericAcc struct {
+	n      int64
+	min    float64
+	max    float64
+	mean   float64
+	m2     float64
+	sketch *sketch
+}
+
+func newNumericAcc(sketchSize int) *numericAcc {
+	return &numericAcc{sketch: newSketch(sketchSize)}
+}
+
+func (n *numericAcc) add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	n.n++
+	if n.n == 1 || v < n.min {
+		n.min = v
+	}
+	if n.n == 1 || v > n.max {
+		n.max = v
+	}
+	delta := v - n.mean
+	n.mean += delta / float64(n.n)
+	n.m2 += delta * (v - n.mean)
+	n.sketch.add(v)
+}
+
+func (n *numericAcc) profile(cfg *Config) *NumericProfile {
+	if n.n == 0 {
+		return nil
+	}
+	return &NumericProfile{
+		Count:     n.n,
+		Min:       n.min,
+		Max:       n.max,
+		Mean:      n.mean,
+		StdDev:    math.Sqrt(n.m2 / float64(n.n)),
+		Quantiles: n.sketch.quantiles(cfg.Quantiles),
+		Histogram: n.sketch.histogram(n.min, n.max, cfg.HistogramBins),
+	}
+}
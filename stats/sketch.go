@@ -0,0 +1,155 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// sketch is a KLL-style quantile sketch. Values are held in levels of
+// bounded size. When a level fills it's sorted & every other value is
+// promoted to the next level, doubling the weight each promoted value
+// carries. Memory use grows logarithmically with the number of values, and
+// while fewer than k values have been added results are exact
+type sketch struct {
+	k      int
+	n      int64
+	levels [][]float64
+	// odd alternates which half of a compacted level is kept. a
+	// deterministic choice keeps profiles of identical bodies identical
+	odd bool
+}
+
+func newSketch(k int) *sketch {
+	if k < 8 {
+		k = 8
+	}
+	return &sketch{k: k, levels: [][]float64{nil}}
+}
+
+func (s *sketch) add(v float64) {
+	s.n++
+	s.levels[0] = append(s.levels[0], v)
+	for h := 0; h < len(s.levels); h++ {
+		if len(s.levels[h]) < s.k {
+			break
+		}
+		s.compact(h)
+	}
+}
+
+// compact halves level h, promoting survivors to level h+1
+func (s *sketch) compact(h int) {
+	if h+1 == len(s.levels) {
+		s.levels = append(s.levels, nil)
+	}
+	lvl := s.levels[h]
+	sort.Float64s(lvl)
+	start := 0
+	if s.odd {
+		start = 1
+	}
+	s.odd = !s.odd
+	for i := start; i < len(lvl); i += 2 {
+		s.levels[h+1] = append(s.levels[h+1], lvl[i])
+	}
+	s.levels[h] = lvl[:0]
+}
+
+type weighted struct {
+	v float64
+	w int64
+}
+
+// items gives all retained values with their weights, sorted by value
+func (s *sketch) items() ([]weighted, int64) {
+	var (
+		items []weighted
+		total int64
+	)
+	for h, lvl := range s.levels {
+		w := int64(1) << uint(h)
+		for _, v := range lvl {
+			items = append(items, weighted{v, w})
+			total += w
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].v < items[j].v })
+	return items, total
+}
+
+// quantiles estimates the values at each rank q in [0, 1]
+func (s *sketch) quantiles(qs []float64) []Quantile {
+	items, total := s.items()
+	if total == 0 {
+		return nil
+	}
+
+	res := make([]Quantile, len(qs))
+	for i, q := range qs {
+		target := int64(math.Ceil(q * float64(total)))
+		if target < 1 {
+			target = 1
+		}
+		var cum int64
+		res[i] = Quantile{Q: q, Value: items[len(items)-1].v}
+		for _, it := range items {
+			cum += it.w
+			if cum >= target {
+				res[i].Value = it.v
+				break
+			}
+		}
+	}
+	return res
+}
+
+// histogram distributes retained values into equal-width bins between min
+// and max. Counts are exact while the sketch hasn't compacted, and scaled
+// estimates afterward
+func (s *sketch) histogram(min, max float64, bins int) []Bin {
+	items, total := s.items()
+	if total == 0 || bins < 1 {
+		return nil
+	}
+	if min == max {
+		return []Bin{{Min: min, Max: max, Count: s.n}}
+	}
+
+	width := (max - min) / float64(bins)
+	hist := make([]Bin, bins)
+	weights := make([]float64, bins)
+	for i := range hist {
+		hist[i].Min = min + float64(i)*width
+		hist[i].Max = min + float64(i+1)*width
+	}
+	hist[bins-1].Max = max
+
+	for _, it := range items {
+		i := int((it.v - min) / width)
+		if i >= bins {
+			i = bins - 1
+		} else if i < 0 {
+			i = 0
+		}
+		weights[i] += float64(it.w)
+	}
+
+	// scale weights so bin counts sum to the number of values added
+	scale := float64(s.n) / float64(total)
+	var assigned int64
+	for i := range hist {
+		hist[i].Count = int64(math.Round(weights[i] * scale))
+		assigned += hist[i].Count
+	}
+	// put any rounding remainder in the largest bin
+	if diff := s.n - assigned; diff != 0 {
+		largest := 0
+		for i := range hist {
+			if hist[i].Count > hist[largest].Count {
+				largest = i
+			}
+		}
+		hist[largest].Count += diff
+	}
+	return hist
+}
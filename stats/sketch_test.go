@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSketchQuantiles(t *testing.T) {
+	const n = 100000
+	s := newSketch(200)
+	// add values in a scrambled order to exercise compaction
+	for i := 0; i < n; i++ {
+		s.add(float64((i * 7919) % n))
+	}
+
+	if len(s.levels) < 2 {
+		t.Fatalf("expected sketch to compact")
+	}
+
+	for _, q := range s.quantiles([]float64{0.01, 0.25, 0.5, 0.75, 0.99}) {
+		expect := q.Q * n
+		if err := math.Abs(q.Value-expect) / n; err > 0.02 {
+			t.Errorf("quantile %f out of bounds. expected ~%f, got %f (rank error %f)", q.Q, expect, q.Value, err)
+		}
+	}
+
+	var total int64
+	for _, b := range s.histogram(0, n-1, 10) {
+		total += b.Count
+		if math.Abs(float64(b.Count)-n/10) > n/100 {
+			t.Errorf("bin [%f, %f) count %d too far from %d", b.Min, b.Max, b.Count, n/10)
+		}
+	}
+	if total != n {
+		t.Errorf("histogram counts should sum to %d, got %d", n, total)
+	}
+}
+
+func TestSketchEmpty(t *testing.T) {
+	s := newSketch(0)
+	if q := s.quantiles([]float64{0.5}); q != nil {
+		t.Errorf("expected no quantiles for an empty sketch, got: %v", q)
+	}
+	if h := s.histogram(0, 1, 4); h != nil {
+		t.Errorf("expected no histogram for an empty sketch, got: %v", h)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	tk := newTopK(3)
+	for _, v := range []interface{}{"a", "b", "a", "c", "a", "b", "d", "a"} {
+		tk.add(v)
+	}
+	got := tk.top(2)
+	if len(got) != 2 || got[0].Value != "a" || got[0].Count != 4 {
+		t.Errorf("expected 'a' to be most frequent with count 4, got: %v", got)
+	}
+}
@@ -0,0 +1,124 @@
+// Package stats profiles the body of a dataset, producing a summary of each
+// column in a single pass over a dsio.EntryReader. Profiles include counts,
+// null counts, min & max values, numeric distributions with approximate
+// quantiles & histograms, frequent values, string length distributions and
+// the distribution of inferred value types.
+//
+// Profiles are plain structs that serialize to JSON, suitable for storing
+// alongside a dataset's structure in the dataset.Stats component
+package stats
+
+import (
+	"fmt"
+
+	logger "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset/dsio"
+)
+
+var log = logger.Logger("stats")
+
+// Config configures profiling
+type Config struct {
+	// TopK is the number of most-frequent values to report per column
+	TopK int
+	// Quantiles lists the ranks, between 0 & 1, to estimate values for
+	Quantiles []float64
+	// HistogramBins is the number of equal-width bins numeric histograms use
+	HistogramBins int
+	// SketchSize bounds the number of values each quantile sketch level
+	// holds. Larger sketches are more accurate & use more memory
+	SketchSize int
+}
+
+// DefaultConfig returns the configuration used when none is given
+func DefaultConfig() *Config {
+	return &Config{
+		TopK:          10,
+		Quantiles:     []float64{0.25, 0.5, 0.75},
+		HistogramBins: 10,
+		SketchSize:    200,
+	}
+}
+
+// Profile summarizes the body of a dataset
+type Profile struct {
+	// Count is the number of entries profiled
+	Count int64 `json:"count"`
+	// Columns holds a profile for each column of the body
+	Columns []*ColumnProfile `json:"columns"`
+}
+
+// ColumnProfile summarizes the values of a single column
+type ColumnProfile struct {
+	// Title of the column
+	Title string `json:"title"`
+	// Type is the type the schema declares for this column, if any
+	Type string `json:"type,omitempty"`
+	// Count is the number of values profiled, including nulls
+	Count int64 `json:"count"`
+	// NullCount is the number of null or missing values
+	NullCount int64 `json:"nullCount"`
+	// Types counts values by inferred type name
+	Types map[string]int64 `json:"types,omitempty"`
+	// Min is the smallest non-null value
+	Min interface{} `json:"min,omitempty"`
+	// Max is the largest non-null value
+	Max interface{} `json:"max,omitempty"`
+	// Numeric describes the distribution of numeric values
+	Numeric *NumericProfile `json:"numeric,omitempty"`
+	// StringLength describes the distribution of string value lengths
+	StringLength *NumericProfile `json:"stringLength,omitempty"`
+	// TopK lists the most frequent values. Counts are approximate for columns
+	// with many distinct values
+	TopK []Frequency `json:"topK,omitempty"`
+}
+
+// NumericProfile describes a distribution of numbers
+type NumericProfile struct {
+	Count  int64   `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	// Quantiles are approximate values at configured ranks
+	Quantiles []Quantile `json:"quantiles,omitempty"`
+	// Histogram counts values in equal-width bins
+	Histogram []Bin `json:"histogram,omitempty"`
+}
+
+// Quantile is the estimated value at a rank Q between 0 and 1
+type Quantile struct {
+	Q     float64 `json:"q"`
+	Value float64 `json:"value"`
+}
+
+// Bin is a histogram bucket covering [Min, Max)
+type Bin struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
+}
+
+// Frequency pairs a value with the number of times it occurs
+type Frequency struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+// Compute profiles all entries of a reader
+func Compute(r dsio.EntryReader, configs ...func(cfg *Config)) (*Profile, error) {
+	acc := NewAccumulator(r.Structure(), configs...)
+	if err := dsio.EachEntry(r, func(_ int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		return acc.WriteEntry(ent)
+	}); err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("reading entries: %s", err.Error())
+	}
+	if err := acc.Close(); err != nil {
+		return nil, err
+	}
+	return acc.Profile(), nil
+}
@@ -0,0 +1,150 @@
+package stats
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+var citiesStructure = &dataset.Structure{
+	Format: "json",
+	Schema: map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+				map[string]interface{}{"title": "in_usa", "type": "boolean"},
+			},
+		},
+	},
+}
+
+const citiesBody = `[
+["toronto",40000000,false],
+["new york",8500000,true],
+["chicago",300000,true],
+["chatham",35000,true],
+["raleigh",250000,true],
+["new york",null,"1"]
+]`
+
+func TestCompute(t *testing.T) {
+	r, err := dsio.NewJSONReader(citiesStructure, strings.NewReader(citiesBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Compute(r, func(cfg *Config) {
+		cfg.TopK = 2
+		cfg.Quantiles = []float64{0, 0.5, 1}
+		cfg.HistogramBins = 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := &Profile{
+		Count: 6,
+		Columns: []*ColumnProfile{
+			{
+				Title:     "city",
+				Type:      "string",
+				Count:     6,
+				NullCount: 0,
+				Types:     map[string]int64{"string": 6},
+				Min:       "chatham",
+				Max:       "toronto",
+				StringLength: &NumericProfile{
+					Count:     6,
+					Min:       7,
+					Max:       8,
+					Mean:      7.333333333333334,
+					StdDev:    0.47140452079103157,
+					Quantiles: []Quantile{{0, 7}, {0.5, 7}, {1, 8}},
+					Histogram: []Bin{{7, 7.5, 4}, {7.5, 8, 2}},
+				},
+				TopK: []Frequency{{"new york", 2}, {"chatham", 1}},
+			},
+			{
+				Title:     "pop",
+				Type:      "integer",
+				Count:     6,
+				NullCount: 1,
+				Types:     map[string]int64{"integer": 5, "null": 1},
+				Min:       int64(35000),
+				Max:       int64(40000000),
+				Numeric: &NumericProfile{
+					Count:     5,
+					Min:       35000,
+					Max:       40000000,
+					Mean:      9817000,
+					StdDev:    15430724.415917745,
+					Quantiles: []Quantile{{0, 35000}, {0.5, 300000}, {1, 40000000}},
+					Histogram: []Bin{{35000, 20017500, 4}, {20017500, 40000000, 1}},
+				},
+				TopK: []Frequency{{int64(250000), 1}, {int64(300000), 1}},
+			},
+			{
+				Title:     "in_usa",
+				Type:      "boolean",
+				Count:     6,
+				NullCount: 0,
+				Types:     map[string]int64{"boolean": 5, "integer": 1},
+				Min:       "1",
+				Max:       true,
+				StringLength: &NumericProfile{
+					Count:     1,
+					Min:       1,
+					Max:       1,
+					Mean:      1,
+					Quantiles: []Quantile{{0, 1}, {0.5, 1}, {1, 1}},
+					Histogram: []Bin{{1, 1, 1}},
+				},
+				TopK: []Frequency{{true, 4}, {"1", 1}},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("profile mismatch (-want +got):\n%s", diff)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Profile{}
+	if err := json.Unmarshal(data, p); err != nil {
+		t.Fatalf("profile should round-trip through JSON: %s", err)
+	}
+	if len(p.Columns) != 3 || p.Columns[1].Numeric.Mean != 9817000 {
+		t.Errorf("JSON round trip mismatch: %s", string(data))
+	}
+}
+
+func TestComputeDiscoveredColumns(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader(`[{"a":1},{"a":2,"b":"x"},{"b":"yy"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Compute(r, func(cfg *Config) { cfg.TopK = 0 })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Count != 3 || len(got.Columns) != 2 {
+		t.Fatalf("expected 3 entries & 2 columns, got %d entries & %d columns", got.Count, len(got.Columns))
+	}
+	for _, col := range got.Columns {
+		if col.Count != 3 || col.NullCount != 1 {
+			t.Errorf("column %s expected count 3 with 1 null. got count %d with %d nulls", col.Title, col.Count, col.NullCount)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// topK tracks the most frequent values in a stream using the space-saving
+// algorithm. Counts are exact while fewer than capacity distinct values have
+// been seen, and may overestimate afterward
+type topK struct {
+	capacity int
+	counters map[string]*counter
+}
+
+type counter struct {
+	value interface{}
+	count int64
+}
+
+func newTopK(capacity int) *topK {
+	return &topK{capacity: capacity, counters: map[string]*counter{}}
+}
+
+func (t *topK) add(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	key := string(data)
+
+	if c, ok := t.counters[key]; ok {
+		c.count++
+		return
+	}
+	if len(t.counters) < t.capacity {
+		t.counters[key] = &counter{value: v, count: 1}
+		return
+	}
+
+	// replace the least frequent counter, inheriting its count
+	var (
+		minKey string
+		min    *counter
+	)
+	for k, c := range t.counters {
+		if min == nil || c.count < min.count || (c.count == min.count && k < minKey) {
+			minKey, min = k, c
+		}
+	}
+	delete(t.counters, minKey)
+	t.counters[key] = &counter{value: v, count: min.count + 1}
+}
+
+// top gives up to k of the most frequent values, ordered by descending count
+func (t *topK) top(k int) []Frequency {
+	type keyed struct {
+		key string
+		c   *counter
+	}
+	all := make([]keyed, 0, len(t.counters))
+	for key, c := range t.counters {
+		all = append(all, keyed{key, c})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].c.count == all[j].c.count {
+			return all[i].key < all[j].key
+		}
+		return all[i].c.count > all[j].c.count
+	})
+	if len(all) > k {
+		all = all[:k]
+	}
+
+	freqs := make([]Frequency, len(all))
+	for i, kc := range all {
+		freqs[i] = Frequency{Value: kc.c.value, Count: kc.c.count}
+	}
+	return freqs
+}
@@ -0,0 +1,119 @@
+package dataset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStatsDropDerivedValues(t *testing.T) {
+	sa := &Stats{
+		Path: "/ipfs/QmHash",
+		Qri:  "oh you know it's qri",
+	}
+
+	sa.DropDerivedValues()
+
+	if !cmp.Equal(sa, &Stats{}, cmpopts.IgnoreUnexported(Stats{})) {
+		t.Errorf("expected dropping stats of only derived values to be empty")
+	}
+}
+
+func TestStatsIsEmpty(t *testing.T) {
+	cases := []struct {
+		sa       *Stats
+		expected bool
+	}{
+		{&Stats{}, true},
+		{&Stats{Qri: KindStats.String()}, true},
+		{&Stats{Path: "foo"}, true},
+		{&Stats{Stats: []interface{}{}}, false},
+	}
+
+	for i, c := range cases {
+		if c.sa.IsEmpty() != c.expected {
+			t.Errorf("case %d improperly reported stats as empty == %v", i, c.expected)
+		}
+	}
+}
+
+func TestStatsAssign(t *testing.T) {
+	got := &Stats{Path: "a", Stats: "replace me"}
+	got.Assign(nil, &Stats{Qri: KindStats.String(), Stats: []interface{}{"b"}})
+	expect := &Stats{Path: "a", Qri: KindStats.String(), Stats: []interface{}{"b"}}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStatsMarshalJSON(t *testing.T) {
+	cases := []struct {
+		in  *Stats
+		out string
+	}{
+		{&Stats{}, `{"qri":"sa:0"}`},
+		{&Stats{Stats: map[string]interface{}{"count": 2}}, `{"qri":"sa:0","stats":{"count":2}}`},
+		{&Stats{Path: "/map/QmHash"}, `"/map/QmHash"`},
+	}
+
+	for i, c := range cases {
+		got, err := json.Marshal(c.in)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+		if c.out != string(got) {
+			t.Errorf("case %d, %s != %s", i, c.out, string(got))
+		}
+	}
+}
+
+func TestStatsUnmarshalJSON(t *testing.T) {
+	sa := &Stats{}
+	if err := json.Unmarshal([]byte(`"/path/to/stats"`), sa); err != nil {
+		t.Fatal(err)
+	}
+	if sa.Path != "/path/to/stats" {
+		t.Errorf("unmarshal didn't set proper path: %s", sa.Path)
+	}
+
+	sa = &Stats{}
+	if err := json.Unmarshal([]byte(`{"stats":[{"title":"a"}]}`), sa); err != nil {
+		t.Fatal(err)
+	}
+	expect := &Stats{Qri: KindStats.String(), Stats: []interface{}{map[string]interface{}{"title": "a"}}}
+	if err := CompareStats(expect, sa); err != nil {
+		t.Error(err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"stats":`), sa); err == nil {
+		t.Errorf("expected invalid JSON to error")
+	}
+}
+
+func TestUnmarshalStats(t *testing.T) {
+	sa := Stats{Qri: KindStats.String()}
+	cases := []struct {
+		value interface{}
+		out   *Stats
+		err   string
+	}{
+		{sa, &sa, ""},
+		{&sa, &sa, ""},
+		{[]byte(`{"qri":"sa:0"}`), &Stats{Qri: KindStats.String()}, ""},
+		{5, nil, "couldn't parse stats, value is invalid type"},
+	}
+
+	for i, c := range cases {
+		got, err := UnmarshalStats(c.value)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+			continue
+		}
+		if err := CompareStats(c.out, got); err != nil {
+			t.Errorf("case %d Stats mismatch: %s", i, err.Error())
+		}
+	}
+}
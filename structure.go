@@ -208,6 +208,10 @@ func (s *Structure) UnmarshalJSON(data []byte) (err error) {
 		return nil
 	}
 
+	if data, err = upgradeJSON(data, KindStructure); err != nil {
+		return err
+	}
+
 	_s := _structure{}
 	if err := json.Unmarshal(data, &_s); err != nil {
 		return fmt.Errorf("error unmarshaling dataset structure from json: %s", err.Error())
@@ -289,13 +293,16 @@ func (s *Structure) Assign(structures ...*Structure) {
 
 // UnmarshalStructure tries to extract a structure type from an empty
 // interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
-func UnmarshalStructure(v interface{}) (*Structure, error) {
+func UnmarshalStructure(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Structure, error) {
 	switch r := v.(type) {
 	case *Structure:
 		return r, nil
 	case Structure:
 		return &r, nil
 	case []byte:
+		if err := checkStrict(KindStructure, r, configs); err != nil {
+			return nil, err
+		}
 		structure := &Structure{}
 		err := json.Unmarshal(r, structure)
 		return structure, err
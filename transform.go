@@ -255,6 +255,11 @@ func (q *Transform) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	data, err := upgradeJSON(data, KindTransform)
+	if err != nil {
+		return err
+	}
+
 	_q := _transform{}
 	if err := json.Unmarshal(data, &_q); err != nil {
 		return err
@@ -266,13 +271,16 @@ func (q *Transform) UnmarshalJSON(data []byte) error {
 
 // UnmarshalTransform tries to extract a resource type from an empty
 // interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
-func UnmarshalTransform(v interface{}) (*Transform, error) {
+func UnmarshalTransform(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Transform, error) {
 	switch q := v.(type) {
 	case *Transform:
 		return q, nil
 	case Transform:
 		return &q, nil
 	case []byte:
+		if err := checkStrict(KindTransform, q, configs); err != nil {
+			return nil, err
+		}
 		transform := &Transform{}
 		err := json.Unmarshal(q, transform)
 		return transform, err
@@ -11,8 +11,8 @@ import (
 // Viz stores configuration data related to representing a dataset as a
 // visualization
 type Viz struct {
-	// Format designates the visualization configuration syntax. currently the
-	// only supported syntax is "html"
+	// Format designates the visualization configuration syntax. currently
+	// supported syntaxes are "html" and "vega-lite"
 	Format string `json:"format,omitempty"`
 	// Path is the location of a viz, transient
 	// derived
@@ -173,6 +173,11 @@ func (v *Viz) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	data, err := upgradeJSON(data, KindViz)
+	if err != nil {
+		return err
+	}
+
 	_v := _viz{}
 	if err := json.Unmarshal(data, &_v); err != nil {
 		return err
@@ -187,13 +192,16 @@ func (v *Viz) UnmarshalJSON(data []byte) error {
 
 // UnmarshalViz tries to extract a resource type from an empty
 // interface. Pairs nicely with datastore.Get() from github.com/ipfs/go-datastore
-func UnmarshalViz(v interface{}) (*Viz, error) {
+func UnmarshalViz(v interface{}, configs ...func(cfg *UnmarshalCfg)) (*Viz, error) {
 	switch q := v.(type) {
 	case *Viz:
 		return q, nil
 	case Viz:
 		return &q, nil
 	case []byte:
+		if err := checkStrict(KindViz, q, configs); err != nil {
+			return nil, err
+		}
 		visConfig := &Viz{}
 		err := json.Unmarshal(q, visConfig)
 		return visConfig, err